@@ -0,0 +1,347 @@
+package pgx
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// isArraySliceType reports whether t is a Go slice type that maps to a
+// PostgreSQL array. []byte is not as it maps to bytea.
+func isArraySliceType(t reflect.Type) bool {
+	return t.Kind() == reflect.Slice && t.Elem().Kind() != reflect.Uint8
+}
+
+// arraySliceDepth returns the number of array dimensions that the nested
+// slice type t represents.
+func arraySliceDepth(t reflect.Type) int {
+	depth := 0
+	for isArraySliceType(t) {
+		depth++
+		t = t.Elem()
+	}
+	return depth
+}
+
+// binaryArrayArg reports whether arg is a slice whose innermost elements are
+// not strings. It is used to choose the format of array parameters for types
+// that are only sent as binary for specific Go types.
+func binaryArrayArg(arg interface{}) bool {
+	t := reflect.TypeOf(arg)
+	if t == nil || !isArraySliceType(t) {
+		return false
+	}
+	for isArraySliceType(t) {
+		t = t.Elem()
+	}
+	return t.Kind() != reflect.String
+}
+
+// decodeArray decodes an array of any number of dimensions into dest, which
+// must be a settable nested slice with one level of nesting per dimension.
+// Elements are decoded with Decode or, if the element type implements it,
+// Scanner. NULL elements can be decoded into pointer or Scanner (e.g.
+// NullInt32) elements. Go slices always start at 0, so the lower bounds of the
+// array are not preserved.
+func decodeArray(vr *ValueReader, dest reflect.Value) error {
+	if vr.Len() == -1 {
+		dest.Set(reflect.Zero(dest.Type()))
+		return nil
+	}
+
+	if vr.Type().FormatCode != BinaryFormatCode {
+		return ProtocolError(fmt.Sprintf("Unknown field description format code: %v", vr.Type().FormatCode))
+	}
+
+	numDims := vr.ReadInt32()
+	vr.ReadInt32() // 0 if no nulls / 1 if there is one or more nulls -- not needed as each element has its own size
+	elOid := vr.ReadOid()
+
+	if numDims < 0 {
+		return ProtocolError(fmt.Sprintf("Received an invalid number of array dimensions: %d", numDims))
+	}
+
+	dims := make([]int32, int(numDims))
+	for i := range dims {
+		dims[i] = vr.ReadInt32()
+		vr.ReadInt32() // lower bound
+		if dims[i] < 0 {
+			return ProtocolError(fmt.Sprintf("Received an invalid array dimension length: %d", dims[i]))
+		}
+	}
+
+	if vr.Err() != nil {
+		return vr.Err()
+	}
+
+	if numDims == 0 {
+		dest.Set(reflect.MakeSlice(dest.Type(), 0, 0))
+		return nil
+	}
+
+	if depth := arraySliceDepth(dest.Type()); depth != len(dims) {
+		return fmt.Errorf("Cannot decode %d dimensional array into %v", len(dims), dest.Type())
+	}
+
+	return decodeArrayDimension(vr, elOid, dims, dest)
+}
+
+func decodeArrayDimension(vr *ValueReader, elOid Oid, dims []int32, dest reflect.Value) error {
+	s := reflect.MakeSlice(dest.Type(), int(dims[0]), int(dims[0]))
+
+	for i := 0; i < s.Len(); i++ {
+		var err error
+		if len(dims) > 1 {
+			err = decodeArrayDimension(vr, elOid, dims[1:], s.Index(i))
+		} else {
+			err = decodeElement(vr, elOid, s.Index(i).Addr().Interface())
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	dest.Set(s)
+	return nil
+}
+
+// decodeArrayText decodes a binary format array into its text format. It
+// supports the element types that are read in binary format but scan into a
+// string in the text format and enums.
+func decodeArrayText(vr *ValueReader) string {
+	if vr.Len() == -1 {
+		vr.Fatal(ProtocolError("Cannot decode null into string"))
+		return ""
+	}
+
+	if vr.Type().FormatCode != BinaryFormatCode {
+		vr.Fatal(ProtocolError(fmt.Sprintf("Unknown field description format code: %v", vr.Type().FormatCode)))
+		return ""
+	}
+
+	numDims := vr.ReadInt32()
+	vr.ReadInt32() // has nulls
+	elOid := vr.ReadOid()
+
+	if numDims < 0 {
+		vr.Fatal(ProtocolError(fmt.Sprintf("Received an invalid number of array dimensions: %d", numDims)))
+		return ""
+	}
+
+	dims := make([]int32, int(numDims))
+	lowerBounds := make([]int32, int(numDims))
+	hasLowerBounds := false
+	for i := range dims {
+		dims[i] = vr.ReadInt32()
+		lowerBounds[i] = vr.ReadInt32()
+		if dims[i] < 0 {
+			vr.Fatal(ProtocolError(fmt.Sprintf("Received an invalid array dimension length: %d", dims[i])))
+			return ""
+		}
+		hasLowerBounds = hasLowerBounds || lowerBounds[i] != 1
+	}
+
+	if vr.Err() != nil {
+		return ""
+	}
+	if numDims == 0 {
+		return "{}"
+	}
+
+	buf := &bytes.Buffer{}
+	if hasLowerBounds {
+		for i := range dims {
+			fmt.Fprintf(buf, "[%d:%d]", lowerBounds[i], lowerBounds[i]+dims[i]-1)
+		}
+		buf.WriteByte('=')
+	}
+	decodeArrayTextDimension(vr, elOid, dims, buf)
+	if vr.Err() != nil {
+		return ""
+	}
+	return buf.String()
+}
+
+func decodeArrayTextDimension(vr *ValueReader, elOid Oid, dims []int32, buf *bytes.Buffer) {
+	buf.WriteByte('{')
+	for i := int32(0); i < dims[0]; i++ {
+		if i != 0 {
+			buf.WriteByte(',')
+		}
+		if len(dims) > 1 {
+			decodeArrayTextDimension(vr, elOid, dims[1:], buf)
+			continue
+		}
+
+		fd := FieldDescription{DataType: elOid, FormatCode: BinaryFormatCode}
+		elVR := ValueReader{mr: vr.mr, fd: &fd, conn: vr.conn}
+		elVR.valueBytesRemaining = vr.ReadInt32()
+		if elVR.valueBytesRemaining == -1 {
+			buf.WriteString("NULL")
+			continue
+		}
+		if elVR.valueBytesRemaining > 0 {
+			vr.valueBytesRemaining -= elVR.valueBytesRemaining
+		}
+
+		var el string
+		switch elOid {
+		case NumericOid:
+			el = decodeNumeric(&elVR).String()
+		case DateOid:
+			el = decodeDate(&elVR).Format("2006-01-02")
+		case UuidOid:
+			el = decodeUUID(&elVR).String()
+		default:
+			if _, ok := connInfoOf(vr.conn).enumOids[elOid]; !ok {
+				vr.Fatal(ProtocolError(fmt.Sprintf("Cannot decode array of oid %v into string", elOid)))
+				return
+			}
+			// The binary format of an enum is its label
+			el = elVR.ReadString(elVR.Len())
+		}
+		if elVR.Err() != nil {
+			vr.Fatal(elVR.Err())
+			return
+		}
+		buf.WriteString(quoteArrayElement(el))
+	}
+	buf.WriteByte('}')
+}
+
+// quoteArrayElement quotes s the way PostgreSQL does in the text format of an
+// array.
+func quoteArrayElement(s string) string {
+	if s != "" && !strings.EqualFold(s, "NULL") && !strings.ContainsAny(s, "{}\",\\ \t\n\r\v\f") {
+		return s
+	}
+	s = strings.Replace(s, `\`, `\\`, -1)
+	s = strings.Replace(s, `"`, `\"`, -1)
+	return `"` + s + `"`
+}
+
+// decodeElement decodes an array element or composite type attribute of type
+// elOid into d.
+func decodeElement(vr *ValueReader, elOid Oid, d interface{}) error {
+	fd := FieldDescription{DataType: elOid, FormatCode: BinaryFormatCode}
+	elVR := ValueReader{mr: vr.mr, fd: &fd, conn: vr.conn}
+	elVR.valueBytesRemaining = vr.ReadInt32()
+	if vr.Err() != nil {
+		return vr.Err()
+	}
+	if elVR.valueBytesRemaining > 0 {
+		vr.valueBytesRemaining -= elVR.valueBytesRemaining
+	}
+
+	var err error
+	if s, ok := d.(Scanner); ok {
+		err = s.Scan(&elVR)
+	} else if k := reflect.TypeOf(d).Elem().Kind(); elVR.Len() == -1 && k != reflect.Ptr && k != reflect.Interface {
+		return ProtocolError(fmt.Sprintf("Cannot decode null element into %v - use a pointer or Null* element type", reflect.TypeOf(d).Elem()))
+	} else {
+		err = Decode(&elVR, d)
+	}
+	if err != nil {
+		return err
+	}
+
+	// Consume any remaining data
+	if elVR.Len() > 0 {
+		elVR.ReadBytes(elVR.Len())
+	}
+
+	return elVR.Err()
+}
+
+// encodeArray encodes value, a nested slice with one level of nesting per
+// dimension, as an array of elOid. Elements are encoded with Encode so they
+// may be nil pointers or Null* types to encode NULL elements. Each dimension
+// must be rectangular (i.e. all slices at the same level must have the same
+// length).
+func encodeArray(w *WriteBuf, elOid Oid, value reflect.Value) error {
+	depth := arraySliceDepth(value.Type())
+	var dims []int32
+	for v := value; len(dims) < depth; v = v.Index(0) {
+		dims = append(dims, int32(v.Len()))
+		if v.Len() == 0 {
+			break
+		}
+	}
+
+	sizeIdx := len(w.buf)
+	w.WriteInt32(0) // size placeholder
+
+	if len(dims) < depth || dims[len(dims)-1] == 0 {
+		if arrayHasElements(value, depth) {
+			return fmt.Errorf("cannot encode Go %v into array - multidimensional arrays must have sub-arrays with matching dimensions", value.Type())
+		}
+
+		// An array with no elements has no dimensions
+		w.WriteInt32(0)            // number of dimensions
+		w.WriteInt32(0)            // no nulls
+		w.WriteInt32(int32(elOid)) // type of elements
+	} else {
+		w.WriteInt32(int32(len(dims))) // number of dimensions
+		nullsIdx := len(w.buf)
+		w.WriteInt32(0)            // has nulls placeholder
+		w.WriteInt32(int32(elOid)) // type of elements
+		for _, d := range dims {
+			w.WriteInt32(d) // number of elements
+			w.WriteInt32(1) // index of first element
+		}
+
+		hasNulls, err := encodeArrayDimension(w, elOid, dims, value)
+		if err != nil {
+			return err
+		}
+		if hasNulls {
+			binary.BigEndian.PutUint32(w.buf[nullsIdx:], 1)
+		}
+	}
+
+	binary.BigEndian.PutUint32(w.buf[sizeIdx:], uint32(len(w.buf)-sizeIdx-4))
+	return nil
+}
+
+// arrayHasElements reports whether any of the slices nested depth levels
+// deep in value contain elements.
+func arrayHasElements(value reflect.Value, depth int) bool {
+	if depth == 1 {
+		return value.Len() > 0
+	}
+	for i := 0; i < value.Len(); i++ {
+		if arrayHasElements(value.Index(i), depth-1) {
+			return true
+		}
+	}
+	return false
+}
+
+func encodeArrayDimension(w *WriteBuf, elOid Oid, dims []int32, value reflect.Value) (hasNulls bool, err error) {
+	if int32(value.Len()) != dims[0] {
+		return false, fmt.Errorf("cannot encode Go %v into array - multidimensional arrays must have sub-arrays with matching dimensions", value.Type())
+	}
+
+	for i := 0; i < value.Len(); i++ {
+		if len(dims) > 1 {
+			elHasNulls, err := encodeArrayDimension(w, elOid, dims[1:], value.Index(i))
+			if err != nil {
+				return false, err
+			}
+			hasNulls = hasNulls || elHasNulls
+			continue
+		}
+
+		elIdx := len(w.buf)
+		if err := Encode(w, elOid, value.Index(i).Interface()); err != nil {
+			return false, err
+		}
+		if int32(binary.BigEndian.Uint32(w.buf[elIdx:])) == -1 {
+			hasNulls = true
+		}
+	}
+
+	return hasNulls, nil
+}
@@ -0,0 +1,163 @@
+package pgx
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestArrayRoundTrip(t *testing.T) {
+	one, three := int32(1), int32(3)
+
+	tests := []struct {
+		oid   Oid
+		value interface{}
+	}{
+		{Int4ArrayOid, [][]int32{{1, 2, 3}, {4, 5, 6}}},
+		{Int8ArrayOid, [][][]int64{{{1}, {2}}, {{3}, {4}}, {{5}, {6}}}},
+		{Int4ArrayOid, []NullInt32{{Int32: 1, Valid: true}, {}, {Int32: 3, Valid: true}}},
+		{Int4ArrayOid, []*int32{&one, nil, &three}},
+		{TextArrayOid, [][]string{{"a", "b"}, {"c", "d"}}},
+		{TextArrayOid, [][]NullString{{{String: "a", Valid: true}}, {{}}}},
+		{Int4ArrayOid, [][]int32{}},
+	}
+
+	for i, tt := range tests {
+		w := &WriteBuf{}
+		if err := Encode(w, tt.oid, tt.value); err != nil {
+			t.Errorf("%d. Unexpected error: %v", i, err)
+			continue
+		}
+
+		vr := valueReaderFor(w.buf, tt.oid, BinaryFormatCode)
+		result := reflect.New(reflect.TypeOf(tt.value))
+		if err := Decode(vr, result.Interface()); err != nil {
+			t.Errorf("%d. Unexpected error: %v", i, err)
+			continue
+		}
+		if vr.Err() != nil {
+			t.Errorf("%d. Unexpected error: %v", i, vr.Err())
+			continue
+		}
+		if vr.Len() != 0 {
+			t.Errorf("%d. Expected all bytes to be read, %d remaining", i, vr.Len())
+		}
+
+		if !reflect.DeepEqual(result.Elem().Interface(), tt.value) {
+			t.Errorf("%d. Decoded %v, expected %v", i, result.Elem().Interface(), tt.value)
+		}
+	}
+}
+
+func TestArrayEncodeNonRectangular(t *testing.T) {
+	tests := []interface{}{
+		[][]int32{{1, 2}, {3}},
+		[][]int32{{}, {1, 2}},
+		[][][]int32{{{}}, {{1}}},
+	}
+
+	for i, tt := range tests {
+		w := &WriteBuf{}
+		if err := Encode(w, Int4ArrayOid, tt); err == nil {
+			t.Errorf("%d. Expected error encoding non-rectangular array %v", i, tt)
+		}
+	}
+}
+
+func TestArrayEncodeEmptySubArrays(t *testing.T) {
+	w := &WriteBuf{}
+	if err := Encode(w, Int4ArrayOid, [][]int32{{}, {}}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var a [][]int32
+	if err := Decode(valueReaderFor(w.buf, Int4ArrayOid, BinaryFormatCode), &a); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(a) != 0 {
+		t.Errorf("Expected empty array, got %v", a)
+	}
+}
+
+func TestArrayDecodeWrongDimensions(t *testing.T) {
+	w := &WriteBuf{}
+	if err := Encode(w, Int4ArrayOid, [][]int32{{1, 2}, {3, 4}}); err != nil {
+		t.Fatal(err)
+	}
+
+	var a [][][]int32
+	err := Decode(valueReaderFor(w.buf, Int4ArrayOid, BinaryFormatCode), &a)
+	if err == nil {
+		t.Error("Expected error decoding 2 dimensional array into [][][]int32")
+	}
+}
+
+func TestArrayDecodeNullIntoNonNullable(t *testing.T) {
+	w := &WriteBuf{}
+	if err := Encode(w, Int4ArrayOid, [][]NullInt32{{{Int32: 1, Valid: true}, {}}}); err != nil {
+		t.Fatal(err)
+	}
+
+	var a [][]int32
+	err := Decode(valueReaderFor(w.buf, Int4ArrayOid, BinaryFormatCode), &a)
+	if err == nil {
+		t.Error("Expected error decoding null element into int32")
+	}
+}
+
+func TestDecodeArrayText(t *testing.T) {
+	tests := []struct {
+		oid      Oid
+		value    interface{}
+		expected string
+	}{
+		{NumericArrayOid, []Numeric{{Int: big.NewInt(15), Exp: -1, Valid: true}, {}, {Int: big.NewInt(-2), Valid: true}}, "{1.5,NULL,-2}"},
+		{NumericArrayOid, [][]Numeric{{{Int: big.NewInt(1), Valid: true}}, {{Int: big.NewInt(2), Valid: true}}}, "{{1},{2}}"},
+		{NumericArrayOid, []Numeric{}, "{}"},
+		{DateArrayOid, []time.Time{time.Date(2016, 1, 1, 0, 0, 0, 0, time.Local), time.Date(2016, 2, 29, 0, 0, 0, 0, time.Local)}, "{2016-01-01,2016-02-29}"},
+		{UuidArrayOid, []UUID{{0xa0, 0xee, 0xbc, 0x99, 0x9c, 0x0b, 0x4e, 0xf8, 0xbb, 0x6d, 0x6b, 0xb9, 0xbd, 0x38, 0x0a, 0x11}}, "{a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11}"},
+	}
+
+	for i, tt := range tests {
+		w := &WriteBuf{}
+		if err := Encode(w, tt.oid, tt.value); err != nil {
+			t.Errorf("%d. Unexpected error: %v", i, err)
+			continue
+		}
+
+		vr := valueReaderFor(w.buf, tt.oid, BinaryFormatCode)
+		var s string
+		if err := Decode(vr, &s); err != nil {
+			t.Errorf("%d. Unexpected error: %v", i, err)
+			continue
+		}
+		if vr.Len() != 0 {
+			t.Errorf("%d. Expected all bytes to be read, %d remaining", i, vr.Len())
+		}
+		if s != tt.expected {
+			t.Errorf("%d. Expected %s, got %s", i, tt.expected, s)
+		}
+	}
+}
+
+func TestQuoteArrayElement(t *testing.T) {
+	tests := []struct {
+		s        string
+		expected string
+	}{
+		{"1", "1"},
+		{"", `""`},
+		{"null", `"null"`},
+		{"a b", `"a b"`},
+		{`a"b`, `"a\"b"`},
+		{`a\b`, `"a\\b"`},
+		{"a,b", `"a,b"`},
+	}
+
+	for i, tt := range tests {
+		if s := quoteArrayElement(tt.s); s != tt.expected {
+			t.Errorf("%d. Expected %s, got %s", i, tt.expected, s)
+		}
+	}
+}
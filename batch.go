@@ -0,0 +1,384 @@
+package pgx
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrNoMoreBatchResults occurs when more results are read from a
+// BatchResults than queries were queued in the Batch.
+var ErrNoMoreBatchResults = errors.New("no more results in batch")
+
+// ErrBatchResultsClosed occurs when results are read from a BatchResults that
+// has already been closed.
+var ErrBatchResultsClosed = errors.New("batch results are closed")
+
+// Batch is a queue of queries that are sent to the server together by
+// SendBatch. The zero value is an empty batch ready to use. A Batch is not
+// modified by SendBatch and may be sent multiple times.
+type Batch struct {
+	queries []batchQuery
+}
+
+type batchQuery struct {
+	sql  string
+	args []interface{}
+	ps   *PreparedStatement
+}
+
+// Queue adds a query to the batch. sql can be either a prepared statement name
+// or an SQL string. arguments should be referenced positionally from the sql
+// string as $1, $2, etc.
+func (b *Batch) Queue(sql string, arguments ...interface{}) {
+	b.queries = append(b.queries, batchQuery{sql: sql, args: arguments})
+}
+
+// Len returns the number of queries queued in b.
+func (b *Batch) Len() int {
+	return len(b.queries)
+}
+
+// BatchResults reads the results of a sent Batch. Results must be read in the
+// order the queries were queued. The connection is busy until Close is called,
+// so BatchResults MUST be closed.
+type BatchResults struct {
+	conn       *Conn
+	queries    []batchQuery
+	idx        int
+	rows       *Rows
+	err        error
+	done       bool // the ReadyForQuery ending the batch has been read
+	closed     bool
+	unlockConn bool
+	afterClose func(*BatchResults)
+}
+
+// SendBatch sends all queued queries in b to the server.
+//
+// Queries that are not already prepared statements are first described in a
+// single round trip to learn their parameter and result types. The queries
+// are then all sent in one write and their results read through the returned
+// *BatchResults. Therefore a batch requires at most two round trips no matter
+// how many queries it contains.
+//
+// The queries of a batch are executed as a unit. Outside of an explicit
+// transaction they run in a single implicit transaction, so an error in any
+// query rolls back the entire batch. Inside a transaction an error aborts the
+// transaction. Once an error has occurred, the failed query and all remaining
+// results return that error. An error while reading a result on the client
+// side, such as a Scan error, also discards the remaining results.
+//
+// Any error sending the batch is deferred to the *BatchResults.
+func (c *Conn) SendBatch(b *Batch) *BatchResults {
+	br := &BatchResults{
+		conn:    c,
+		queries: make([]batchQuery, len(b.queries)),
+	}
+	copy(br.queries, b.queries)
+
+	c.lastActivityTime = time.Now()
+
+	if err := c.lock(); err != nil {
+		br.done = true
+		br.err = err
+		return br
+	}
+	br.unlockConn = true
+
+	if len(br.queries) == 0 {
+		br.done = true
+		return br
+	}
+
+	if err := c.describeBatch(br.queries); err != nil {
+		br.done = true
+		br.err = err
+		return br
+	}
+
+	if err := c.sendBatch(br.queries); err != nil {
+		br.done = true
+		br.err = err
+		return br
+	}
+
+	return br
+}
+
+// describeBatch finds the prepared statement for each query or, for SQL
+// strings, describes all of them with one pipeline of Parse/Describe messages
+// on the unnamed statement.
+func (c *Conn) describeBatch(queries []batchQuery) error {
+	var wbuf *WriteBuf
+	var pending []*PreparedStatement
+
+	for i := range queries {
+		if ps, ok := c.preparedStatements[queries[i].sql]; ok {
+			queries[i].ps = ps
+			continue
+		}
+		if ps, ok := c.stmtCache.lookup(queries[i].sql); ok {
+			queries[i].ps = ps
+			continue
+		}
+
+		ps := &PreparedStatement{SQL: queries[i].sql}
+		queries[i].ps = ps
+		pending = append(pending, ps)
+
+		// parse
+		if wbuf == nil {
+			wbuf = newWriteBuf(c, 'P')
+		} else {
+			wbuf.startMsg('P')
+		}
+		wbuf.WriteCString("")
+		wbuf.WriteCString(ps.SQL)
+		wbuf.WriteInt16(0)
+
+		// describe
+		wbuf.startMsg('D')
+		wbuf.WriteByte('S')
+		wbuf.WriteCString("")
+	}
+
+	if len(pending) == 0 {
+		return nil
+	}
+
+	// sync
+	wbuf.startMsg('S')
+	wbuf.closeMsg()
+
+	_, err := c.conn.Write(wbuf.buf)
+	if err != nil {
+		c.die(err)
+		return err
+	}
+
+	var softErr error
+	described := 0
+
+	for {
+		t, r, err := c.rxMsg()
+		if err != nil {
+			return err
+		}
+
+		switch t {
+		case parseComplete:
+		case parameterDescription:
+			if described < len(pending) {
+				pending[described].ParameterOids = c.rxParameterDescription(r)
+			}
+		case rowDescription:
+			if described < len(pending) {
+				pending[described].FieldDescriptions = c.rxRowDescription(r)
+				c.setFieldFormats(pending[described].FieldDescriptions)
+			}
+			described++
+		case noData:
+			described++
+		case readyForQuery:
+			c.rxReadyForQuery(r)
+			return softErr
+		default:
+			if e := c.processContextFreeMsg(t, r); e != nil && softErr == nil {
+				softErr = e
+			}
+		}
+	}
+}
+
+// sendBatch writes Parse (for SQL strings), Bind and Execute messages for all
+// queries followed by a single Sync.
+func (c *Conn) sendBatch(queries []batchQuery) error {
+	var wbuf *WriteBuf
+	startMsg := func(t byte) {
+		if wbuf == nil {
+			wbuf = newWriteBuf(c, t)
+		} else {
+			wbuf.startMsg(t)
+		}
+	}
+
+	for _, q := range queries {
+		if q.ps.Name == "" {
+			startMsg('P')
+			wbuf.WriteCString("")
+			wbuf.WriteCString(q.ps.SQL)
+			wbuf.WriteInt16(int16(len(q.ps.ParameterOids)))
+			for _, oid := range q.ps.ParameterOids {
+				wbuf.WriteInt32(int32(oid))
+			}
+		}
+
+		startMsg('B')
+		if err := c.writeBind(wbuf, q.ps, q.args); err != nil {
+			return err
+		}
+
+		startMsg('E')
+		wbuf.WriteByte(0)
+		wbuf.WriteInt32(0)
+	}
+
+	startMsg('S')
+	wbuf.closeMsg()
+
+	_, err := c.conn.Write(wbuf.buf)
+	if err != nil {
+		c.die(err)
+	}
+
+	return err
+}
+
+// nextResult advances to the next query. Any unclosed *Rows from the previous
+// query are closed first.
+func (br *BatchResults) nextResult() error {
+	if br.closed {
+		return ErrBatchResultsClosed
+	}
+
+	if br.rows != nil {
+		br.rows.Close()
+		br.rows = nil
+	}
+
+	if br.err != nil {
+		return br.err
+	}
+
+	if br.idx >= len(br.queries) {
+		return ErrNoMoreBatchResults
+	}
+
+	br.idx++
+	return nil
+}
+
+// ExecResults reads the results of the next query in the batch as if it had
+// been sent with Exec.
+func (br *BatchResults) ExecResults() (CommandTag, error) {
+	if err := br.nextResult(); err != nil {
+		return CommandTag(""), err
+	}
+
+	for {
+		t, r, err := br.conn.rxMsg()
+		if err != nil {
+			br.fail(err)
+			return CommandTag(""), err
+		}
+
+		switch t {
+		case parseComplete, bindComplete, rowDescription, dataRow, noData:
+		case commandComplete:
+			return CommandTag(r.readCString()), nil
+		case emptyQueryResponse:
+			return CommandTag(""), nil
+		case readyForQuery:
+			br.conn.rxReadyForQuery(r)
+			br.done = true
+			err = ProtocolError("batch ended before all results were read")
+			br.fail(err)
+			return CommandTag(""), err
+		default:
+			if err = br.conn.processContextFreeMsg(t, r); err != nil {
+				br.fail(err)
+				return CommandTag(""), err
+			}
+		}
+	}
+}
+
+// QueryResults reads the results of the next query in the batch as if it had
+// been sent with Query. The returned *Rows should be closed before reading
+// the next result, but will be closed automatically if it is not.
+func (br *BatchResults) QueryResults() (*Rows, error) {
+	if err := br.nextResult(); err != nil {
+		// Because checking for errors can be deferred to the *Rows, build one with the error
+		return &Rows{closed: true, err: err}, err
+	}
+
+	q := &br.queries[br.idx-1]
+	rows := br.conn.getRows(q.sql, q.args)
+	rows.batch = br
+	rows.fields = q.ps.FieldDescriptions
+	br.rows = rows
+
+	return rows, nil
+}
+
+// QueryRowResults reads the results of the next query in the batch as if it
+// had been sent with QueryRow.
+func (br *BatchResults) QueryRowResults() *Row {
+	rows, _ := br.QueryResults()
+	return (*Row)(rows)
+}
+
+// Close reads any remaining results and makes the connection available for
+// use again. It returns the first error encountered by the batch, if any. It
+// is safe to call Close multiple times.
+func (br *BatchResults) Close() error {
+	if br.closed {
+		return br.err
+	}
+
+	for br.err == nil && br.idx < len(br.queries) {
+		br.ExecResults()
+	}
+	if br.rows != nil {
+		br.rows.Close()
+		br.rows = nil
+	}
+	br.drain()
+
+	br.closed = true
+	if br.unlockConn {
+		br.conn.unlock()
+		br.unlockConn = false
+	}
+
+	if br.afterClose != nil {
+		br.afterClose(br)
+	}
+
+	return br.err
+}
+
+// fail records err as the batch error and discards the rest of the batch.
+func (br *BatchResults) fail(err error) {
+	if br.err == nil {
+		br.err = err
+	}
+	br.drain()
+
+	if br.idx > 0 {
+		br.conn.invalidateCachedStatement(br.queries[br.idx-1].sql, err)
+	}
+}
+
+// drain reads and discards messages through the ReadyForQuery that ends the
+// batch.
+func (br *BatchResults) drain() {
+	for !br.done {
+		t, r, err := br.conn.rxMsg()
+		if err != nil {
+			if br.err == nil {
+				br.err = err
+			}
+			br.done = true
+			return
+		}
+
+		switch t {
+		case readyForQuery:
+			br.conn.rxReadyForQuery(r)
+			br.done = true
+		case noticeResponse, notificationResponse, 'S':
+			br.conn.processContextFreeMsg(t, r)
+		}
+	}
+}
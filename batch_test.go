@@ -0,0 +1,306 @@
+package pgx_test
+
+import (
+	"testing"
+
+	"github.com/jackc/pgx"
+)
+
+func TestConnSendBatch(t *testing.T) {
+	t.Parallel()
+
+	conn := mustConnect(t, *defaultConnConfig)
+	defer closeConn(t, conn)
+
+	mustExec(t, conn, "create temporary table ledger(id serial primary key, description varchar not null, amount int not null)")
+
+	batch := &pgx.Batch{}
+	batch.Queue("insert into ledger(description, amount) values($1, $2)", "q1", 1)
+	batch.Queue("insert into ledger(description, amount) values($1, $2)", "q2", 2)
+	batch.Queue("insert into ledger(description, amount) values($1, $2)", "q3", 3)
+	batch.Queue("select id, description, amount from ledger order by id")
+	batch.Queue("select sum(amount) from ledger")
+
+	br := conn.SendBatch(batch)
+
+	for i := 0; i < 3; i++ {
+		ct, err := br.ExecResults()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ct.RowsAffected() != 1 {
+			t.Errorf("ct.RowsAffected() => %v, want %v", ct.RowsAffected(), 1)
+		}
+	}
+
+	rows, err := br.QueryResults()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var id int32
+	var description string
+	var amount int32
+	var rowCount int
+	for rows.Next() {
+		rowCount++
+		if err := rows.Scan(&id, &description, &amount); err != nil {
+			t.Fatal(err)
+		}
+		if id != int32(rowCount) || amount != int32(rowCount) {
+			t.Errorf("Unexpected row %d: id=%d description=%s amount=%d", rowCount, id, description, amount)
+		}
+	}
+	if rows.Err() != nil {
+		t.Fatal(rows.Err())
+	}
+	if rowCount != 3 {
+		t.Errorf("Expected 3 rows, got %d", rowCount)
+	}
+
+	var sum int64
+	err = br.QueryRowResults().Scan(&sum)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sum != 6 {
+		t.Errorf("sum => %v, want %v", sum, 6)
+	}
+
+	if _, err := br.ExecResults(); err != pgx.ErrNoMoreBatchResults {
+		t.Errorf("Expected ErrNoMoreBatchResults, got %v", err)
+	}
+
+	if err := br.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	ensureConnValid(t, conn)
+}
+
+func TestConnSendBatchWithPreparedStatement(t *testing.T) {
+	t.Parallel()
+
+	conn := mustConnect(t, *defaultConnConfig)
+	defer closeConn(t, conn)
+
+	if _, err := conn.Prepare("ps1", "select n from generate_series(0,$1::int) n"); err != nil {
+		t.Fatal(err)
+	}
+
+	batch := &pgx.Batch{}
+	queryCount := 3
+	for i := 0; i < queryCount; i++ {
+		batch.Queue("ps1", 5)
+	}
+
+	br := conn.SendBatch(batch)
+
+	for i := 0; i < queryCount; i++ {
+		rows, err := br.QueryResults()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for k := 0; rows.Next(); k++ {
+			var n int
+			if err := rows.Scan(&n); err != nil {
+				t.Fatal(err)
+			}
+			if n != k {
+				t.Fatalf("n => %v, want %v", n, k)
+			}
+		}
+
+		if rows.Err() != nil {
+			t.Fatal(rows.Err())
+		}
+	}
+
+	if err := br.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	ensureConnValid(t, conn)
+}
+
+func TestConnSendBatchResultsClosedEarly(t *testing.T) {
+	t.Parallel()
+
+	conn := mustConnect(t, *defaultConnConfig)
+	defer closeConn(t, conn)
+
+	batch := &pgx.Batch{}
+	batch.Queue("select generate_series(1,1000)")
+	batch.Queue("select 1")
+	batch.Queue("select 2")
+
+	br := conn.SendBatch(batch)
+
+	// Leave rows unread and unclosed
+	if _, err := br.QueryResults(); err != nil {
+		t.Fatal(err)
+	}
+
+	var n int32
+	if err := br.QueryRowResults().Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Errorf("n => %v, want %v", n, 1)
+	}
+
+	if err := br.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	ensureConnValid(t, conn)
+}
+
+func TestConnSendBatchErrorAbortsBatch(t *testing.T) {
+	t.Parallel()
+
+	conn := mustConnect(t, *defaultConnConfig)
+	defer closeConn(t, conn)
+
+	mustExec(t, conn, "create temporary table ledger(id int primary key)")
+
+	batch := &pgx.Batch{}
+	batch.Queue("insert into ledger(id) values(1)")
+	batch.Queue("insert into ledger(id) values(1)")
+	batch.Queue("insert into ledger(id) values(2)")
+
+	br := conn.SendBatch(batch)
+
+	if _, err := br.ExecResults(); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := br.ExecResults()
+	if pgErr, ok := err.(pgx.PgError); !ok || pgErr.Code != "23505" {
+		t.Fatalf("Expected unique_violation, got %v", err)
+	}
+
+	if _, err := br.ExecResults(); err == nil {
+		t.Error("Expected error for query after failed query")
+	}
+
+	if err := br.Close(); err == nil {
+		t.Error("Expected Close to return batch error")
+	}
+
+	// The implicit transaction rolled back the entire batch
+	var count int64
+	if err := conn.QueryRow("select count(*) from ledger").Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+	if count != 0 {
+		t.Errorf("count => %v, want %v", count, 0)
+	}
+
+	ensureConnValid(t, conn)
+}
+
+func TestConnSendBatchSyntaxError(t *testing.T) {
+	t.Parallel()
+
+	conn := mustConnect(t, *defaultConnConfig)
+	defer closeConn(t, conn)
+
+	batch := &pgx.Batch{}
+	batch.Queue("select 1")
+	batch.Queue("selct 2")
+
+	br := conn.SendBatch(batch)
+
+	var n int32
+	if err := br.QueryRowResults().Scan(&n); err == nil {
+		t.Error("Expected syntax error to fail entire batch")
+	}
+
+	if err := br.Close(); err == nil {
+		t.Error("Expected Close to return batch error")
+	}
+
+	ensureConnValid(t, conn)
+}
+
+func TestTxSendBatch(t *testing.T) {
+	t.Parallel()
+
+	conn := mustConnect(t, *defaultConnConfig)
+	defer closeConn(t, conn)
+
+	mustExec(t, conn, "create temporary table ledger(id int primary key)")
+
+	tx, err := conn.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	batch := &pgx.Batch{}
+	batch.Queue("insert into ledger(id) values($1)", 1)
+	batch.Queue("insert into ledger(id) values($1)", 2)
+
+	br := tx.SendBatch(batch)
+	if err := br.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := tx.Rollback(); err != nil {
+		t.Fatal(err)
+	}
+
+	var count int64
+	if err := conn.QueryRow("select count(*) from ledger").Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+	if count != 0 {
+		t.Errorf("count => %v, want %v", count, 0)
+	}
+
+	br = tx.SendBatch(batch)
+	if err := br.Close(); err != pgx.ErrTxClosed {
+		t.Errorf("Expected ErrTxClosed, got %v", err)
+	}
+
+	ensureConnValid(t, conn)
+}
+
+func TestPoolSendBatch(t *testing.T) {
+	t.Parallel()
+
+	pool := createConnPool(t, 2)
+	defer pool.Close()
+
+	batch := &pgx.Batch{}
+	batch.Queue("select $1::int", 1)
+	batch.Queue("select $1::text", "foo")
+
+	br := pool.SendBatch(batch)
+
+	var n int32
+	if err := br.QueryRowResults().Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Errorf("n => %v, want %v", n, 1)
+	}
+
+	var s string
+	if err := br.QueryRowResults().Scan(&s); err != nil {
+		t.Fatal(err)
+	}
+	if s != "foo" {
+		t.Errorf("s => %v, want %v", s, "foo")
+	}
+
+	if err := br.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	stats := pool.Stat()
+	if stats.CurrentConnections != stats.AvailableConnections {
+		t.Errorf("Expected all connections to be released, got %+v", stats)
+	}
+}
@@ -0,0 +1,270 @@
+package pgx
+
+import (
+	"encoding/binary"
+	"fmt"
+	"reflect"
+)
+
+// CompositeType describes a composite type registered with
+// RegisterCompositeType.
+type CompositeType struct {
+	Name     string
+	Oid      Oid
+	ArrayOid Oid // 0 if the type has no array type
+	Fields   []CompositeField
+}
+
+// CompositeField is an attribute of a CompositeType.
+type CompositeField struct {
+	Name     string
+	DataType Oid
+}
+
+// RegisterCompositeType loads the attributes of the composite type name (e.g.
+// a type created with CREATE TYPE ... AS or the row type of a table) so that
+// values of the type and arrays of the type can be binary encoded and decoded.
+// name may be schema qualified. Composite types used by the attributes of name
+// are registered as well.
+//
+// A registered composite type can be encoded from and decoded into a struct or
+// a []interface{}. Struct fields are matched to attributes in the same way
+// ScanStruct matches them to columns. Every attribute must have a matching
+// field. A []interface{} holds the attributes in order. Use a pointer to a
+// struct for values that may be NULL.
+//
+// Registrations belong to the connection. With a ConnPool, register composite
+// types in ConnPoolConfig.AfterConnect.
+func (c *Conn) RegisterCompositeType(name string) (*CompositeType, error) {
+	var oid Oid
+	if err := c.QueryRow("select $1::regtype::oid", name).Scan(&oid); err != nil {
+		return nil, err
+	}
+
+	if err := c.registerCompositeType(oid); err != nil {
+		return nil, err
+	}
+
+	ct, _ := c.CompositeType(oid)
+	return ct, nil
+}
+
+// CompositeType returns the composite type with oid registered with
+// RegisterCompositeType.
+func (c *Conn) CompositeType(oid Oid) (*CompositeType, bool) {
+	return registeredCompositeType(c, oid)
+}
+
+func (c *Conn) registerCompositeType(oid Oid) error {
+	ct := &CompositeType{Oid: oid}
+
+	var typtype Char
+	var relid Oid
+	var arrayName Name
+	err := c.QueryRow(`select t.typname, t.typtype, t.typrelid, t.typarray, coalesce(a.typname, '')
+from pg_type t
+left join pg_type a on a.oid=t.typarray
+where t.oid=$1`, oid).Scan(&ct.Name, &typtype, &relid, &ct.ArrayOid, &arrayName)
+	if err != nil {
+		return err
+	}
+	if typtype != 'c' {
+		return fmt.Errorf("%s is not a composite type", ct.Name)
+	}
+
+	// Composite types used by attributes directly or as array elements
+	var nested []Oid
+
+	rows, err := c.Query(`select a.attname, a.atttypid, t.typtype='c', coalesce(e.typtype='c', false), t.typelem
+from pg_attribute a
+join pg_type t on t.oid=a.atttypid
+left join pg_type e on e.oid=t.typelem
+where a.attrelid=$1 and a.attnum > 0 and not a.attisdropped
+order by a.attnum`, relid)
+	if err != nil {
+		return err
+	}
+
+	for rows.Next() {
+		var f CompositeField
+		var isComposite, isCompositeArray bool
+		var elemOid Oid
+		rows.Scan(&f.Name, &f.DataType, &isComposite, &isCompositeArray, &elemOid)
+
+		ct.Fields = append(ct.Fields, f)
+		if isComposite {
+			nested = append(nested, f.DataType)
+		} else if isCompositeArray {
+			nested = append(nested, elemOid)
+		}
+	}
+	if rows.Err() != nil {
+		return rows.Err()
+	}
+
+	c.connInfo.RegisterDataType(DataType{Name: ct.Name, Oid: oid, FormatCode: BinaryFormatCode, Codec: &compositeCodec{ct: ct}})
+	if ct.ArrayOid != 0 {
+		c.connInfo.RegisterDataType(DataType{Name: string(arrayName), Oid: ct.ArrayOid, ElementOid: oid, FormatCode: BinaryFormatCode})
+	}
+	c.PgTypes = c.connInfo.pgTypes()
+
+	for _, nestedOid := range nested {
+		if _, ok := c.CompositeType(nestedOid); ok {
+			continue
+		}
+		if err := c.registerCompositeType(nestedOid); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// registeredCompositeType returns the composite type with oid registered on c,
+// which may be nil.
+func registeredCompositeType(c *Conn, oid Oid) (*CompositeType, bool) {
+	if cc, ok := connInfoOf(c).codec(oid).(*compositeCodec); ok {
+		return cc.ct, true
+	}
+	return nil, false
+}
+
+// compositeCodec is the Codec of a registered composite type.
+type compositeCodec struct {
+	ct *CompositeType
+}
+
+func (cc *compositeCodec) FormatCode() int16 { return BinaryFormatCode }
+
+func (cc *compositeCodec) Encode(w *WriteBuf, oid Oid, value interface{}) error {
+	return encodeComposite(w, cc.ct, reflect.ValueOf(value))
+}
+
+func (cc *compositeCodec) Decode(vr *ValueReader, d interface{}) error {
+	if v, ok := d.(*[]interface{}); ok {
+		*v = decodeRecord(vr)
+		return vr.Err()
+	}
+
+	if v := reflect.ValueOf(d); v.Kind() == reflect.Ptr && v.Elem().Kind() == reflect.Struct {
+		return decodeComposite(vr, cc.ct, v.Elem())
+	}
+
+	return fmt.Errorf("Cannot decode composite type %s into %T - use a struct or []interface{}", cc.ct.Name, d)
+}
+
+func (cc *compositeCodec) DecodeValue(vr *ValueReader) (interface{}, error) {
+	return decodeRecord(vr), vr.Err()
+}
+
+// compositeFieldPaths returns the index path of the field of t that each
+// attribute of ct is encoded from or decoded into.
+func compositeFieldPaths(ct *CompositeType, t reflect.Type) ([][]int, error) {
+	byName := make(map[string][]int)
+	addStructFields(t, nil, byName)
+
+	paths := make([][]int, len(ct.Fields))
+	for i, f := range ct.Fields {
+		path, ok := byName[f.Name]
+		if !ok {
+			return nil, fmt.Errorf("Cannot find field for attribute %q of composite type %s in %v", f.Name, ct.Name, t)
+		}
+		paths[i] = path
+	}
+
+	return paths, nil
+}
+
+// decodeComposite decodes a value of the composite type ct into dest, which
+// must be a settable struct.
+func decodeComposite(vr *ValueReader, ct *CompositeType, dest reflect.Value) error {
+	if vr.Len() == -1 {
+		return ProtocolError(fmt.Sprintf("Cannot decode null into %v - use a pointer", dest.Type()))
+	}
+
+	if vr.Type().FormatCode != BinaryFormatCode {
+		return ProtocolError(fmt.Sprintf("Unknown field description format code: %v", vr.Type().FormatCode))
+	}
+
+	paths, err := compositeFieldPaths(ct, dest.Type())
+	if err != nil {
+		return err
+	}
+
+	fieldCount := vr.ReadInt32()
+	if vr.Err() != nil {
+		return vr.Err()
+	}
+	if int(fieldCount) != len(ct.Fields) {
+		return ProtocolError(fmt.Sprintf("Composite type %s has %d attributes, but %d were received", ct.Name, len(ct.Fields), fieldCount))
+	}
+
+	for _, path := range paths {
+		fieldOid := vr.ReadOid()
+		if vr.Err() != nil {
+			return vr.Err()
+		}
+		if err := decodeElement(vr, fieldOid, fieldByIndexAlloc(dest, path).Addr().Interface()); err != nil {
+			return err
+		}
+	}
+
+	return vr.Err()
+}
+
+// encodeComposite encodes value, a struct or a []interface{}, as the
+// composite type ct.
+func encodeComposite(w *WriteBuf, ct *CompositeType, value reflect.Value) error {
+	fields := make([]interface{}, len(ct.Fields))
+
+	switch {
+	case value.Kind() == reflect.Struct:
+		paths, err := compositeFieldPaths(ct, value.Type())
+		if err != nil {
+			return err
+		}
+		for i, path := range paths {
+			if f, ok := fieldByIndexNil(value, path); ok {
+				fields[i] = f.Interface()
+			}
+		}
+	case value.Type() == reflect.TypeOf([]interface{}{}):
+		if value.Len() != len(ct.Fields) {
+			return SerializationError(fmt.Sprintf("Cannot encode %d values into composite type %s with %d attributes", value.Len(), ct.Name, len(ct.Fields)))
+		}
+		for i := range fields {
+			fields[i] = value.Index(i).Interface()
+		}
+	default:
+		return SerializationError(fmt.Sprintf("Cannot encode %v into composite type %s - use a struct or []interface{}", value.Type(), ct.Name))
+	}
+
+	sizeIdx := len(w.buf)
+	w.WriteInt32(0) // size placeholder
+	w.WriteInt32(int32(len(ct.Fields)))
+
+	for i, f := range ct.Fields {
+		w.WriteInt32(int32(f.DataType))
+		if err := Encode(w, f.DataType, fields[i]); err != nil {
+			return err
+		}
+	}
+
+	binary.BigEndian.PutUint32(w.buf[sizeIdx:], uint32(len(w.buf)-sizeIdx-4))
+	return nil
+}
+
+// fieldByIndexNil is like reflect.Value.FieldByIndex, but it returns false
+// instead of panicking when it reaches a nil embedded struct pointer.
+func fieldByIndexNil(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
@@ -0,0 +1,118 @@
+package pgx
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type compositeAddress struct {
+	Street string
+	Zip    *int32 `db:"postal_code"`
+}
+
+type compositePerson struct {
+	Name      string
+	Home      compositeAddress
+	Work      *compositeAddress
+	Previous  []compositeAddress
+	Nicknames []string
+}
+
+func TestCompositeRoundTrip(t *testing.T) {
+	c := newTestConn()
+	zip := int32(12345)
+
+	tests := []struct {
+		oid   Oid
+		value interface{}
+	}{
+		{testAddressOid, compositeAddress{Street: "1 Main St", Zip: &zip}},
+		{testAddressOid, compositeAddress{Street: "2 Main St"}},
+		{testAddressArrayOid, []compositeAddress{{Street: "a"}, {Street: "b", Zip: &zip}}},
+		{testAddressArrayOid, []*compositeAddress{{Street: "a"}, nil}},
+		{testPersonOid, compositePerson{
+			Name:      "John",
+			Home:      compositeAddress{Street: "1 Main St", Zip: &zip},
+			Previous:  []compositeAddress{{Street: "3 Oak Ave"}},
+			Nicknames: []string{"Johnny"},
+		}},
+		{testPersonOid, compositePerson{
+			Name:      "Jane",
+			Work:      &compositeAddress{Street: "9 Elm St"},
+			Previous:  []compositeAddress{},
+			Nicknames: []string{},
+		}},
+	}
+
+	for i, tt := range tests {
+		result, err := roundTrip(c, tt.oid, tt.value)
+		if err != nil {
+			t.Errorf("%d. Unexpected error: %v", i, err)
+			continue
+		}
+		if !reflect.DeepEqual(result, tt.value) {
+			t.Errorf("%d. Decoded %+v, expected %+v", i, result, tt.value)
+		}
+	}
+}
+
+func TestCompositeInterfaceSlice(t *testing.T) {
+	c := newTestConn()
+
+	vr, err := encodeValueReader(c, testPersonOid, []interface{}{
+		"John",
+		[]interface{}{"1 Main St", int32(1)},
+		nil,
+		[]compositeAddress{{Street: "a"}},
+		[]string{"Johnny"},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected failure: %v", err)
+	}
+
+	var record []interface{}
+	if err := Decode(vr, &record); err != nil {
+		t.Fatalf("Unexpected failure: %v", err)
+	}
+	if vr.Err() != nil {
+		t.Fatalf("Unexpected failure: %v", vr.Err())
+	}
+
+	expected := []interface{}{
+		"John",
+		[]interface{}{"1 Main St", int32(1)},
+		nil,
+		[]interface{}{[]interface{}{"a", nil}},
+		[]string{"Johnny"},
+	}
+	if !reflect.DeepEqual(record, expected) {
+		t.Errorf("Decoded %v, expected %v", record, expected)
+	}
+}
+
+func TestCompositeErrors(t *testing.T) {
+	c := newTestConn()
+
+	type missingZip struct {
+		Street string
+	}
+
+	w := &WriteBuf{conn: c}
+	err := Encode(w, testAddressOid, missingZip{Street: "x"})
+	if err == nil || !strings.Contains(err.Error(), "postal_code") {
+		t.Errorf("Expected missing field error, got %v", err)
+	}
+
+	w = &WriteBuf{conn: c}
+	err = Encode(w, testAddressOid, []interface{}{"x"})
+	if err == nil {
+		t.Error("Expected error encoding too few values")
+	}
+
+	// Without the registration the type is unknown
+	w = &WriteBuf{}
+	if err := Encode(w, testAddressOid, compositeAddress{}); err == nil {
+		t.Error("Expected error encoding unregistered composite type")
+	}
+}
@@ -0,0 +1,152 @@
+package pgx_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/jackc/pgx"
+)
+
+type compositeAddress struct {
+	Street string
+	Zip    *int32 `db:"postal_code"`
+}
+
+type compositePerson struct {
+	Name     string
+	Home     compositeAddress
+	Work     *compositeAddress
+	Previous []compositeAddress
+}
+
+func createCompositeTypes(t *testing.T, conn *pgx.Conn) {
+	mustExec(t, conn, "create temporary table address(street text, postal_code int4)")
+	mustExec(t, conn, "create temporary table person(name text, home address, work address, previous address[])")
+}
+
+func TestCompositeTypeTranscode(t *testing.T) {
+	t.Parallel()
+
+	conn := mustConnect(t, *defaultConnConfig)
+	defer closeConn(t, conn)
+
+	createCompositeTypes(t, conn)
+
+	ct, err := conn.RegisterCompositeType("person")
+	if err != nil {
+		t.Fatalf("conn.RegisterCompositeType failed: %v", err)
+	}
+	if ct.Name != "person" || len(ct.Fields) != 4 || ct.Fields[1].Name != "home" {
+		t.Fatalf("Unexpected composite type: %+v", ct)
+	}
+	if _, ok := conn.CompositeType(ct.Fields[1].DataType); !ok {
+		t.Fatal("Expected nested composite type address to be registered")
+	}
+
+	zip := int32(12345)
+	tests := []interface{}{
+		compositePerson{
+			Name:     "John",
+			Home:     compositeAddress{Street: "1 Main St", Zip: &zip},
+			Previous: []compositeAddress{{Street: "3 Oak Ave"}, {Street: "4 Elm St", Zip: &zip}},
+		},
+		compositePerson{
+			Name:     "Jane",
+			Home:     compositeAddress{Street: "2 Main St"},
+			Work:     &compositeAddress{Street: "9 Office Park"},
+			Previous: []compositeAddress{},
+		},
+	}
+
+	for i, tt := range tests {
+		result := reflect.New(reflect.TypeOf(tt))
+		err := conn.QueryRow("select $1::person", tt).Scan(result.Interface())
+		if err != nil {
+			t.Errorf("%d. Unexpected failure: %v", i, err)
+			continue
+		}
+		if !reflect.DeepEqual(result.Elem().Interface(), tt) {
+			t.Errorf("%d. Expected %+v, got %+v", i, tt, result.Elem().Interface())
+		}
+	}
+
+	var addresses []compositeAddress
+	err = conn.QueryRow("select array[row('a', 1), row('b', null)]::address[]").Scan(&addresses)
+	if err != nil {
+		t.Fatalf("Unexpected failure: %v", err)
+	}
+	if len(addresses) != 2 || addresses[0].Street != "a" || *addresses[0].Zip != 1 || addresses[1].Zip != nil {
+		t.Errorf("Unexpected addresses: %+v", addresses)
+	}
+
+	var work *compositeAddress
+	err = conn.QueryRow("select null::address").Scan(&work)
+	if err != nil {
+		t.Fatalf("Unexpected failure: %v", err)
+	}
+	if work != nil {
+		t.Errorf("Expected nil, got %+v", work)
+	}
+
+	ensureConnValid(t, conn)
+}
+
+func TestCompositeTypeInterfaceSlice(t *testing.T) {
+	t.Parallel()
+
+	conn := mustConnect(t, *defaultConnConfig)
+	defer closeConn(t, conn)
+
+	createCompositeTypes(t, conn)
+
+	if _, err := conn.RegisterCompositeType("address"); err != nil {
+		t.Fatalf("conn.RegisterCompositeType failed: %v", err)
+	}
+
+	var record []interface{}
+	err := conn.QueryRow("select $1::address", []interface{}{"1 Main St", int32(7)}).Scan(&record)
+	if err != nil {
+		t.Fatalf("Unexpected failure: %v", err)
+	}
+	expected := []interface{}{"1 Main St", int32(7)}
+	if !reflect.DeepEqual(record, expected) {
+		t.Errorf("Expected %v, got %v", expected, record)
+	}
+
+	rows, err := conn.Query("select row('2 Main St', null)::address")
+	if err != nil {
+		t.Fatalf("conn.Query failed: %v", err)
+	}
+	for rows.Next() {
+		values, err := rows.Values()
+		if err != nil {
+			t.Fatalf("rows.Values failed: %v", err)
+		}
+		expected := []interface{}{[]interface{}{"2 Main St", nil}}
+		if !reflect.DeepEqual(values, expected) {
+			t.Errorf("Expected %v, got %v", expected, values)
+		}
+	}
+	if rows.Err() != nil {
+		t.Fatalf("conn.Query failed: %v", rows.Err())
+	}
+
+	ensureConnValid(t, conn)
+}
+
+func TestRegisterCompositeTypeErrors(t *testing.T) {
+	t.Parallel()
+
+	conn := mustConnect(t, *defaultConnConfig)
+	defer closeConn(t, conn)
+
+	if _, err := conn.RegisterCompositeType("int4"); err == nil {
+		t.Error("Expected error registering a non-composite type")
+	}
+
+	if _, err := conn.RegisterCompositeType("no_such_type"); err == nil {
+		t.Error("Expected error registering a missing type")
+	}
+
+	ensureConnValid(t, conn)
+}
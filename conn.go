@@ -2,6 +2,7 @@ package pgx
 
 import (
 	"bufio"
+	"context"
 	"crypto/md5"
 	"crypto/tls"
 	"encoding/binary"
@@ -9,6 +10,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"math/rand"
 	"net"
 	"net/url"
 	"os"
@@ -17,6 +19,7 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -33,10 +36,55 @@ type ConnConfig struct {
 	TLSConfig         *tls.Config // config for TLS connection -- nil disables TLS
 	UseFallbackTLS    bool        // Try FallbackTLSConfig if connecting with TLSConfig fails. Used for preferring TLS, but allowing unencrypted, or vice-versa
 	FallbackTLSConfig *tls.Config // config for fallback TLS connection (only used if UseFallBackTLS is true)-- nil disables TLS
+	ChannelBinding    string      // SCRAM-SHA-256-PLUS channel binding over TLS: "prefer" (default), "disable", or "require"
 	Logger            Logger
 	LogLevel          int
 	Dial              DialFunc
 	RuntimeParams     map[string]string // Run-time parameters to set on connection as session default values (e.g. search_path or application_name)
+
+	// PreparedStatementCacheSize is the number of queries to automatically
+	// prepare and cache per connection. When greater than 0, queries sent by
+	// Query, QueryRow and Exec are prepared as named statements and reused
+	// when the same SQL is sent again. The least recently used statement is
+	// deallocated when the cache is full. 0 disables the cache.
+	PreparedStatementCacheSize int
+
+	// AlternateHosts are tried in order after Host if connecting to Host fails
+	// or it does not match TargetSessionAttrs. A Port of 0 defaults to 5432.
+	AlternateHosts []ConnHost
+
+	// RandomHostOrder tries Host and AlternateHosts in a random order instead
+	// to spread connections across them.
+	RandomHostOrder bool
+
+	// TargetSessionAttrs is the kind of server to accept when trying hosts:
+	// "any" (default), "read-write", "read-only", "primary", or "standby".
+	// read-write and read-only check transaction_read_only and primary and
+	// standby check pg_is_in_recovery() after connecting.
+	TargetSessionAttrs string
+
+	// OnNotice is called with each notice (e.g. the output of RAISE NOTICE or
+	// a warning) the server sends. It is called while the connection is
+	// reading a response, so it must not run queries on the *Conn. When set
+	// in ConnPoolConfig it is called for all connections in the pool.
+	OnNotice func(*Conn, *Notice)
+
+	// OnNotification is called with each LISTEN/NOTIFY notification as soon
+	// as it is received, including while reading the results of other
+	// queries. The notification is also queued for WaitForNotification. Like
+	// OnNotice it must not run queries on the *Conn.
+	OnNotification func(*Conn, *Notification)
+
+	// Codecs maps data type names (e.g. citext) to the Codec that encodes
+	// and decodes them. The codecs are registered in the ConnInfo of each
+	// connection. Connecting fails if one of the types does not exist.
+	Codecs map[string]Codec
+}
+
+// ConnHost is the address of a PostgreSQL server.
+type ConnHost struct {
+	Host string // host (e.g. localhost) or path to unix domain socket directory (e.g. /private/tmp)
+	Port uint16 // default: 5432
 }
 
 // Conn is a PostgreSQL connection handle. It is not safe for concurrent usage.
@@ -51,10 +99,14 @@ type Conn struct {
 	Pid                int32             // backend pid
 	SecretKey          int32             // key to use to send a cancel query message to the server
 	RuntimeParams      map[string]string // parameters that have been reported by the server
-	PgTypes            map[Oid]PgType    // oids to PgTypes
+	PgTypes            map[Oid]PgType    // Deprecated: use ConnInfo
 	config             ConnConfig        // config used when establishing this connection
+	tlsConfig          *tls.Config       // TLS config negotiated when establishing this connection (nil if TLS is not in use)
+	scram              *scramClient      // state of an in-progress SCRAM authentication exchange
+	scramMechanism     string            // mechanism of the completed SCRAM authentication exchange
 	TxStatus           byte
 	preparedStatements map[string]*PreparedStatement
+	stmtCache          *stmtCache
 	channels           map[string]struct{}
 	notifications      []*Notification
 	alive              bool
@@ -65,9 +117,18 @@ type Conn struct {
 	fp                 *fastpath
 	pgsqlAfInet        *byte
 	pgsqlAfInet6       *byte
+	connInfo           *ConnInfo
 	busy               bool
 	poolResetCount     int
+	poolCreatedTime    time.Time                  // when the pool established the connection
+	poolReleasedTime   time.Time                  // when the connection was last released to the pool
+	queryDone          func(time.Duration, error) // called by the pool to record each Exec and Query
 	preallocatedRows   []Rows
+	ctxInProgress      bool
+	doneChan           chan struct{}
+	closedChan         chan error
+	peekMux            sync.Mutex
+	peeking            bool
 }
 
 // PreparedStatement is a description of a prepared statement
@@ -90,6 +151,10 @@ type Notification struct {
 	Payload string
 }
 
+// Notice is a notice or warning sent by the PostgreSQL server. It has the same
+// fields as PgError.
+type Notice PgError
+
 // PgType is information about PostgreSQL type and how to encode and decode it
 type PgType struct {
 	Name          string // name of type e.g. int4, text, date
@@ -145,16 +210,14 @@ func Connect(config ConnConfig) (c *Conn, err error) {
 	return connect(config, nil, nil, nil)
 }
 
-func connect(config ConnConfig, pgTypes map[Oid]PgType, pgsqlAfInet *byte, pgsqlAfInet6 *byte) (c *Conn, err error) {
+func connect(config ConnConfig, connInfo *ConnInfo, pgsqlAfInet *byte, pgsqlAfInet6 *byte) (c *Conn, err error) {
 	c = new(Conn)
 
 	c.config = config
 
-	if pgTypes != nil {
-		c.PgTypes = make(map[Oid]PgType, len(pgTypes))
-		for k, v := range pgTypes {
-			c.PgTypes[k] = v
-		}
+	if connInfo != nil {
+		c.connInfo = connInfo.clone()
+		c.PgTypes = c.connInfo.pgTypes()
 	}
 
 	if pgsqlAfInet != nil {
@@ -194,40 +257,149 @@ func connect(config ConnConfig, pgTypes map[Oid]PgType, pgsqlAfInet *byte, pgsql
 		}
 	}
 
-	network := "tcp"
-	address := fmt.Sprintf("%s:%d", c.config.Host, c.config.Port)
-	// See if host is a valid path, if yes connect with a socket
-	if _, err := os.Stat(c.config.Host); err == nil {
-		// For backward compatibility accept socket file paths -- but directories are now preferred
-		network = "unix"
-		address = c.config.Host
-		if !strings.Contains(address, "/.s.PGSQL.") {
-			address = filepath.Join(address, ".s.PGSQL.") + strconv.FormatInt(int64(c.config.Port), 10)
-		}
+	if err = validateTargetSessionAttrs(c.config.TargetSessionAttrs); err != nil {
+		return nil, err
 	}
+
 	if c.config.Dial == nil {
 		c.config.Dial = (&net.Dialer{KeepAlive: 5 * time.Minute}).Dial
 	}
 
+	hosts := c.config.hosts()
+	if c.config.RandomHostOrder {
+		shuffled := make([]ConnHost, len(hosts))
+		for i, j := range rand.Perm(len(hosts)) {
+			shuffled[i] = hosts[j]
+		}
+		hosts = shuffled
+	}
+
+	for i, h := range hosts {
+		// c.config holds the host actually connected to so CancelRequest uses it
+		c.config.Host, c.config.Port = h.Host, h.Port
+
+		err = c.connectHost(config)
+		if err == nil {
+			return c, nil
+		}
+
+		if i < len(hosts)-1 && c.shouldLog(LogLevelInfo) {
+			c.log(LogLevelInfo, fmt.Sprintf("Connect to %s failed, trying next host: %v", h.Host, err))
+		}
+	}
+
+	if c.shouldLog(LogLevelError) {
+		c.log(LogLevelError, fmt.Sprintf("Connect failed: %v", err))
+	}
+	return nil, err
+}
+
+// connectHost connects to c.config.Host, first with TLSConfig and then with
+// FallbackTLSConfig if allowed, and checks that the server matches
+// TargetSessionAttrs.
+func (c *Conn) connectHost(config ConnConfig) error {
+	network, address := c.config.networkAddress()
+
 	if c.shouldLog(LogLevelInfo) {
 		c.log(LogLevelInfo, fmt.Sprintf("Dialing PostgreSQL server at %s address: %s", network, address))
 	}
-	err = c.connect(config, network, address, config.TLSConfig)
+	err := c.connect(config, network, address, hostTLSConfig(config.TLSConfig, config.Host, c.config.Host))
 	if err != nil && config.UseFallbackTLS {
 		if c.shouldLog(LogLevelInfo) {
 			c.log(LogLevelInfo, fmt.Sprintf("Connect with TLSConfig failed, trying FallbackTLSConfig: %v", err))
 		}
-		err = c.connect(config, network, address, config.FallbackTLSConfig)
+		err = c.connect(config, network, address, hostTLSConfig(config.FallbackTLSConfig, config.Host, c.config.Host))
 	}
-
 	if err != nil {
-		if c.shouldLog(LogLevelError) {
-			c.log(LogLevelError, fmt.Sprintf("Connect failed: %v", err))
+		return err
+	}
+
+	if err = c.checkTargetSessionAttrs(); err != nil {
+		c.Close()
+		return err
+	}
+
+	return nil
+}
+
+// hosts returns Host followed by AlternateHosts with default ports filled in.
+func (cc *ConnConfig) hosts() []ConnHost {
+	hosts := make([]ConnHost, 0, 1+len(cc.AlternateHosts))
+	hosts = append(hosts, ConnHost{Host: cc.Host, Port: cc.Port})
+	for _, h := range cc.AlternateHosts {
+		if h.Port == 0 {
+			h.Port = 5432
 		}
-		return nil, err
+		hosts = append(hosts, h)
 	}
+	return hosts
+}
 
-	return c, nil
+// hostTLSConfig returns tlsConfig for connecting to host. If tlsConfig
+// verifies the name of the primary host (as configured by sslmode=require and
+// above) then a copy that verifies host is returned instead.
+func hostTLSConfig(tlsConfig *tls.Config, primaryHost, host string) *tls.Config {
+	if tlsConfig == nil || host == primaryHost || tlsConfig.ServerName != primaryHost {
+		return tlsConfig
+	}
+	tlsConfig = tlsConfig.Clone()
+	tlsConfig.ServerName = host
+	return tlsConfig
+}
+
+func validateTargetSessionAttrs(targetSessionAttrs string) error {
+	switch targetSessionAttrs {
+	case "", "any", "read-write", "read-only", "primary", "standby":
+		return nil
+	default:
+		return errors.New("target_session_attrs is invalid")
+	}
+}
+
+// checkTargetSessionAttrs returns an error if the server c is connected to
+// does not match c.config.TargetSessionAttrs.
+func (c *Conn) checkTargetSessionAttrs() error {
+	// Replication connections can't execute queries
+	if _, ok := c.config.RuntimeParams["replication"]; ok {
+		return nil
+	}
+
+	switch c.config.TargetSessionAttrs {
+	case "read-write", "read-only":
+		var readOnly string
+		if err := c.QueryRow("show transaction_read_only").Scan(&readOnly); err != nil {
+			return err
+		}
+		if (readOnly == "on") != (c.config.TargetSessionAttrs == "read-only") {
+			return fmt.Errorf("server at %s is not %s", c.config.Host, c.config.TargetSessionAttrs)
+		}
+	case "primary", "standby":
+		var inRecovery bool
+		if err := c.QueryRow("select pg_is_in_recovery()").Scan(&inRecovery); err != nil {
+			return err
+		}
+		if inRecovery != (c.config.TargetSessionAttrs == "standby") {
+			return fmt.Errorf("server at %s is not a %s", c.config.Host, c.config.TargetSessionAttrs)
+		}
+	}
+
+	return nil
+}
+
+func (cc *ConnConfig) networkAddress() (network, address string) {
+	network = "tcp"
+	address = fmt.Sprintf("%s:%d", cc.Host, cc.Port)
+	// See if host is a valid path, if yes connect with a socket
+	if _, err := os.Stat(cc.Host); err == nil {
+		// For backward compatibility accept socket file paths -- but directories are now preferred
+		network = "unix"
+		address = cc.Host
+		if !strings.Contains(address, "/.s.PGSQL.") {
+			address = filepath.Join(address, ".s.PGSQL.") + strconv.FormatInt(int64(cc.Port), 10)
+		}
+	}
+
+	return network, address
 }
 
 func (c *Conn) connect(config ConnConfig, network, address string, tlsConfig *tls.Config) (err error) {
@@ -244,9 +416,13 @@ func (c *Conn) connect(config ConnConfig, network, address string, tlsConfig *tl
 
 	c.RuntimeParams = make(map[string]string)
 	c.preparedStatements = make(map[string]*PreparedStatement)
+	if config.PreparedStatementCacheSize > 0 {
+		c.stmtCache = newStmtCache(c, config.PreparedStatementCacheSize)
+	}
 	c.channels = make(map[string]struct{})
 	c.alive = true
 	c.lastActivityTime = time.Now()
+	c.tlsConfig = tlsConfig
 
 	if tlsConfig != nil {
 		if c.shouldLog(LogLevelDebug) {
@@ -306,12 +482,12 @@ func (c *Conn) connect(config ConnConfig, network, address string, tlsConfig *tl
 			}
 
 			// Replication connections can't execute the queries to
-			// populate the c.PgTypes and c.pgsqlAfInet
+			// populate the c.connInfo and c.pgsqlAfInet
 			if _, ok := msg.options["replication"]; ok {
 				return nil
 			}
 
-			if c.PgTypes == nil {
+			if c.connInfo == nil {
 				err = c.loadPgTypes()
 				if err != nil {
 					return err
@@ -335,33 +511,61 @@ func (c *Conn) connect(config ConnConfig, network, address string, tlsConfig *tl
 }
 
 func (c *Conn) loadPgTypes() error {
-	rows, err := c.Query(`select t.oid, t.typname
+	rows, err := c.Query(`select t.oid, t.typname, t.typtype::text, case when t.typlen = -1 then t.typelem else 0::oid end
 from pg_type t
 left join pg_type base_type on t.typelem=base_type.oid
 where (
-	  t.typtype='b'
-	  and (base_type.oid is null or base_type.typtype='b')
+	  t.typtype in('b', 'e')
+	  and (base_type.oid is null or base_type.typtype in('b', 'e'))
 	)
   or t.typname in('record');`)
 	if err != nil {
 		return err
 	}
 
-	c.PgTypes = make(map[Oid]PgType, 128)
+	ci := NewConnInfo()
 
 	for rows.Next() {
-		var oid Oid
-		var t PgType
+		var dt DataType
+		var typtype string
 
-		rows.Scan(&oid, &t.Name)
+		rows.Scan(&dt.Oid, &dt.Name, &typtype, &dt.ElementOid)
 
 		// The zero value is text format so we ignore any types without a default type format
-		t.DefaultFormat, _ = DefaultTypeFormats[t.Name]
+		dt.FormatCode, _ = DefaultTypeFormats[dt.Name]
+
+		if builtin, ok := builtinDataType(dt.Oid, dt.Name); ok {
+			dt.decodeValue = builtin.decodeValue
+			dt.decodeTextValue = builtin.decodeTextValue
+		}
 
-		c.PgTypes[oid] = t
+		if typtype == "e" {
+			ci.enumOids[dt.Oid] = struct{}{}
+		}
+
+		ci.RegisterDataType(dt)
+	}
+	if rows.Err() != nil {
+		return rows.Err()
+	}
+
+	// Arrays of enums are only supported in binary format
+	for _, dt := range ci.oidToDataType {
+		if _, ok := ci.enumOids[dt.ElementOid]; ok {
+			dt.FormatCode = BinaryFormatCode
+		}
+	}
+
+	for name, codec := range c.config.Codecs {
+		if err := ci.RegisterCodec(name, codec); err != nil {
+			return err
+		}
 	}
 
-	return rows.Err()
+	c.connInfo = ci
+	c.PgTypes = ci.pgTypes()
+
+	return nil
 }
 
 // Family is needed for binary encoding of inet/cidr. The constant is based on
@@ -406,6 +610,9 @@ func (c *Conn) Close() (err error) {
 func ParseURI(uri string) (ConnConfig, error) {
 	var cp ConnConfig
 
+	// net/url does not accept a list of hosts so parse them separately
+	uri, hostList := splitURIHosts(uri)
+
 	url, err := url.Parse(uri)
 	if err != nil {
 		return cp, err
@@ -416,14 +623,18 @@ func ParseURI(uri string) (ConnConfig, error) {
 		cp.Password, _ = url.User.Password()
 	}
 
-	parts := strings.SplitN(url.Host, ":", 2)
-	cp.Host = parts[0]
-	if len(parts) == 2 {
-		p, err := strconv.ParseUint(parts[1], 10, 16)
-		if err != nil {
-			return cp, err
-		}
-		cp.Port = uint16(p)
+	if hostList == "" {
+		hostList = url.Host
+	}
+	var hosts, ports []string
+	for _, hostPort := range strings.Split(hostList, ",") {
+		host, port := splitHostPort(hostPort)
+		hosts = append(hosts, host)
+		ports = append(ports, port)
+	}
+	err = configHosts(&cp, hosts, ports)
+	if err != nil {
+		return cp, err
 	}
 	cp.Database = strings.TrimLeft(url.Path, "/")
 
@@ -432,8 +643,28 @@ func ParseURI(uri string) (ConnConfig, error) {
 		return cp, err
 	}
 
+	cp.ChannelBinding = url.Query().Get("channel_binding")
+	err = validateChannelBinding(cp.ChannelBinding)
+	if err != nil {
+		return cp, err
+	}
+
+	cp.TargetSessionAttrs = url.Query().Get("target_session_attrs")
+	err = validateTargetSessionAttrs(cp.TargetSessionAttrs)
+	if err != nil {
+		return cp, err
+	}
+
+	cp.RandomHostOrder, err = parseLoadBalanceHosts(url.Query().Get("load_balance_hosts"))
+	if err != nil {
+		return cp, err
+	}
+
 	ignoreKeys := map[string]struct{}{
-		"sslmode": {},
+		"sslmode":              {},
+		"channel_binding":      {},
+		"target_session_attrs": {},
+		"load_balance_hosts":   {},
 	}
 
 	cp.RuntimeParams = make(map[string]string)
@@ -469,7 +700,7 @@ func ParseDSN(s string) (ConnConfig, error) {
 
 	m := dsnRegexp.FindAllStringSubmatch(s, -1)
 
-	var sslmode string
+	var sslmode, hostList, portList, loadBalanceHosts string
 
 	cp.RuntimeParams = make(map[string]string)
 
@@ -480,23 +711,41 @@ func ParseDSN(s string) (ConnConfig, error) {
 		case "password":
 			cp.Password = b[2]
 		case "host":
-			cp.Host = b[2]
+			hostList = b[2]
 		case "port":
-			p, err := strconv.ParseUint(b[2], 10, 16)
-			if err != nil {
-				return cp, err
-			}
-			cp.Port = uint16(p)
+			portList = b[2]
 		case "dbname":
 			cp.Database = b[2]
 		case "sslmode":
 			sslmode = b[2]
+		case "channel_binding":
+			cp.ChannelBinding = b[2]
+		case "target_session_attrs":
+			cp.TargetSessionAttrs = b[2]
+		case "load_balance_hosts":
+			loadBalanceHosts = b[2]
 		default:
 			cp.RuntimeParams[b[1]] = b[2]
 		}
 	}
 
-	err := configSSL(sslmode, &cp)
+	err := configHostList(&cp, hostList, portList)
+	if err != nil {
+		return cp, err
+	}
+	err = configSSL(sslmode, &cp)
+	if err != nil {
+		return cp, err
+	}
+	err = validateChannelBinding(cp.ChannelBinding)
+	if err != nil {
+		return cp, err
+	}
+	err = validateTargetSessionAttrs(cp.TargetSessionAttrs)
+	if err != nil {
+		return cp, err
+	}
+	cp.RandomHostOrder, err = parseLoadBalanceHosts(loadBalanceHosts)
 	if err != nil {
 		return cp, err
 	}
@@ -519,6 +768,12 @@ func ParseDSN(s string) (ConnConfig, error) {
 // PGPASSWORD
 // PGSSLMODE
 // PGAPPNAME
+// PGCHANNELBINDING
+// PGTARGETSESSIONATTRS
+// PGLOADBALANCEHOSTS
+//
+// PGHOST and PGPORT may be comma separated lists as with libpq. The first host
+// is parsed into Host and Port and the rest into AlternateHosts.
 //
 // Important TLS Security Notes:
 // ParseEnvLibpq tries to match libpq behavior with regard to PGSSLMODE. This
@@ -540,14 +795,8 @@ func ParseDSN(s string) (ConnConfig, error) {
 func ParseEnvLibpq() (ConnConfig, error) {
 	var cc ConnConfig
 
-	cc.Host = os.Getenv("PGHOST")
-
-	if pgport := os.Getenv("PGPORT"); pgport != "" {
-		if port, err := strconv.ParseUint(pgport, 10, 16); err == nil {
-			cc.Port = uint16(port)
-		} else {
-			return cc, err
-		}
+	if err := configHostList(&cc, os.Getenv("PGHOST"), os.Getenv("PGPORT")); err != nil {
+		return cc, err
 	}
 
 	cc.Database = os.Getenv("PGDATABASE")
@@ -561,6 +810,23 @@ func ParseEnvLibpq() (ConnConfig, error) {
 		return cc, err
 	}
 
+	cc.ChannelBinding = os.Getenv("PGCHANNELBINDING")
+	err = validateChannelBinding(cc.ChannelBinding)
+	if err != nil {
+		return cc, err
+	}
+
+	cc.TargetSessionAttrs = os.Getenv("PGTARGETSESSIONATTRS")
+	err = validateTargetSessionAttrs(cc.TargetSessionAttrs)
+	if err != nil {
+		return cc, err
+	}
+
+	cc.RandomHostOrder, err = parseLoadBalanceHosts(os.Getenv("PGLOADBALANCEHOSTS"))
+	if err != nil {
+		return cc, err
+	}
+
 	cc.RuntimeParams = make(map[string]string)
 	if appname := os.Getenv("PGAPPNAME"); appname != "" {
 		cc.RuntimeParams["application_name"] = appname
@@ -571,6 +837,111 @@ func ParseEnvLibpq() (ConnConfig, error) {
 	return cc, nil
 }
 
+// splitURIHosts removes the host list from uri if it has more than one host
+// and returns it separately.
+func splitURIHosts(uri string) (string, string) {
+	start := strings.Index(uri, "://")
+	if start == -1 {
+		return uri, ""
+	}
+	start += 3
+
+	end := len(uri)
+	if i := strings.IndexAny(uri[start:], "/?#"); i != -1 {
+		end = start + i
+	}
+	if i := strings.LastIndex(uri[start:end], "@"); i != -1 {
+		start += i + 1
+	}
+
+	if !strings.Contains(uri[start:end], ",") {
+		return uri, ""
+	}
+	return uri[:start] + uri[end:], uri[start:end]
+}
+
+// splitHostPort splits a host[:port] or [ipv6 host][:port] string.
+func splitHostPort(hostPort string) (host, port string) {
+	if strings.HasPrefix(hostPort, "[") {
+		if i := strings.Index(hostPort, "]"); i != -1 {
+			return hostPort[1:i], strings.TrimPrefix(hostPort[i+1:], ":")
+		}
+	}
+
+	parts := strings.SplitN(hostPort, ":", 2)
+	if len(parts) == 2 {
+		return parts[0], parts[1]
+	}
+	return parts[0], ""
+}
+
+// configHostList parses the comma separated libpq style host and port lists
+// into cc. port may be a single port to use for all hosts.
+func configHostList(cc *ConnConfig, hostList, portList string) error {
+	hosts := strings.Split(hostList, ",")
+
+	var ports []string
+	if portList != "" {
+		ports = strings.Split(portList, ",")
+	}
+	if len(ports) == 1 {
+		for len(ports) < len(hosts) {
+			ports = append(ports, ports[0])
+		}
+	}
+
+	return configHosts(cc, hosts, ports)
+}
+
+// configHosts sets Host and Port of cc to the first of hosts and ports and
+// AlternateHosts to the rest. ports must be empty or match hosts and an empty
+// port means the default.
+func configHosts(cc *ConnConfig, hosts, ports []string) error {
+	if len(ports) > 0 && len(ports) != len(hosts) {
+		return fmt.Errorf("could not match %d port numbers to %d hosts", len(ports), len(hosts))
+	}
+
+	cc.AlternateHosts = nil
+	for i, host := range hosts {
+		h := ConnHost{Host: host}
+		if len(ports) > 0 && ports[i] != "" {
+			p, err := strconv.ParseUint(ports[i], 10, 16)
+			if err != nil {
+				return err
+			}
+			h.Port = uint16(p)
+		}
+
+		if i == 0 {
+			cc.Host, cc.Port = h.Host, h.Port
+		} else {
+			cc.AlternateHosts = append(cc.AlternateHosts, h)
+		}
+	}
+
+	return nil
+}
+
+func parseLoadBalanceHosts(loadBalanceHosts string) (bool, error) {
+	switch loadBalanceHosts {
+	case "", "disable":
+		return false, nil
+	case "random":
+		return true, nil
+	default:
+		return false, errors.New("load_balance_hosts is invalid")
+	}
+}
+
+func validateChannelBinding(channelBinding string) error {
+	switch channelBinding {
+	case "", "prefer", "disable", "require":
+		return nil
+	default:
+		return errors.New("channel_binding is invalid")
+	}
+}
+
 func configSSL(sslmode string, cc *ConnConfig) error {
 	// Match libpq default behavior
 	if sslmode == "" {
@@ -683,11 +1054,7 @@ func (c *Conn) PrepareEx(name, sql string, opts *PrepareExOptions) (ps *Prepared
 			}
 		case rowDescription:
 			ps.FieldDescriptions = c.rxRowDescription(r)
-			for i := range ps.FieldDescriptions {
-				t, _ := c.PgTypes[ps.FieldDescriptions[i].DataType]
-				ps.FieldDescriptions[i].DataTypeName = t.Name
-				ps.FieldDescriptions[i].FormatCode = t.DefaultFormat
-			}
+			c.setFieldFormats(ps.FieldDescriptions)
 		case noData:
 		case readyForQuery:
 			c.rxReadyForQuery(r)
@@ -705,6 +1072,20 @@ func (c *Conn) PrepareEx(name, sql string, opts *PrepareExOptions) (ps *Prepared
 	}
 }
 
+// setFieldFormats fills in the type name and the result format pgx requests
+// for each field.
+func (c *Conn) setFieldFormats(fields []FieldDescription) {
+	if c.connInfo == nil {
+		return
+	}
+	for i := range fields {
+		if dt, ok := c.connInfo.DataTypeForOid(fields[i].DataType); ok {
+			fields[i].DataTypeName = dt.Name
+			fields[i].FormatCode = dt.FormatCode
+		}
+	}
+}
+
 // Deallocate released a prepared statement
 func (c *Conn) Deallocate(name string) (err error) {
 	delete(c.preparedStatements, name)
@@ -746,7 +1127,11 @@ func (c *Conn) Deallocate(name string) (err error) {
 
 // Listen establishes a PostgreSQL listen/notify to channel
 func (c *Conn) Listen(channel string) error {
-	_, err := c.Exec("listen " + quoteIdentifier(channel))
+	if err := validateIdentifier(channel); err != nil {
+		return err
+	}
+
+	_, err := c.Exec("listen " + Identifier{channel}.Sanitize())
 	if err != nil {
 		return err
 	}
@@ -756,9 +1141,22 @@ func (c *Conn) Listen(channel string) error {
 	return nil
 }
 
-// Unlisten unsubscribes from a listen channel
+// Unlisten unsubscribes from a listen channel. Unlisten("*") unsubscribes
+// from all channels.
 func (c *Conn) Unlisten(channel string) error {
-	_, err := c.Exec("unlisten " + quoteIdentifier(channel))
+	if channel == "*" {
+		if _, err := c.Exec("unlisten *"); err != nil {
+			return err
+		}
+		c.channels = make(map[string]struct{})
+		return nil
+	}
+
+	if err := validateIdentifier(channel); err != nil {
+		return err
+	}
+
+	_, err := c.Exec("unlisten " + Identifier{channel}.Sanitize())
 	if err != nil {
 		return err
 	}
@@ -770,6 +1168,20 @@ func (c *Conn) Unlisten(channel string) error {
 // WaitForNotification waits for a PostgreSQL notification for up to timeout.
 // If the timeout occurs it returns pgx.ErrNotificationTimeout
 func (c *Conn) WaitForNotification(timeout time.Duration) (*Notification, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	notification, err := c.WaitForNotificationContext(ctx)
+	if err == context.DeadlineExceeded {
+		err = ErrNotificationTimeout
+	}
+	return notification, err
+}
+
+// WaitForNotificationContext waits for a PostgreSQL notification until one is
+// received or ctx is done. If ctx is done it returns ctx.Err(). As no query is
+// in progress while waiting, the connection remains usable after ctx is done.
+func (c *Conn) WaitForNotificationContext(ctx context.Context) (*Notification, error) {
 	// Return already received notification immediately
 	if len(c.notifications) > 0 {
 		notification := c.notifications[0]
@@ -777,13 +1189,22 @@ func (c *Conn) WaitForNotification(timeout time.Duration) (*Notification, error)
 		return notification, nil
 	}
 
-	stopTime := time.Now().Add(timeout)
+	if ctx.Done() != nil {
+		stop := make(chan struct{})
+		defer close(stop)
+		go c.waitForNotificationContextHandler(ctx, stop)
+	}
 
 	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
 		now := time.Now()
 
-		if now.After(stopTime) {
-			return nil, ErrNotificationTimeout
+		stopTime, hasStopTime := ctx.Deadline()
+		if hasStopTime && !now.Before(stopTime) {
+			return nil, context.DeadlineExceeded
 		}
 
 		// If there has been no activity on this connection for a while send a nop message just to ensure
@@ -805,20 +1226,35 @@ func (c *Conn) WaitForNotification(timeout time.Duration) (*Notification, error)
 		}
 
 		var deadline time.Time
-		if stopTime.Before(nextEnsureAliveTime) {
+		if hasStopTime && stopTime.Before(nextEnsureAliveTime) {
 			deadline = stopTime
 		} else {
 			deadline = nextEnsureAliveTime
 		}
 
-		notification, err := c.waitForNotification(deadline)
+		notification, err := c.waitForNotification(ctx, deadline)
 		if err != ErrNotificationTimeout {
 			return notification, err
 		}
 	}
 }
 
-func (c *Conn) waitForNotification(deadline time.Time) (*Notification, error) {
+// waitForNotificationContextHandler interrupts waitForNotification when ctx is
+// done. The read deadline is only changed while waitForNotification is
+// peeking so that an interrupt can never break a partially read message.
+func (c *Conn) waitForNotificationContextHandler(ctx context.Context, stop <-chan struct{}) {
+	select {
+	case <-ctx.Done():
+		c.peekMux.Lock()
+		if c.peeking {
+			c.conn.SetReadDeadline(time.Now())
+		}
+		c.peekMux.Unlock()
+	case <-stop:
+	}
+}
+
+func (c *Conn) waitForNotification(ctx context.Context, deadline time.Time) (*Notification, error) {
 	var zeroTime time.Time
 
 	for {
@@ -830,16 +1266,32 @@ func (c *Conn) waitForNotification(deadline time.Time) (*Notification, error) {
 		// deadline and peek into the reader. If a timeout error occurs there
 		// we don't break the pgx connection. If the Peek returns that data
 		// is available then we turn off the read deadline before the rxMsg.
+		c.peekMux.Lock()
+		if err := ctx.Err(); err != nil {
+			c.peekMux.Unlock()
+			return nil, err
+		}
 		err := c.conn.SetReadDeadline(deadline)
 		if err != nil {
+			c.peekMux.Unlock()
 			return nil, err
 		}
+		c.peeking = true
+		c.peekMux.Unlock()
 
 		// Wait until there is a byte available before continuing onto the normal msg reading path
 		_, err = c.reader.Peek(1)
+
+		c.peekMux.Lock()
+		c.peeking = false
+		c.peekMux.Unlock()
+
 		if err != nil {
 			c.conn.SetReadDeadline(zeroTime) // we can only return one error and we already have one -- so ignore possiple error from SetReadDeadline
 			if err, ok := err.(*net.OpError); ok && err.Timeout() {
+				if ctxErr := ctx.Err(); ctxErr != nil {
+					return nil, ctxErr
+				}
 				return nil, ErrNotificationTimeout
 			}
 			return nil, err
@@ -899,7 +1351,7 @@ func (c *Conn) sendSimpleQuery(sql string, args ...interface{}) error {
 		return nil
 	}
 
-	ps, err := c.Prepare("", sql)
+	ps, err := c.prepareForQuery(sql)
 	if err != nil {
 		return err
 	}
@@ -908,12 +1360,36 @@ func (c *Conn) sendSimpleQuery(sql string, args ...interface{}) error {
 }
 
 func (c *Conn) sendPreparedQuery(ps *PreparedStatement, arguments ...interface{}) (err error) {
+	// bind
+	wbuf := newWriteBuf(c, 'B')
+	if err = c.writeBind(wbuf, ps, arguments); err != nil {
+		return err
+	}
+
+	// execute
+	wbuf.startMsg('E')
+	wbuf.WriteByte(0)
+	wbuf.WriteInt32(0)
+
+	// sync
+	wbuf.startMsg('S')
+	wbuf.closeMsg()
+
+	_, err = c.conn.Write(wbuf.buf)
+	if err != nil {
+		c.die(err)
+	}
+
+	return err
+}
+
+// writeBind writes the body of a Bind message of ps and arguments to the
+// unnamed portal. The Bind message must already be started in wbuf.
+func (c *Conn) writeBind(wbuf *WriteBuf, ps *PreparedStatement, arguments []interface{}) error {
 	if len(ps.ParameterOids) != len(arguments) {
 		return fmt.Errorf("Prepared statement \"%v\" requires %d parameters, but %d were provided", ps.Name, len(ps.ParameterOids), len(arguments))
 	}
 
-	// bind
-	wbuf := newWriteBuf(c, 'B')
 	wbuf.WriteByte(0)
 	wbuf.WriteCString(ps.Name)
 
@@ -925,12 +1401,7 @@ func (c *Conn) sendPreparedQuery(ps *PreparedStatement, arguments ...interface{}
 		case string, *string:
 			wbuf.WriteInt16(TextFormatCode)
 		default:
-			switch oid {
-			case BoolOid, ByteaOid, Int2Oid, Int4Oid, Int8Oid, Float4Oid, Float8Oid, TimestampTzOid, TimestampTzArrayOid, TimestampOid, TimestampArrayOid, DateOid, BoolArrayOid, ByteaArrayOid, Int2ArrayOid, Int4ArrayOid, Int8ArrayOid, Float4ArrayOid, Float8ArrayOid, TextArrayOid, VarcharArrayOid, OidOid, InetOid, CidrOid, InetArrayOid, CidrArrayOid, RecordOid, JsonOid, JsonbOid:
-				wbuf.WriteInt16(BinaryFormatCode)
-			default:
-				wbuf.WriteInt16(TextFormatCode)
-			}
+			wbuf.WriteInt16(c.paramFormatCode(oid, arg))
 		}
 	}
 
@@ -946,21 +1417,23 @@ func (c *Conn) sendPreparedQuery(ps *PreparedStatement, arguments ...interface{}
 		wbuf.WriteInt16(fd.FormatCode)
 	}
 
-	// execute
-	wbuf.startMsg('E')
-	wbuf.WriteByte(0)
-	wbuf.WriteInt32(0)
-
-	// sync
-	wbuf.startMsg('S')
-	wbuf.closeMsg()
+	return nil
+}
 
-	_, err = c.conn.Write(wbuf.buf)
-	if err != nil {
-		c.die(err)
+// paramFormatCode returns the format to send arg, which is not an Encoder or a
+// string, in as a parameter of type oid.
+func (c *Conn) paramFormatCode(oid Oid, arg interface{}) int16 {
+	switch oid {
+	case NumericOid, NumericArrayOid:
+		return numericArgFormatCode(arg)
+	case UuidOid, UuidArrayOid:
+		return uuidArgFormatCode(arg)
 	}
 
-	return err
+	if dt, ok := connInfoOf(c).DataTypeForOid(oid); ok {
+		return dt.FormatCode
+	}
+	return TextFormatCode
 }
 
 // Exec executes sql. sql can be either a prepared statement name or an SQL string.
@@ -985,6 +1458,10 @@ func (c *Conn) Exec(sql string, arguments ...interface{}) (commandTag CommandTag
 			}
 		}
 
+		if c.queryDone != nil {
+			c.queryDone(time.Since(startTime), err)
+		}
+
 		if unlockErr := c.unlock(); unlockErr != nil && err == nil {
 			err = unlockErr
 		}
@@ -1007,6 +1484,7 @@ func (c *Conn) Exec(sql string, arguments ...interface{}) (commandTag CommandTag
 		switch t {
 		case readyForQuery:
 			c.rxReadyForQuery(r)
+			c.invalidateCachedStatement(sql, softErr)
 			return commandTag, softErr
 		case rowDescription:
 		case dataRow:
@@ -1021,6 +1499,144 @@ func (c *Conn) Exec(sql string, arguments ...interface{}) (commandTag CommandTag
 	}
 }
 
+// ExecContext executes sql like Exec, but ctx can be used to abandon the
+// query. If ctx is done before the query completes the in-flight network
+// operation is interrupted, the server is asked to cancel the query, and the
+// connection is closed. ExecContext then returns ctx.Err().
+func (c *Conn) ExecContext(ctx context.Context, sql string, arguments ...interface{}) (commandTag CommandTag, err error) {
+	if err = c.startContext(ctx); err != nil {
+		return commandTag, err
+	}
+	commandTag, err = c.Exec(sql, arguments...)
+	return commandTag, c.termContext(err)
+}
+
+// startContext begins watching ctx for the operation about to be started on
+// c. Every successful call to startContext must be paired with a call to
+// termContext once the operation is complete.
+func (c *Conn) startContext(ctx context.Context) error {
+	if c.busy {
+		return ErrConnBusy
+	}
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	// A context that can never be done does not need to be watched
+	if ctx.Done() == nil {
+		return nil
+	}
+
+	if c.doneChan == nil {
+		c.doneChan = make(chan struct{})
+		c.closedChan = make(chan error)
+	}
+
+	c.ctxInProgress = true
+	go c.contextHandler(ctx)
+
+	return nil
+}
+
+// contextHandler waits for either ctx to be done or for the operation to
+// complete. When ctx is done the operation is interrupted and the cause is
+// delivered to termContext.
+func (c *Conn) contextHandler(ctx context.Context) {
+	select {
+	case <-ctx.Done():
+		c.cancelQuery()
+		c.closedChan <- ctx.Err()
+	case <-c.doneChan:
+	}
+}
+
+// cancelQuery interrupts any in-flight network operation on c and asks the
+// server to cancel the running query. It is called from the contextHandler
+// goroutine so it must not touch any connection state besides the socket.
+func (c *Conn) cancelQuery() {
+	c.conn.SetDeadline(time.Now())
+
+	// The connection is not reused after being interrupted so there is no
+	// risk of the cancel request arriving after a later query has started.
+	go c.CancelRequest()
+}
+
+// CancelRequest asks the server to cancel the query currently running on c.
+// It opens a separate connection using the Dial function and TLS settings c
+// was established with and sends a CancelRequest message identified by c.Pid
+// and c.SecretKey. Unlike the rest of Conn, CancelRequest is safe to call
+// from a goroutine other than the one using c.
+//
+// A nil error only means the request was delivered. The server may still
+// finish the query before the cancellation takes effect, and there is no
+// guarantee which query is running on c when it arrives. If the query is
+// canceled, the caller using c receives a PgError with code 57014
+// (query_canceled) and c remains usable.
+func (c *Conn) CancelRequest() error {
+	network, address := c.config.networkAddress()
+	cancelConn, err := c.config.Dial(network, address)
+	if err != nil {
+		return err
+	}
+	// Closing the TLS connection closes the underlying connection
+	defer func() { cancelConn.Close() }()
+
+	if c.tlsConfig != nil {
+		cancelConn, err = negotiateTLS(cancelConn, c.tlsConfig)
+		if err != nil {
+			return err
+		}
+	}
+
+	buf := make([]byte, 16)
+	binary.BigEndian.PutUint32(buf[0:4], 16)
+	binary.BigEndian.PutUint32(buf[4:8], 80877102)
+	binary.BigEndian.PutUint32(buf[8:12], uint32(c.Pid))
+	binary.BigEndian.PutUint32(buf[12:16], uint32(c.SecretKey))
+	_, err = cancelConn.Write(buf)
+	if err != nil {
+		return err
+	}
+
+	// The server closes the connection without responding once it has read the
+	// cancel request
+	_, err = cancelConn.Read(buf)
+	if err != io.EOF {
+		return fmt.Errorf("Server failed to close connection after cancel request: %v", err)
+	}
+
+	return nil
+}
+
+// termContext stops watching the context of the current operation. If the
+// context was done while the operation was in progress the connection is
+// closed as its state is unknown and the context error is returned instead of
+// opErr.
+func (c *Conn) termContext(opErr error) error {
+	if !c.ctxInProgress {
+		return opErr
+	}
+
+	var err error
+	select {
+	case ctxErr := <-c.closedChan:
+		c.die(ctxErr)
+		// If the operation completed before it could be interrupted its result
+		// is still valid
+		if opErr != nil {
+			err = ctxErr
+		}
+	case c.doneChan <- struct{}{}:
+		err = opErr
+	}
+
+	c.ctxInProgress = false
+	return err
+}
+
 // Processes messages that are not exclusive to one context such as
 // authentication or query response. The response to these messages
 // is the same regardless of when they occur.
@@ -1032,6 +1648,7 @@ func (c *Conn) processContextFreeMsg(t byte, r *msgReader) (err error) {
 	case errorResponse:
 		return c.rxErrorResponse(r)
 	case noticeResponse:
+		c.rxNoticeResponse(r)
 		return nil
 	case emptyQueryResponse:
 		return nil
@@ -1065,12 +1682,31 @@ func (c *Conn) rxMsg() (t byte, r *msgReader, err error) {
 func (c *Conn) rxAuthenticationX(r *msgReader) (err error) {
 	switch r.readInt32() {
 	case 0: // AuthenticationOk
+		// A server that skips AuthenticationSASLFinal has not proven it knows
+		// the password
+		if c.scram != nil {
+			err = errors.New("received AuthenticationOk before SCRAM authentication completed")
+		} else if c.config.ChannelBinding == "require" && c.scramMechanism != scramSHA256Plus {
+			err = ErrChannelBindingRequired
+		}
 	case 3: // AuthenticationCleartextPassword
+		if c.config.ChannelBinding == "require" {
+			return ErrChannelBindingRequired
+		}
 		err = c.txPasswordMessage(c.config.Password)
 	case 5: // AuthenticationMD5Password
+		if c.config.ChannelBinding == "require" {
+			return ErrChannelBindingRequired
+		}
 		salt := r.readString(4)
 		digestedPassword := "md5" + hexMD5(hexMD5(c.config.Password+c.config.User)+salt)
 		err = c.txPasswordMessage(digestedPassword)
+	case 10: // AuthenticationSASL
+		err = c.rxAuthenticationSASL(r)
+	case 11: // AuthenticationSASLContinue
+		err = c.rxAuthenticationSASLContinue(r)
+	case 12: // AuthenticationSASLFinal
+		err = c.rxAuthenticationSASLFinal(r)
 	default:
 		err = errors.New("Received unknown authentication message")
 	}
@@ -1145,6 +1781,16 @@ func (c *Conn) rxErrorResponse(r *msgReader) (err PgError) {
 	}
 }
 
+func (c *Conn) rxNoticeResponse(r *msgReader) *Notice {
+	// A notice response has the same fields as an error response but never a
+	// FATAL severity so it does not kill the connection.
+	notice := Notice(c.rxErrorResponse(r))
+	if c.config.OnNotice != nil {
+		c.config.OnNotice(c, &notice)
+	}
+	return &notice
+}
+
 func (c *Conn) rxBackendKeyData(r *msgReader) {
 	c.Pid = r.readInt32()
 	c.SecretKey = r.readInt32()
@@ -1193,26 +1839,36 @@ func (c *Conn) rxNotificationResponse(r *msgReader) {
 	n.Channel = r.readCString()
 	n.Payload = r.readCString()
 	c.notifications = append(c.notifications, n)
+
+	if c.config.OnNotification != nil {
+		c.config.OnNotification(c, n)
+	}
 }
 
 func (c *Conn) startTLS(tlsConfig *tls.Config) (err error) {
-	err = binary.Write(c.conn, binary.BigEndian, []int32{8, 80877103})
+	c.conn, err = negotiateTLS(c.conn, tlsConfig)
+	return err
+}
+
+// negotiateTLS sends an SSLRequest on conn and, if the server accepts it,
+// returns conn wrapped in a TLS client. On error the original conn is
+// returned so the caller can close it.
+func negotiateTLS(conn net.Conn, tlsConfig *tls.Config) (net.Conn, error) {
+	err := binary.Write(conn, binary.BigEndian, []int32{8, 80877103})
 	if err != nil {
-		return
+		return conn, err
 	}
 
 	response := make([]byte, 1)
-	if _, err = io.ReadFull(c.conn, response); err != nil {
-		return
+	if _, err = io.ReadFull(conn, response); err != nil {
+		return conn, err
 	}
 
 	if response[0] != 'S' {
-		return ErrTLSRefused
+		return conn, ErrTLSRefused
 	}
 
-	c.conn = tls.Client(c.conn, tlsConfig)
-
-	return nil
+	return tls.Client(conn, tlsConfig), nil
 }
 
 func (c *Conn) txStartupMessage(msg *startupMessage) error {
@@ -1294,7 +1950,3 @@ func (c *Conn) SetLogLevel(lvl int) (int, error) {
 	c.logLevel = lvl
 	return lvl, nil
 }
-
-func quoteIdentifier(s string) string {
-	return `"` + strings.Replace(s, `"`, `""`, -1) + `"`
-}
@@ -0,0 +1,316 @@
+package pgx
+
+import (
+	"fmt"
+)
+
+// Codec encodes and decodes values of a PostgreSQL data type pgx does not
+// support itself, such as a type from an extension (e.g. citext, ltree, or
+// PostGIS geometry). A Codec is registered by type name with
+// ConnConfig.Codecs or ConnInfo.RegisterCodec.
+//
+// Arguments that implement Encoder and destinations that implement Scanner or
+// PgxScanner are handled by their own methods. Strings and []byte arguments
+// are sent as is and *[]byte destinations receive the raw bytes. All other
+// arguments and destinations of the type are handled by the Codec, as are
+// arrays of the type if the Codec uses the binary format.
+type Codec interface {
+	// FormatCode returns the format values are sent and requested in
+	// (TextFormatCode or BinaryFormatCode). Only binary format codecs can be
+	// used with CopyTo.
+	FormatCode() int16
+
+	// Encode writes value, which is not nil, to w as type oid including the
+	// int32 length prefix.
+	Encode(w *WriteBuf, oid Oid, value interface{}) error
+
+	// Decode decodes vr into d, which is a pointer. vr.Len() is -1 for NULL.
+	// It should return an error for destination types it does not support.
+	// Pointers to pointers and *interface{} are handled by pgx.
+	Decode(vr *ValueReader, d interface{}) error
+
+	// DecodeValue decodes vr, which is not NULL, into the value returned by
+	// Rows.Values.
+	DecodeValue(vr *ValueReader) (interface{}, error)
+}
+
+// DataType is a PostgreSQL data type known to a ConnInfo.
+type DataType struct {
+	Name       string
+	Oid        Oid
+	ElementOid Oid   // element type if this is an array type
+	FormatCode int16 // format values are sent and requested in
+	Codec      Codec // nil for types pgx handles itself
+
+	// decodeValue and decodeTextValue decode a binary or text format value
+	// for Rows.Values. They are set for the types pgx supports itself.
+	decodeValue     func(vr *ValueReader) interface{}
+	decodeTextValue func(vr *ValueReader) interface{}
+}
+
+// ConnInfo maps the oids and names of the data types known to a connection
+// to how pgx encodes and decodes them. It is loaded from pg_type when the
+// connection is established. Changes only affect the connection the ConnInfo
+// belongs to.
+type ConnInfo struct {
+	oidToDataType  map[Oid]*DataType
+	nameToDataType map[string]*DataType
+	enumOids       map[Oid]struct{}  // all enum types
+	enumTypes      map[Oid]*EnumType // enum types registered with RegisterEnumType
+}
+
+// NewConnInfo returns a ConnInfo without any data types.
+func NewConnInfo() *ConnInfo {
+	return &ConnInfo{
+		oidToDataType:  make(map[Oid]*DataType, 128),
+		nameToDataType: make(map[string]*DataType, 128),
+		enumOids:       make(map[Oid]struct{}),
+		enumTypes:      make(map[Oid]*EnumType),
+	}
+}
+
+// RegisterDataType adds dt or replaces the data type with the same oid.
+func (ci *ConnInfo) RegisterDataType(dt DataType) {
+	if old, ok := ci.oidToDataType[dt.Oid]; ok && ci.nameToDataType[old.Name] == old {
+		delete(ci.nameToDataType, old.Name)
+	}
+	ci.oidToDataType[dt.Oid] = &dt
+	ci.nameToDataType[dt.Name] = &dt
+}
+
+// DataTypeForOid returns the data type with oid.
+func (ci *ConnInfo) DataTypeForOid(oid Oid) (*DataType, bool) {
+	dt, ok := ci.oidToDataType[oid]
+	return dt, ok
+}
+
+// DataTypeForName returns the data type named name (e.g. int4 or _int4 for
+// its array type). If types in different schemas have the same name the one
+// loaded last is returned.
+func (ci *ConnInfo) DataTypeForName(name string) (*DataType, bool) {
+	dt, ok := ci.nameToDataType[name]
+	return dt, ok
+}
+
+// RegisterCodec sets the codec of the data type named name. If codec uses the
+// binary format then arrays of the type are requested in binary format as
+// well so they can be decoded with codec.
+func (ci *ConnInfo) RegisterCodec(name string, codec Codec) error {
+	dt, ok := ci.nameToDataType[name]
+	if !ok {
+		return fmt.Errorf("Cannot register codec for unknown data type %s", name)
+	}
+
+	dt.Codec = codec
+	dt.FormatCode = codec.FormatCode()
+
+	if dt.FormatCode == BinaryFormatCode {
+		for _, arrayType := range ci.oidToDataType {
+			if arrayType.ElementOid == dt.Oid {
+				arrayType.FormatCode = BinaryFormatCode
+			}
+		}
+	}
+
+	return nil
+}
+
+// clone returns a copy of ci that can be changed without affecting ci.
+func (ci *ConnInfo) clone() *ConnInfo {
+	c := &ConnInfo{
+		oidToDataType:  make(map[Oid]*DataType, len(ci.oidToDataType)),
+		nameToDataType: make(map[string]*DataType, len(ci.nameToDataType)),
+		enumOids:       make(map[Oid]struct{}, len(ci.enumOids)),
+		enumTypes:      make(map[Oid]*EnumType, len(ci.enumTypes)),
+	}
+
+	for oid, dt := range ci.oidToDataType {
+		dtCopy := *dt
+		c.oidToDataType[oid] = &dtCopy
+		if ci.nameToDataType[dt.Name] == dt {
+			c.nameToDataType[dt.Name] = &dtCopy
+		}
+	}
+
+	for oid := range ci.enumOids {
+		c.enumOids[oid] = struct{}{}
+	}
+
+	// EnumTypes are not modified once registered so they can be shared
+	for oid, et := range ci.enumTypes {
+		c.enumTypes[oid] = et
+	}
+
+	return c
+}
+
+// pgTypes returns the data types of ci as Conn.PgTypes.
+func (ci *ConnInfo) pgTypes() map[Oid]PgType {
+	pgTypes := make(map[Oid]PgType, len(ci.oidToDataType))
+	for oid, dt := range ci.oidToDataType {
+		pgTypes[oid] = PgType{Name: dt.Name, DefaultFormat: dt.FormatCode}
+	}
+	return pgTypes
+}
+
+// arrayElementOid returns the element type of oid if it is an array type that
+// is decoded and encoded as a binary format array.
+func (ci *ConnInfo) arrayElementOid(oid Oid) (Oid, bool) {
+	dt, ok := ci.oidToDataType[oid]
+	if !ok || dt.ElementOid == 0 || dt.FormatCode != BinaryFormatCode {
+		return 0, false
+	}
+	return dt.ElementOid, true
+}
+
+// codec returns the codec registered for oid or nil if there is none.
+func (ci *ConnInfo) codec(oid Oid) Codec {
+	if dt, ok := ci.oidToDataType[oid]; ok {
+		return dt.Codec
+	}
+	return nil
+}
+
+// ConnInfo returns the data types known to c. Registering a codec or a data
+// type on it only affects c. It is nil for replication connections.
+func (c *Conn) ConnInfo() *ConnInfo {
+	return c.connInfo
+}
+
+// defaultConnInfo holds the data types pgx supports itself. It is used for
+// encoding and decoding without a connection or on a connection that has not
+// loaded its data types such as a replication connection.
+var defaultConnInfo *ConnInfo
+
+// connInfoOf returns the ConnInfo of c, which may be nil, or defaultConnInfo.
+func connInfoOf(c *Conn) *ConnInfo {
+	if c == nil || c.connInfo == nil {
+		return defaultConnInfo
+	}
+	return c.connInfo
+}
+
+// newDefaultConnInfo returns a ConnInfo with the data types pgx supports
+// itself in their DefaultTypeFormats format.
+func newDefaultConnInfo() *ConnInfo {
+	ci := NewConnInfo()
+	for _, dt := range builtinDataTypes {
+		if dt.Oid == 0 {
+			continue
+		}
+		dt.FormatCode = DefaultTypeFormats[dt.Name]
+		ci.RegisterDataType(dt)
+	}
+	return ci
+}
+
+// builtinDataTypeByOid and builtinDataTypeByName index builtinDataTypes.
+var builtinDataTypeByOid map[Oid]*DataType
+var builtinDataTypeByName map[string]*DataType
+
+func init() {
+	builtinDataTypeByOid = make(map[Oid]*DataType, len(builtinDataTypes))
+	builtinDataTypeByName = make(map[string]*DataType, len(builtinDataTypes))
+	for i := range builtinDataTypes {
+		dt := &builtinDataTypes[i]
+		if dt.Oid != 0 {
+			builtinDataTypeByOid[dt.Oid] = dt
+		}
+		builtinDataTypeByName[dt.Name] = dt
+	}
+}
+
+// builtinDataType returns the data type pgx supports itself with oid or, for
+// extension types with a different oid in each database, name.
+func builtinDataType(oid Oid, name string) (*DataType, bool) {
+	if dt, ok := builtinDataTypeByOid[oid]; ok {
+		return dt, true
+	}
+	if dt, ok := builtinDataTypeByName[name]; ok && dt.Oid == 0 {
+		return dt, true
+	}
+	return nil, false
+}
+
+// builtinDataTypes are the data types pgx supports itself. Their formats come
+// from DefaultTypeFormats. Extension types have an Oid of 0.
+var builtinDataTypes = []DataType{
+	{Name: "bool", Oid: BoolOid, decodeValue: func(vr *ValueReader) interface{} { return decodeBool(vr) }},
+	{Name: "bytea", Oid: ByteaOid, decodeValue: func(vr *ValueReader) interface{} { return decodeBytea(vr) }},
+	{Name: "char", Oid: CharOid, decodeValue: func(vr *ValueReader) interface{} { return decodeChar(vr) }},
+	{Name: "name", Oid: NameOid, decodeValue: func(vr *ValueReader) interface{} { return Name(decodeText(vr)) }},
+	{Name: "int8", Oid: Int8Oid, decodeValue: func(vr *ValueReader) interface{} { return decodeInt8(vr) }},
+	{Name: "int2", Oid: Int2Oid, decodeValue: func(vr *ValueReader) interface{} { return decodeInt2(vr) }},
+	{Name: "int4", Oid: Int4Oid, decodeValue: func(vr *ValueReader) interface{} { return decodeInt4(vr) }},
+	{Name: "text", Oid: TextOid, decodeValue: func(vr *ValueReader) interface{} { return decodeText(vr) }},
+	{Name: "oid", Oid: OidOid, decodeValue: func(vr *ValueReader) interface{} { return decodeOid(vr) }},
+	{Name: "tid", Oid: TidOid, decodeValue: func(vr *ValueReader) interface{} { return decodeTid(vr) }},
+	{Name: "xid", Oid: XidOid, decodeValue: func(vr *ValueReader) interface{} { return decodeXid(vr) }},
+	{Name: "cid", Oid: CidOid, decodeValue: func(vr *ValueReader) interface{} { return decodeCid(vr) }},
+	{Name: "json", Oid: JsonOid, decodeValue: decodeJSONValue},
+	{Name: "cidr", Oid: CidrOid, decodeValue: func(vr *ValueReader) interface{} { return decodeInet(vr) }},
+	{Name: "_cidr", Oid: CidrArrayOid, ElementOid: CidrOid, decodeValue: func(vr *ValueReader) interface{} { return decodeInetArray(vr) }},
+	{Name: "float4", Oid: Float4Oid, decodeValue: func(vr *ValueReader) interface{} { return decodeFloat4(vr) }},
+	{Name: "float8", Oid: Float8Oid, decodeValue: func(vr *ValueReader) interface{} { return decodeFloat8(vr) }},
+	{Name: "unknown", Oid: UnknownOid, decodeValue: func(vr *ValueReader) interface{} { return decodeText(vr) }},
+	{Name: "inet", Oid: InetOid, decodeValue: func(vr *ValueReader) interface{} { return decodeInet(vr) }},
+	{Name: "_bool", Oid: BoolArrayOid, ElementOid: BoolOid, decodeValue: func(vr *ValueReader) interface{} { return decodeBoolArray(vr) }},
+	{Name: "_bytea", Oid: ByteaArrayOid, ElementOid: ByteaOid, decodeValue: func(vr *ValueReader) interface{} { return decodeByteaArray(vr) }},
+	{Name: "_int2", Oid: Int2ArrayOid, ElementOid: Int2Oid, decodeValue: func(vr *ValueReader) interface{} { return decodeInt2Array(vr) }},
+	{Name: "_int4", Oid: Int4ArrayOid, ElementOid: Int4Oid, decodeValue: func(vr *ValueReader) interface{} { return decodeInt4Array(vr) }},
+	{Name: "_text", Oid: TextArrayOid, ElementOid: TextOid, decodeValue: func(vr *ValueReader) interface{} { return decodeTextArray(vr) }},
+	{Name: "_varchar", Oid: VarcharArrayOid, ElementOid: VarcharOid, decodeValue: func(vr *ValueReader) interface{} { return decodeTextArray(vr) }},
+	{Name: "_int8", Oid: Int8ArrayOid, ElementOid: Int8Oid, decodeValue: func(vr *ValueReader) interface{} { return decodeInt8Array(vr) }},
+	{Name: "_float4", Oid: Float4ArrayOid, ElementOid: Float4Oid, decodeValue: func(vr *ValueReader) interface{} { return decodeFloat4Array(vr) }},
+	{Name: "_float8", Oid: Float8ArrayOid, ElementOid: Float8Oid, decodeValue: func(vr *ValueReader) interface{} { return decodeFloat8Array(vr) }},
+	{Name: "aclitem", Oid: AclItemOid, decodeTextValue: func(vr *ValueReader) interface{} { return AclItem(decodeText(vr)) }},
+	{Name: "_aclitem", Oid: AclItemArrayOid, ElementOid: AclItemOid, decodeTextValue: func(vr *ValueReader) interface{} { return decodeAclItemArray(vr) }},
+	{Name: "_inet", Oid: InetArrayOid, ElementOid: InetOid, decodeValue: func(vr *ValueReader) interface{} { return decodeInetArray(vr) }},
+	{Name: "varchar", Oid: VarcharOid, decodeValue: func(vr *ValueReader) interface{} { return decodeText(vr) }},
+	{Name: "date", Oid: DateOid, decodeValue: func(vr *ValueReader) interface{} { return decodeDate(vr) }},
+	{Name: "time", Oid: TimeOid, decodeValue: func(vr *ValueReader) interface{} { return decodeTimeOfDay(vr) }},
+	{Name: "timestamp", Oid: TimestampOid, decodeValue: func(vr *ValueReader) interface{} { return decodeTimestamp(vr) }},
+	{Name: "_timestamp", Oid: TimestampArrayOid, ElementOid: TimestampOid, decodeValue: func(vr *ValueReader) interface{} { return decodeTimestampArray(vr) }},
+	{Name: "_date", Oid: DateArrayOid, ElementOid: DateOid, decodeValue: func(vr *ValueReader) interface{} { return decodeDateArray(vr) }},
+	{Name: "timestamptz", Oid: TimestampTzOid, decodeValue: func(vr *ValueReader) interface{} { return decodeTimestampTz(vr) }},
+	{Name: "_timestamptz", Oid: TimestampTzArrayOid, ElementOid: TimestampTzOid, decodeValue: func(vr *ValueReader) interface{} { return decodeTimestampArray(vr) }},
+	{Name: "interval", Oid: IntervalOid, decodeValue: func(vr *ValueReader) interface{} { return decodeInterval(vr) }},
+	{Name: "_numeric", Oid: NumericArrayOid, ElementOid: NumericOid, decodeValue: func(vr *ValueReader) interface{} { return decodeNumericArray(vr) }},
+	{Name: "numeric", Oid: NumericOid, decodeValue: func(vr *ValueReader) interface{} { return decodeNumeric(vr) }},
+	{Name: "timetz", Oid: TimetzOid, decodeValue: func(vr *ValueReader) interface{} { return decodeTimeOfDay(vr) }},
+	{Name: "record", Oid: RecordOid, decodeValue: func(vr *ValueReader) interface{} { return decodeRecord(vr) }},
+	{Name: "uuid", Oid: UuidOid, decodeValue: func(vr *ValueReader) interface{} { return decodeUUID(vr) }},
+	{Name: "_uuid", Oid: UuidArrayOid, ElementOid: UuidOid, decodeValue: func(vr *ValueReader) interface{} { return decodeUUIDArray(vr) }},
+	{Name: "jsonb", Oid: JsonbOid, decodeValue: decodeJSONBValue},
+	{Name: "int4range", Oid: Int4RangeOid, decodeValue: decodeRangeValue},
+	{Name: "numrange", Oid: NumRangeOid, decodeValue: decodeRangeValue},
+	{Name: "tsrange", Oid: TsRangeOid, decodeValue: decodeRangeValue},
+	{Name: "tstzrange", Oid: TstzRangeOid, decodeValue: decodeRangeValue},
+	{Name: "daterange", Oid: DateRangeOid, decodeValue: decodeRangeValue},
+	{Name: "int8range", Oid: Int8RangeOid, decodeValue: decodeRangeValue},
+	{Name: "hstore", decodeTextValue: decodeHstoreValue},
+}
+
+func decodeJSONValue(vr *ValueReader) interface{} {
+	var d interface{}
+	decodeJSON(vr, &d)
+	return d
+}
+
+func decodeJSONBValue(vr *ValueReader) interface{} {
+	var d interface{}
+	decodeJSONB(vr, &d)
+	return d
+}
+
+func decodeRangeValue(vr *ValueReader) interface{} {
+	return decodeRange(vr)
+}
+
+func decodeHstoreValue(vr *ValueReader) interface{} {
+	var h NullHstore
+	if err := h.Scan(vr); err != nil {
+		vr.Fatal(err)
+	}
+	return h
+}
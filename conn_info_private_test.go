@@ -0,0 +1,317 @@
+package pgx
+
+import (
+	"fmt"
+	"net"
+	"reflect"
+	"testing"
+)
+
+type codecPoint struct {
+	X, Y int32
+}
+
+// codecPointCodec encodes a codecPoint as two int4s.
+type codecPointCodec struct{}
+
+func (codecPointCodec) FormatCode() int16 { return BinaryFormatCode }
+
+func (codecPointCodec) Encode(w *WriteBuf, oid Oid, value interface{}) error {
+	p, ok := value.(codecPoint)
+	if !ok {
+		return fmt.Errorf("Cannot encode %T", value)
+	}
+	w.WriteInt32(8)
+	w.WriteInt32(p.X)
+	w.WriteInt32(p.Y)
+	return nil
+}
+
+func (codecPointCodec) Decode(vr *ValueReader, d interface{}) error {
+	p, ok := d.(*codecPoint)
+	if !ok {
+		return fmt.Errorf("Cannot decode into %T", d)
+	}
+	if vr.Len() == -1 {
+		return fmt.Errorf("Cannot decode null into %T", d)
+	}
+	p.X = vr.ReadInt32()
+	p.Y = vr.ReadInt32()
+	return vr.Err()
+}
+
+func (c codecPointCodec) DecodeValue(vr *ValueReader) (interface{}, error) {
+	var p codecPoint
+	err := c.Decode(vr, &p)
+	return p, err
+}
+
+// Oids of the types registered by newTestConn. They are above any oid
+// PostgreSQL assigns to built-in types.
+const (
+	testAddressOid Oid = 100001 + iota
+	testAddressArrayOid
+	testPersonOid
+	testMoodOid
+	testMoodArrayOid
+	testColorOid
+	testColorArrayOid
+	testPtOid
+	testPtArrayOid
+	testUnknownOid // not registered
+)
+
+// newTestConn returns a *Conn with these types registered:
+//
+//   - address and person composite types as by RegisterCompositeType
+//   - a mood enum as by RegisterEnumType
+//   - a color enum as found by loadPgTypes but not registered
+//   - a pt type with a codecPointCodec as by RegisterCodec
+func newTestConn() *Conn {
+	address := &CompositeType{
+		Name:     "address",
+		Oid:      testAddressOid,
+		ArrayOid: testAddressArrayOid,
+		Fields: []CompositeField{
+			{Name: "street", DataType: TextOid},
+			{Name: "postal_code", DataType: Int4Oid},
+		},
+	}
+	person := &CompositeType{
+		Name: "person",
+		Oid:  testPersonOid,
+		Fields: []CompositeField{
+			{Name: "name", DataType: TextOid},
+			{Name: "home", DataType: testAddressOid},
+			{Name: "work", DataType: testAddressOid},
+			{Name: "previous", DataType: testAddressArrayOid},
+			{Name: "nicknames", DataType: TextArrayOid},
+		},
+	}
+
+	c := &Conn{connInfo: newDefaultConnInfo()}
+	ci := c.connInfo
+
+	ci.RegisterDataType(DataType{Name: "address", Oid: testAddressOid, FormatCode: BinaryFormatCode, Codec: &compositeCodec{ct: address}})
+	ci.RegisterDataType(DataType{Name: "_address", Oid: testAddressArrayOid, ElementOid: testAddressOid, FormatCode: BinaryFormatCode})
+	ci.RegisterDataType(DataType{Name: "person", Oid: testPersonOid, FormatCode: BinaryFormatCode, Codec: &compositeCodec{ct: person}})
+
+	ci.enumOids[testMoodOid] = struct{}{}
+	ci.enumTypes[testMoodOid] = &EnumType{Name: "mood", Oid: testMoodOid, ArrayOid: testMoodArrayOid, Labels: []string{"sad", "ok", "happy"}}
+	ci.RegisterDataType(DataType{Name: "mood", Oid: testMoodOid, FormatCode: TextFormatCode})
+	ci.RegisterDataType(DataType{Name: "_mood", Oid: testMoodArrayOid, ElementOid: testMoodOid, FormatCode: BinaryFormatCode})
+	ci.enumOids[testColorOid] = struct{}{}
+	ci.RegisterDataType(DataType{Name: "color", Oid: testColorOid, FormatCode: TextFormatCode})
+	ci.RegisterDataType(DataType{Name: "_color", Oid: testColorArrayOid, ElementOid: testColorOid, FormatCode: BinaryFormatCode})
+
+	ci.RegisterDataType(DataType{Name: "pt", Oid: testPtOid})
+	ci.RegisterDataType(DataType{Name: "_pt", Oid: testPtArrayOid, ElementOid: testPtOid})
+	if err := ci.RegisterCodec("pt", codecPointCodec{}); err != nil {
+		panic(err)
+	}
+
+	return c
+}
+
+// encodeValueReader encodes value as oid with c and returns a ValueReader
+// that reads it back in the binary format.
+func encodeValueReader(c *Conn, oid Oid, value interface{}) (*ValueReader, error) {
+	w := &WriteBuf{conn: c}
+	if err := Encode(w, oid, value); err != nil {
+		return nil, err
+	}
+
+	vr := valueReaderFor(w.buf, oid, BinaryFormatCode)
+	vr.conn = c
+	return vr, nil
+}
+
+// roundTrip encodes value as oid with c and decodes it into a new value of
+// the same type.
+func roundTrip(c *Conn, oid Oid, value interface{}) (interface{}, error) {
+	vr, err := encodeValueReader(c, oid, value)
+	if err != nil {
+		return nil, err
+	}
+
+	result := reflect.New(reflect.TypeOf(value))
+	if err := Decode(vr, result.Interface()); err != nil {
+		return nil, err
+	}
+	if vr.Err() != nil {
+		return nil, vr.Err()
+	}
+	if vr.Len() != 0 {
+		return nil, fmt.Errorf("Expected all bytes to be read, %d remaining", vr.Len())
+	}
+
+	return result.Elem().Interface(), nil
+}
+
+func TestConnInfoRegisterCodec(t *testing.T) {
+	c := newTestConn()
+
+	dt, ok := c.connInfo.DataTypeForName("pt")
+	if !ok || dt.Oid != testPtOid || dt.FormatCode != BinaryFormatCode || dt.Codec == nil {
+		t.Fatalf("Unexpected data type: %+v", dt)
+	}
+	if dt, _ := c.connInfo.DataTypeForOid(testPtArrayOid); dt.FormatCode != BinaryFormatCode {
+		t.Errorf("Expected array of binary codec type to be binary, got %v", dt.FormatCode)
+	}
+
+	if err := c.connInfo.RegisterCodec("missing", codecPointCodec{}); err == nil {
+		t.Error("Expected error registering codec for unknown type")
+	}
+}
+
+func TestConnInfoClone(t *testing.T) {
+	ci := newDefaultConnInfo()
+	clone := ci.clone()
+
+	clone.RegisterDataType(DataType{Name: "pt", Oid: testPtOid})
+	if err := clone.RegisterCodec("int4", codecPointCodec{}); err != nil {
+		t.Fatalf("Unexpected failure: %v", err)
+	}
+
+	if _, ok := ci.DataTypeForName("pt"); ok {
+		t.Error("Data type registered on clone was registered on original")
+	}
+	if ci.codec(Int4Oid) != nil {
+		t.Error("Codec registered on clone was registered on original")
+	}
+}
+
+func TestCodecRoundTrip(t *testing.T) {
+	c := newTestConn()
+	p := codecPoint{1, 2}
+
+	tests := []struct {
+		oid   Oid
+		value interface{}
+	}{
+		{testPtOid, codecPoint{3, 4}},
+		{testPtOid, &p},
+		{testPtArrayOid, []codecPoint{{1, 2}, {3, 4}}},
+		{testPtArrayOid, []*codecPoint{&p, nil}},
+		{testPtArrayOid, [][]codecPoint{{{1, 2}}, {{3, 4}}}},
+	}
+
+	for i, tt := range tests {
+		result, err := roundTrip(c, tt.oid, tt.value)
+		if err != nil {
+			t.Errorf("%d. Unexpected error: %v", i, err)
+			continue
+		}
+		if !reflect.DeepEqual(result, tt.value) {
+			t.Errorf("%d. Decoded %+v, expected %+v", i, result, tt.value)
+		}
+	}
+}
+
+func TestCodecDecodeValue(t *testing.T) {
+	c := newTestConn()
+
+	vr, err := encodeValueReader(c, testPtArrayOid, []*codecPoint{{1, 2}, nil})
+	if err != nil {
+		t.Fatalf("Unexpected failure: %v", err)
+	}
+	v := decodeValue(vr)
+	if vr.Err() != nil {
+		t.Fatalf("Unexpected failure: %v", vr.Err())
+	}
+	if expected := []interface{}{codecPoint{1, 2}, nil}; !reflect.DeepEqual(v, expected) {
+		t.Errorf("Decoded %#v, expected %#v", v, expected)
+	}
+
+	vr, err = encodeValueReader(c, testPtOid, codecPoint{3, 4})
+	if err != nil {
+		t.Fatalf("Unexpected failure: %v", err)
+	}
+	var d interface{}
+	if err := Decode(vr, &d); err != nil {
+		t.Fatalf("Unexpected failure: %v", err)
+	}
+	if d != (codecPoint{3, 4}) {
+		t.Errorf("Decoded %#v, expected %#v", d, codecPoint{3, 4})
+	}
+}
+
+func TestDecodeValue(t *testing.T) {
+	afInet, afInet6 := byte(2), byte(3)
+	c := newTestConn()
+	c.pgsqlAfInet, c.pgsqlAfInet6 = &afInet, &afInet6
+	_, ipNet, _ := net.ParseCIDR("127.0.0.1/32")
+
+	tests := []struct {
+		oid      Oid
+		value    interface{}
+		expected interface{}
+	}{
+		{NameOid, Name("foo"), Name("foo")},
+		{CharOid, Char('x'), Char('x')},
+		{XidOid, Xid(42), Xid(42)},
+		{CidOid, Cid(43), Cid(43)},
+		{TidOid, NullTid{Tid: Tid{1, 2}, Valid: true}, Tid{1, 2}},
+		{UuidOid, [16]byte{1, 2, 3}, UUID{1, 2, 3}},
+		{InetArrayOid, []net.IPNet{*ipNet}, []net.IPNet{*ipNet}},
+		{ByteaArrayOid, [][]byte{{1, 2}, {3}}, [][]byte{{1, 2}, {3}}},
+	}
+
+	for i, tt := range tests {
+		w := &WriteBuf{conn: c}
+		if err := Encode(w, tt.oid, tt.value); err != nil {
+			t.Errorf("%d. Unexpected error: %v", i, err)
+			continue
+		}
+
+		vr := valueReaderFor(w.buf, tt.oid, BinaryFormatCode)
+		vr.conn = c
+		v := decodeValue(vr)
+		if vr.Err() != nil {
+			t.Errorf("%d. Unexpected error: %v", i, vr.Err())
+			continue
+		}
+		if !reflect.DeepEqual(v, tt.expected) {
+			t.Errorf("%d. Decoded %#v, expected %#v", i, v, tt.expected)
+		}
+	}
+
+	textTests := []struct {
+		oid      Oid
+		name     string
+		format   int16
+		src      string
+		expected interface{}
+	}{
+		{AclItemOid, "aclitem", TextFormatCode, "postgres=arwdDxt/postgres", AclItem("postgres=arwdDxt/postgres")},
+		{AclItemArrayOid, "_aclitem", TextFormatCode, "{postgres=r/postgres}", []AclItem{"postgres=r/postgres"}},
+		{testUnknownOid, "hstore", TextFormatCode, `"a"=>"b"`, NullHstore{Hstore: map[string]NullString{"a": {String: "b", Valid: true}}, Valid: true}},
+		{testUnknownOid, "widget", TextFormatCode, "(1,2)", "(1,2)"},
+		{testUnknownOid, "widget", BinaryFormatCode, "\x01\x02", []byte{1, 2}},
+		{1042, "bpchar", BinaryFormatCode, "ab  ", "ab  "},
+		{VarcharOid, "varchar", BinaryFormatCode, "cd", "cd"},
+		{testMoodOid, "mood", BinaryFormatCode, "happy", "happy"},
+	}
+
+	// Types pgx reads as text that can be in binary format in copy data
+	c.connInfo.RegisterDataType(DataType{Name: "bpchar", Oid: 1042})
+	c.connInfo.RegisterDataType(DataType{Name: "varchar", Oid: VarcharOid})
+
+	for i, tt := range textTests {
+		w := &WriteBuf{conn: c}
+		w.WriteInt32(int32(len(tt.src)))
+		w.WriteBytes([]byte(tt.src))
+
+		vr := valueReaderFor(w.buf, tt.oid, tt.format)
+		vr.fd.DataTypeName = tt.name
+		vr.conn = c
+		v := decodeValue(vr)
+		if vr.Err() != nil {
+			t.Errorf("%d. Unexpected error: %v", i, vr.Err())
+			continue
+		}
+		if !reflect.DeepEqual(v, tt.expected) {
+			t.Errorf("%d. Decoded %#v, expected %#v", i, v, tt.expected)
+		}
+	}
+}
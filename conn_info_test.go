@@ -0,0 +1,169 @@
+package pgx_test
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/jackc/pgx"
+)
+
+type geoPoint struct {
+	X, Y float64
+}
+
+// geoPointCodec maps the PostgreSQL point type to geoPoint.
+type geoPointCodec struct{}
+
+func (geoPointCodec) FormatCode() int16 { return pgx.BinaryFormatCode }
+
+func (geoPointCodec) Encode(w *pgx.WriteBuf, oid pgx.Oid, value interface{}) error {
+	p, ok := value.(geoPoint)
+	if !ok {
+		return fmt.Errorf("Cannot encode %T into point", value)
+	}
+	w.WriteInt32(16)
+	w.WriteInt64(int64(math.Float64bits(p.X)))
+	w.WriteInt64(int64(math.Float64bits(p.Y)))
+	return nil
+}
+
+func (geoPointCodec) Decode(vr *pgx.ValueReader, d interface{}) error {
+	p, ok := d.(*geoPoint)
+	if !ok {
+		return fmt.Errorf("Cannot decode point into %T", d)
+	}
+	if vr.Len() == -1 {
+		return fmt.Errorf("Cannot decode null into %T", d)
+	}
+	p.X = math.Float64frombits(uint64(vr.ReadInt64()))
+	p.Y = math.Float64frombits(uint64(vr.ReadInt64()))
+	return vr.Err()
+}
+
+func (c geoPointCodec) DecodeValue(vr *pgx.ValueReader) (interface{}, error) {
+	var p geoPoint
+	err := c.Decode(vr, &p)
+	return p, err
+}
+
+func codecConnConfig() pgx.ConnConfig {
+	config := *defaultConnConfig
+	config.Codecs = map[string]pgx.Codec{"point": geoPointCodec{}}
+	return config
+}
+
+func TestCodecTranscode(t *testing.T) {
+	t.Parallel()
+
+	conn := mustConnect(t, codecConnConfig())
+	defer closeConn(t, conn)
+
+	var p geoPoint
+	var ps []*geoPoint
+	var null *geoPoint
+	err := conn.QueryRow("select $1::point, $2::point[], null::point", geoPoint{1.5, 2}, []*geoPoint{{3, 4}, nil}).Scan(&p, &ps, &null)
+	if err != nil {
+		t.Fatalf("conn.QueryRow failed: %v", err)
+	}
+	if p != (geoPoint{1.5, 2}) {
+		t.Errorf("Expected %v, got %v", geoPoint{1.5, 2}, p)
+	}
+	if len(ps) != 2 || *ps[0] != (geoPoint{3, 4}) || ps[1] != nil {
+		t.Errorf("Unexpected point array: %v", ps)
+	}
+	if null != nil {
+		t.Errorf("Expected nil, got %v", null)
+	}
+
+	rows, err := conn.Query("select '(5,6)'::point, array['(7,8)'::point]")
+	if err != nil {
+		t.Fatalf("conn.Query failed: %v", err)
+	}
+	for rows.Next() {
+		values, err := rows.Values()
+		if err != nil {
+			t.Fatalf("rows.Values failed: %v", err)
+		}
+		expected := []interface{}{geoPoint{5, 6}, []interface{}{geoPoint{7, 8}}}
+		if !reflect.DeepEqual(values, expected) {
+			t.Errorf("Expected %v, got %v", expected, values)
+		}
+	}
+	if rows.Err() != nil {
+		t.Fatalf("rows.Err failed: %v", rows.Err())
+	}
+
+	ensureConnValid(t, conn)
+}
+
+func TestCodecCopyTo(t *testing.T) {
+	t.Parallel()
+
+	conn := mustConnect(t, codecConnConfig())
+	defer closeConn(t, conn)
+
+	mustExec(t, conn, "create temporary table foo(a point)")
+
+	inputRows := [][]interface{}{{geoPoint{1, 2}}, {nil}}
+	copyCount, err := conn.CopyTo("foo", []string{"a"}, pgx.CopyToRows(inputRows))
+	if err != nil {
+		t.Fatalf("Unexpected error for CopyTo: %v", err)
+	}
+	if copyCount != len(inputRows) {
+		t.Errorf("Expected CopyTo to return %d copied rows, but got %d", len(inputRows), copyCount)
+	}
+
+	var p geoPoint
+	if err := conn.QueryRow("select a from foo where a is not null").Scan(&p); err != nil {
+		t.Fatalf("conn.QueryRow failed: %v", err)
+	}
+	if p != (geoPoint{1, 2}) {
+		t.Errorf("Expected %v, got %v", geoPoint{1, 2}, p)
+	}
+
+	ensureConnValid(t, conn)
+}
+
+func TestCodecUnknownType(t *testing.T) {
+	t.Parallel()
+
+	config := *defaultConnConfig
+	config.Codecs = map[string]pgx.Codec{"nonexistent_type": geoPointCodec{}}
+
+	conn, err := pgx.Connect(config)
+	if err == nil {
+		conn.Close()
+		t.Fatal("Expected error connecting with codec for unknown type")
+	}
+}
+
+func TestConnPoolCodecs(t *testing.T) {
+	t.Parallel()
+
+	pool, err := pgx.NewConnPool(pgx.ConnPoolConfig{ConnConfig: codecConnConfig(), MaxConnections: 2})
+	if err != nil {
+		t.Fatalf("Unable to create connection pool: %v", err)
+	}
+	defer pool.Close()
+
+	conns := make([]*pgx.Conn, 2)
+	for i := range conns {
+		conns[i], err = pool.Acquire()
+		if err != nil {
+			t.Fatalf("pool.Acquire failed: %v", err)
+		}
+	}
+
+	for _, conn := range conns {
+		var p geoPoint
+		if err := conn.QueryRow("select $1::point", geoPoint{1, 2}).Scan(&p); err != nil {
+			t.Fatalf("conn.QueryRow failed: %v", err)
+		}
+		if p != (geoPoint{1, 2}) {
+			t.Errorf("Expected %v, got %v", geoPoint{1, 2}, p)
+		}
+		pool.Release(conn)
+	}
+}
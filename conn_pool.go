@@ -1,7 +1,9 @@
 package pgx
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"sync"
 	"time"
 )
@@ -11,8 +13,38 @@ type ConnPoolConfig struct {
 	MaxConnections int               // max simultaneous connections to use, default 5, must be at least 2
 	AfterConnect   func(*Conn) error // function to call on every new connection
 	AcquireTimeout time.Duration     // max wait time when all connections are busy (0 means no timeout)
+
+	// MaxConnLifetime is the duration after which a connection is closed
+	// instead of being reused. Connections that are acquired when they expire
+	// are closed when they are released. 0 means no limit.
+	MaxConnLifetime time.Duration
+
+	// MaxConnIdleTime is the duration after which an unused connection is
+	// closed, as long as MinIdleConnections are still available. 0 means no
+	// limit.
+	MaxConnIdleTime time.Duration
+
+	// MinIdleConnections is the number of available connections the pool
+	// tries to maintain. It must not be greater than MaxConnections.
+	MinIdleConnections int
+
+	// Observer, if set, is notified of acquires, connections being created and
+	// destroyed, and queries run on pooled connections.
+	Observer ConnPoolObserver
+
+	// HealthCheckPeriod is how often the pool closes expired connections,
+	// tops up to MinIdleConnections, and pings connections that have been
+	// idle for at least HealthCheckPeriod so dead connections are discarded
+	// before they are acquired. It defaults to 1 minute when any of the
+	// options above are set. Otherwise health checks only run if
+	// HealthCheckPeriod is set.
+	HealthCheckPeriod time.Duration
 }
 
+// defaultHealthCheckPeriod is the HealthCheckPeriod used when a connection
+// lifecycle option is set without a HealthCheckPeriod.
+const defaultHealthCheckPeriod = time.Minute
+
 type ConnPool struct {
 	allConnections       []*Conn
 	availableConnections []*Conn
@@ -27,22 +59,120 @@ type ConnPool struct {
 	closed               bool
 	preparedStatements   map[string]*PreparedStatement
 	acquireTimeout       time.Duration
-	pgTypes              map[Oid]PgType
+	connInfo             *ConnInfo
 	pgsqlAfInet          *byte
 	pgsqlAfInet6         *byte
 	txAfterClose         func(tx *Tx)
 	rowsAfterClose       func(rows *Rows)
+	maxConnLifetime      time.Duration
+	maxConnIdleTime      time.Duration
+	minIdleConnections   int
+	healthCheckPeriod    time.Duration
+	closeChan            chan struct{} // closed by Close to stop the health check goroutine
+	observer             ConnPoolObserver
+	stat                 ConnPoolStat // cumulative counters, the connection counts are filled in by Stat
 }
 
 type ConnPoolStat struct {
 	MaxConnections       int // max simultaneous connections to use
 	CurrentConnections   int // current live connections
 	AvailableConnections int // unused live connections
+
+	AcquireCount        int64         // successful acquires
+	AcquireWaitCount    int64         // successful acquires that had to wait for a connection to be released
+	AcquireDuration     time.Duration // total time spent in successful acquires
+	AcquireTimeoutCount int64         // acquires that failed with ErrAcquireTimeout or because the context deadline passed
+
+	CreatedConnections   int64                       // connections established by the pool
+	CreatedByReason      map[ConnCreateReason]int64  // CreatedConnections by reason
+	DestroyedConnections int64                       // connections closed or discarded by the pool
+	DestroyedByReason    map[ConnDestroyReason]int64 // DestroyedConnections by reason
+
+	QueryCount      int64         // queries and execs run on pooled connections
+	QueryErrorCount int64         // queries and execs that returned an error
+	QueryDuration   time.Duration // total time spent in queries and execs
+}
+
+// ConnCreateReason is the reason a ConnPool established a connection.
+type ConnCreateReason int
+
+const (
+	ConnCreateInitial ConnCreateReason = iota // the first connection established by NewConnPool
+	ConnCreateAcquire                         // an acquire found no available connection
+	ConnCreateMinIdle                         // the health check topped up to MinIdleConnections
+)
+
+func (r ConnCreateReason) String() string {
+	switch r {
+	case ConnCreateInitial:
+		return "initial"
+	case ConnCreateAcquire:
+		return "acquire"
+	case ConnCreateMinIdle:
+		return "min idle"
+	default:
+		return fmt.Sprintf("ConnCreateReason(%d)", int(r))
+	}
+}
+
+// ConnDestroyReason is the reason a ConnPool closed or discarded a connection.
+type ConnDestroyReason int
+
+const (
+	ConnDestroyDead        ConnDestroyReason = iota // the connection was dead when released or health checked
+	ConnDestroyReset                                // the pool was reset or a prepared statement was added or removed while the connection was acquired
+	ConnDestroyMaxLifetime                          // the connection outlived MaxConnLifetime
+	ConnDestroyMaxIdleTime                          // the connection was unused for MaxConnIdleTime
+	ConnDestroyPoolClosed                           // the pool was closed
+)
+
+func (r ConnDestroyReason) String() string {
+	switch r {
+	case ConnDestroyDead:
+		return "dead"
+	case ConnDestroyReset:
+		return "reset"
+	case ConnDestroyMaxLifetime:
+		return "max lifetime"
+	case ConnDestroyMaxIdleTime:
+		return "max idle time"
+	case ConnDestroyPoolClosed:
+		return "pool closed"
+	default:
+		return fmt.Sprintf("ConnDestroyReason(%d)", int(r))
+	}
+}
+
+// ConnPoolObserver receives events from a ConnPool, e.g. to export them to a
+// metrics system. The same information is accumulated in ConnPoolStat.
+//
+// Implementations must be safe for concurrent use. Methods may be called while
+// the pool is locked, so they must return quickly and must not call back into
+// the pool.
+type ConnPoolObserver interface {
+	// AcquireDone is called when Acquire or AcquireContext returns. waited is
+	// true if all connections were busy so the acquire had to wait for one to
+	// be released. err is the error the acquire returned, if any.
+	AcquireDone(duration time.Duration, waited bool, err error)
+
+	// ConnCreated is called when the pool establishes a connection.
+	ConnCreated(reason ConnCreateReason)
+
+	// ConnDestroyed is called when the pool closes or discards a connection.
+	ConnDestroyed(reason ConnDestroyReason)
+
+	// QueryDone is called when an Exec completes or the Rows of a Query are
+	// closed on a pooled connection.
+	QueryDone(duration time.Duration, err error)
 }
 
 // ErrAcquireTimeout occurs when an attempt to acquire a connection times out.
 var ErrAcquireTimeout = errors.New("timeout acquiring connection from pool")
 
+// ErrConnNotAcquired occurs when CancelRequest is called with a connection
+// that is not currently acquired from the pool.
+var ErrConnNotAcquired = errors.New("connection is not acquired from pool")
+
 // NewConnPool creates a new ConnPool. config.ConnConfig is passed through to
 // Connect directly.
 func NewConnPool(config ConnPoolConfig) (p *ConnPool, err error) {
@@ -60,7 +190,31 @@ func NewConnPool(config ConnPoolConfig) (p *ConnPool, err error) {
 		return nil, errors.New("AcquireTimeout must be equal to or greater than 0")
 	}
 
+	p.maxConnLifetime = config.MaxConnLifetime
+	if p.maxConnLifetime < 0 {
+		return nil, errors.New("MaxConnLifetime must be equal to or greater than 0")
+	}
+	p.maxConnIdleTime = config.MaxConnIdleTime
+	if p.maxConnIdleTime < 0 {
+		return nil, errors.New("MaxConnIdleTime must be equal to or greater than 0")
+	}
+	p.minIdleConnections = config.MinIdleConnections
+	if p.minIdleConnections < 0 {
+		return nil, errors.New("MinIdleConnections must be equal to or greater than 0")
+	}
+	if p.minIdleConnections > p.maxConnections {
+		return nil, errors.New("MinIdleConnections must not be greater than MaxConnections")
+	}
+	p.healthCheckPeriod = config.HealthCheckPeriod
+	if p.healthCheckPeriod < 0 {
+		return nil, errors.New("HealthCheckPeriod must be equal to or greater than 0")
+	}
+	if p.healthCheckPeriod == 0 && (p.maxConnLifetime > 0 || p.maxConnIdleTime > 0 || p.minIdleConnections > 0) {
+		p.healthCheckPeriod = defaultHealthCheckPeriod
+	}
+
 	p.afterConnect = config.AfterConnect
+	p.observer = config.Observer
 
 	if config.LogLevel != 0 {
 		p.logLevel = config.LogLevel
@@ -92,36 +246,131 @@ func NewConnPool(config ConnPoolConfig) (p *ConnPool, err error) {
 	if err != nil {
 		return
 	}
+	p.connCreated(ConnCreateInitial)
 	p.allConnections = append(p.allConnections, c)
 	p.availableConnections = append(p.availableConnections, c)
 
+	if p.healthCheckPeriod > 0 {
+		p.closeChan = make(chan struct{})
+		go p.healthCheckLoop()
+	}
+
 	return
 }
 
 // Acquire takes exclusive use of a connection until it is released.
 func (p *ConnPool) Acquire() (*Conn, error) {
+	return p.AcquireContext(context.Background())
+}
+
+// AcquireContext takes exclusive use of a connection until it is released. If
+// all connections are busy it waits until one is available, AcquireTimeout
+// elapses, or ctx is done.
+func (p *ConnPool) AcquireContext(ctx context.Context) (*Conn, error) {
+	startTime := time.Now()
+	var waited bool
+
 	p.cond.L.Lock()
-	c, err := p.acquire(nil)
+	c, err := p.acquire(ctx, nil, &waited)
+	p.acquireDone(time.Since(startTime), waited, err)
 	p.cond.L.Unlock()
 	return c, err
 }
 
+// acquireDone records the result of an acquire. The pool must already be
+// locked.
+func (p *ConnPool) acquireDone(duration time.Duration, waited bool, err error) {
+	switch {
+	case err == nil:
+		p.stat.AcquireCount++
+		p.stat.AcquireDuration += duration
+		if waited {
+			p.stat.AcquireWaitCount++
+		}
+	case err == ErrAcquireTimeout || err == context.DeadlineExceeded:
+		p.stat.AcquireTimeoutCount++
+	}
+
+	if p.observer != nil {
+		p.observer.AcquireDone(duration, waited, err)
+	}
+}
+
+// connCreated records that a connection was established. The pool must
+// already be locked.
+func (p *ConnPool) connCreated(reason ConnCreateReason) {
+	p.stat.CreatedConnections++
+	if p.stat.CreatedByReason == nil {
+		p.stat.CreatedByReason = make(map[ConnCreateReason]int64)
+	}
+	p.stat.CreatedByReason[reason]++
+
+	if p.observer != nil {
+		p.observer.ConnCreated(reason)
+	}
+}
+
+// destroyConn closes c, which must already have been removed from
+// allConnections and availableConnections, and records why. The pool must
+// already be locked.
+func (p *ConnPool) destroyConn(c *Conn, reason ConnDestroyReason) {
+	c.Close()
+
+	p.stat.DestroyedConnections++
+	if p.stat.DestroyedByReason == nil {
+		p.stat.DestroyedByReason = make(map[ConnDestroyReason]int64)
+	}
+	p.stat.DestroyedByReason[reason]++
+
+	if p.observer != nil {
+		p.observer.ConnDestroyed(reason)
+	}
+}
+
+// queryDone records a query run on a pooled connection.
+func (p *ConnPool) queryDone(duration time.Duration, err error) {
+	p.cond.L.Lock()
+	p.stat.QueryCount++
+	if err != nil {
+		p.stat.QueryErrorCount++
+	}
+	p.stat.QueryDuration += duration
+	p.cond.L.Unlock()
+
+	if p.observer != nil {
+		p.observer.QueryDone(duration, err)
+	}
+}
+
 // deadlinePassed returns true if the given deadline has passed.
 func (p *ConnPool) deadlinePassed(deadline *time.Time) bool {
 	return deadline != nil && time.Now().After(*deadline)
 }
 
-// acquire performs acquision assuming pool is already locked
-func (p *ConnPool) acquire(deadline *time.Time) (*Conn, error) {
+// acquire performs acquision assuming pool is already locked. waited is set to
+// true if acquire has to wait for a connection to be released.
+func (p *ConnPool) acquire(ctx context.Context, deadline *time.Time, waited *bool) (*Conn, error) {
 	if p.closed {
 		return nil, errors.New("cannot acquire from closed pool")
 	}
 
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	// A connection is available
-	if len(p.availableConnections) > 0 {
+	for len(p.availableConnections) > 0 {
 		c := p.availableConnections[len(p.availableConnections)-1]
-		c.poolResetCount = p.resetCount
 		p.availableConnections = p.availableConnections[:len(p.availableConnections)-1]
+
+		// Do not hand out a connection the health check would have closed
+		if p.connExpired(c, time.Now()) {
+			p.removeFromAllConnections(c)
+			p.destroyConn(c, ConnDestroyMaxLifetime)
+			continue
+		}
+
+		c.poolResetCount = p.resetCount
 		return c, nil
 	}
 
@@ -146,6 +395,23 @@ func (p *ConnPool) acquire(deadline *time.Time) (*Conn, error) {
 		defer timer.Stop()
 	}
 
+	// If there is a context then wake up the waiters when it is done. The lock
+	// is taken before broadcasting so the wake up cannot be lost between
+	// checking ctx and calling Wait.
+	if ctx.Done() != nil {
+		stop := make(chan struct{})
+		defer close(stop)
+		go func() {
+			select {
+			case <-ctx.Done():
+				p.cond.L.Lock()
+				p.cond.Broadcast()
+				p.cond.L.Unlock()
+			case <-stop:
+			}
+		}()
+	}
+
 	// No connections are available, but we can create more
 	if len(p.allConnections)+p.inProgressConnects < p.maxConnections {
 		// Create a new connection.
@@ -155,6 +421,7 @@ func (p *ConnPool) acquire(deadline *time.Time) (*Conn, error) {
 		if err != nil {
 			return nil, err
 		}
+		p.connCreated(ConnCreateAcquire)
 		c.poolResetCount = p.resetCount
 		p.allConnections = append(p.allConnections, c)
 		return c, nil
@@ -163,12 +430,16 @@ func (p *ConnPool) acquire(deadline *time.Time) (*Conn, error) {
 	if p.logLevel >= LogLevelWarn {
 		p.logger.Warn("All connections in pool are busy - waiting...")
 	}
+	*waited = true
 
 	// Wait until there is an available connection OR room to create a new connection
 	for len(p.availableConnections) == 0 && len(p.allConnections)+p.inProgressConnects == p.maxConnections {
 		if p.deadlinePassed(deadline) {
 			return nil, ErrAcquireTimeout
 		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
 		p.cond.Wait()
 	}
 
@@ -176,7 +447,7 @@ func (p *ConnPool) acquire(deadline *time.Time) (*Conn, error) {
 	if timer != nil {
 		timer.Stop()
 	}
-	return p.acquire(deadline)
+	return p.acquire(ctx, deadline, waited)
 }
 
 // Release gives up use of a connection.
@@ -196,21 +467,184 @@ func (p *ConnPool) Release(conn *Conn) {
 	p.cond.L.Lock()
 
 	if conn.poolResetCount != p.resetCount {
-		conn.Close()
+		p.destroyConn(conn, ConnDestroyReset)
 		p.cond.L.Unlock()
 		p.cond.Signal()
 		return
 	}
 
-	if conn.IsAlive() {
-		p.availableConnections = append(p.availableConnections, conn)
-	} else {
+	now := time.Now()
+	switch {
+	case !conn.IsAlive():
 		p.removeFromAllConnections(conn)
+		p.destroyConn(conn, ConnDestroyDead)
+	case p.connExpired(conn, now):
+		p.removeFromAllConnections(conn)
+		p.destroyConn(conn, ConnDestroyMaxLifetime)
+	default:
+		conn.poolReleasedTime = now
+		p.availableConnections = append(p.availableConnections, conn)
 	}
 	p.cond.L.Unlock()
 	p.cond.Signal()
 }
 
+// connExpired returns true if c has outlived MaxConnLifetime.
+func (p *ConnPool) connExpired(c *Conn, now time.Time) bool {
+	return p.maxConnLifetime > 0 && now.Sub(c.poolCreatedTime) >= p.maxConnLifetime
+}
+
+// healthCheckLoop calls checkHealth every HealthCheckPeriod until the pool is
+// closed.
+func (p *ConnPool) healthCheckLoop() {
+	ticker := time.NewTicker(p.healthCheckPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			p.checkHealth()
+		case <-p.closeChan:
+			return
+		}
+	}
+}
+
+// checkHealth closes available connections that have outlived
+// MaxConnLifetime or MaxConnIdleTime, pings connections that have had no
+// activity for at least HealthCheckPeriod, and then creates connections until
+// MinIdleConnections are available.
+func (p *ConnPool) checkHealth() {
+	p.cond.L.Lock()
+	defer p.cond.L.Unlock()
+
+	if p.closed {
+		return
+	}
+
+	now := time.Now()
+	var toPing []*Conn
+	available := p.availableConnections[:0]
+	for i, c := range p.availableConnections {
+		// The number of connections that will still be available if c is
+		// closed, assuming those after c are kept
+		remaining := len(available) + len(toPing) + len(p.availableConnections) - i - 1
+
+		switch {
+		case p.connExpired(c, now):
+			p.removeFromAllConnections(c)
+			p.destroyConn(c, ConnDestroyMaxLifetime)
+		case p.maxConnIdleTime > 0 && now.Sub(c.poolReleasedTime) >= p.maxConnIdleTime && remaining >= p.minIdleConnections:
+			p.removeFromAllConnections(c)
+			p.destroyConn(c, ConnDestroyMaxIdleTime)
+		case now.Sub(c.lastActivityTime) >= p.healthCheckPeriod:
+			toPing = append(toPing, c)
+		default:
+			available = append(available, c)
+		}
+	}
+	p.availableConnections = available
+
+	if len(toPing) > 0 {
+		// The connections being pinged are treated as acquired so the lock can
+		// be released while waiting on the server.
+		for _, c := range toPing {
+			c.poolResetCount = p.resetCount
+		}
+		p.cond.L.Unlock()
+		for _, c := range toPing {
+			p.ping(c)
+		}
+		p.cond.L.Lock()
+
+		for _, c := range toPing {
+			if c.poolResetCount != p.resetCount {
+				p.destroyConn(c, ConnDestroyReset)
+				continue
+			}
+			// poolReleasedTime is left alone as a ping does not count as use
+			if c.IsAlive() {
+				p.availableConnections = append(p.availableConnections, c)
+			} else {
+				p.removeFromAllConnections(c)
+				p.destroyConn(c, ConnDestroyDead)
+				if p.logLevel >= LogLevelWarn {
+					p.logger.Warn("Closed dead connection found by pool health check", "pid", c.Pid, "err", c.CauseOfDeath())
+				}
+			}
+		}
+		p.cond.Broadcast()
+	}
+
+	for !p.closed &&
+		len(p.availableConnections)+p.inProgressConnects < p.minIdleConnections &&
+		len(p.allConnections)+p.inProgressConnects < p.maxConnections {
+		// Careful here: createConnectionUnlocked() removes the current lock,
+		// creates a connection and then locks it back.
+		c, err := p.createConnectionUnlocked()
+		if err != nil {
+			if p.logLevel >= LogLevelWarn {
+				p.logger.Warn("Pool health check failed to create connection", "err", err)
+			}
+			return
+		}
+		if p.closed {
+			c.Close()
+			return
+		}
+		p.connCreated(ConnCreateMinIdle)
+		c.poolResetCount = p.resetCount
+		c.poolReleasedTime = time.Now()
+		p.allConnections = append(p.allConnections, c)
+		p.availableConnections = append(p.availableConnections, c)
+		p.cond.Signal()
+	}
+}
+
+// ping sends an empty query to c to check that it is still alive. c is closed
+// if the server does not respond within HealthCheckPeriod.
+func (p *ConnPool) ping(c *Conn) {
+	ctx, cancel := context.WithTimeout(context.Background(), p.healthCheckPeriod)
+	defer cancel()
+
+	// Health check pings are not counted as queries
+	queryDone := c.queryDone
+	c.queryDone = nil
+	defer func() { c.queryDone = queryDone }()
+
+	// Any error that means c is unusable also kills it, so only IsAlive
+	// needs to be checked afterwards.
+	c.ExecContext(ctx, "--;")
+}
+
+// CancelRequest asks the server to cancel the query currently running on
+// conn, which must have been acquired from p and not yet released. conn is
+// not closed and may be released normally once the canceled query returns.
+// CancelRequest is safe to call from any goroutine.
+func (p *ConnPool) CancelRequest(conn *Conn) error {
+	p.cond.L.Lock()
+	acquired := false
+	for _, c := range p.allConnections {
+		if c == conn {
+			acquired = true
+			break
+		}
+	}
+	for _, c := range p.availableConnections {
+		if c == conn {
+			acquired = false
+			break
+		}
+	}
+	p.cond.L.Unlock()
+
+	if !acquired {
+		return ErrConnNotAcquired
+	}
+
+	return conn.CancelRequest()
+}
+
 // removeFromAllConnections Removes the given connection from the list.
 // It returns true if the connection was found and removed or false otherwise.
 func (p *ConnPool) removeFromAllConnections(conn *Conn) bool {
@@ -230,6 +664,9 @@ func (p *ConnPool) Close() {
 	p.cond.L.Lock()
 	defer p.cond.L.Unlock()
 
+	if !p.closed && p.closeChan != nil {
+		close(p.closeChan)
+	}
 	p.closed = true
 
 	// Wait until all connections are released
@@ -240,8 +677,10 @@ func (p *ConnPool) Close() {
 	}
 
 	for _, c := range p.allConnections {
-		_ = c.Close()
+		p.destroyConn(c, ConnDestroyPoolClosed)
 	}
+	p.allConnections = p.allConnections[0:0]
+	p.availableConnections = p.availableConnections[0:0]
 }
 
 // Reset closes all open connections, but leaves the pool open. It is intended
@@ -258,7 +697,7 @@ func (p *ConnPool) Reset() {
 	p.allConnections = p.allConnections[0:0]
 
 	for _, conn := range p.availableConnections {
-		conn.Close()
+		p.destroyConn(conn, ConnDestroyReset)
 	}
 
 	p.availableConnections = p.availableConnections[0:0]
@@ -282,14 +721,24 @@ func (p *ConnPool) Stat() (s ConnPoolStat) {
 	p.cond.L.Lock()
 	defer p.cond.L.Unlock()
 
+	s = p.stat
 	s.MaxConnections = p.maxConnections
 	s.CurrentConnections = len(p.allConnections)
 	s.AvailableConnections = len(p.availableConnections)
+
+	s.CreatedByReason = make(map[ConnCreateReason]int64, len(p.stat.CreatedByReason))
+	for r, n := range p.stat.CreatedByReason {
+		s.CreatedByReason[r] = n
+	}
+	s.DestroyedByReason = make(map[ConnDestroyReason]int64, len(p.stat.DestroyedByReason))
+	for r, n := range p.stat.DestroyedByReason {
+		s.DestroyedByReason[r] = n
+	}
 	return
 }
 
 func (p *ConnPool) createConnection() (*Conn, error) {
-	c, err := connect(p.config, p.pgTypes, p.pgsqlAfInet, p.pgsqlAfInet6)
+	c, err := connect(p.config, p.connInfo, p.pgsqlAfInet, p.pgsqlAfInet6)
 	if err != nil {
 		return nil, err
 	}
@@ -324,7 +773,9 @@ func (p *ConnPool) createConnectionUnlocked() (*Conn, error) {
 // afterConnectionCreated executes (if it is) afterConnect() callback and prepares
 // all the known statements for the new connection.
 func (p *ConnPool) afterConnectionCreated(c *Conn) (*Conn, error) {
-	p.pgTypes = c.PgTypes
+	// Copied before afterConnect so types it registers on c are registered
+	// by afterConnect on every connection
+	p.connInfo = c.connInfo.clone()
 	p.pgsqlAfInet = c.pgsqlAfInet
 	p.pgsqlAfInet6 = c.pgsqlAfInet6
 
@@ -343,6 +794,10 @@ func (p *ConnPool) afterConnectionCreated(c *Conn) (*Conn, error) {
 		}
 	}
 
+	c.poolCreatedTime = time.Now()
+	c.poolReleasedTime = c.poolCreatedTime
+	c.queryDone = p.queryDone
+
 	return c, nil
 }
 
@@ -357,6 +812,19 @@ func (p *ConnPool) Exec(sql string, arguments ...interface{}) (commandTag Comman
 	return c.Exec(sql, arguments...)
 }
 
+// ExecContext acquires a connection, delegates the call to that connection,
+// and releases the connection. ctx applies to both acquiring the connection
+// and executing sql.
+func (p *ConnPool) ExecContext(ctx context.Context, sql string, arguments ...interface{}) (commandTag CommandTag, err error) {
+	var c *Conn
+	if c, err = p.AcquireContext(ctx); err != nil {
+		return
+	}
+	defer p.Release(c)
+
+	return c.ExecContext(ctx, sql, arguments...)
+}
+
 // Query acquires a connection and delegates the call to that connection. When
 // *Rows are closed, the connection is released automatically.
 func (p *ConnPool) Query(sql string, args ...interface{}) (*Rows, error) {
@@ -385,12 +853,75 @@ func (p *ConnPool) QueryRow(sql string, args ...interface{}) *Row {
 	return (*Row)(rows)
 }
 
+// Select acquires a connection and delegates the call to that connection.
+// The connection is released once all rows have been read.
+func (p *ConnPool) Select(dst interface{}, sql string, args ...interface{}) error {
+	return selectStructs(dst, func() (*Rows, error) { return p.Query(sql, args...) })
+}
+
+// QueryContext acquires a connection and delegates the call to that
+// connection. ctx applies to both acquiring the connection and the query. When
+// *Rows are closed, the connection is released automatically.
+func (p *ConnPool) QueryContext(ctx context.Context, sql string, args ...interface{}) (*Rows, error) {
+	c, err := p.AcquireContext(ctx)
+	if err != nil {
+		// Because checking for errors can be deferred to the *Rows, build one with the error
+		return &Rows{closed: true, err: err}, err
+	}
+
+	rows, err := c.QueryContext(ctx, sql, args...)
+	if err != nil {
+		p.Release(c)
+		return rows, err
+	}
+
+	rows.AfterClose(p.rowsAfterClose)
+
+	return rows, nil
+}
+
+// QueryRowContext acquires a connection and delegates the call to that
+// connection. The connection is released automatically after Scan is called on
+// the returned *Row.
+func (p *ConnPool) QueryRowContext(ctx context.Context, sql string, args ...interface{}) *Row {
+	rows, _ := p.QueryContext(ctx, sql, args...)
+	return (*Row)(rows)
+}
+
 // Begin acquires a connection and begins a transaction on it. When the
 // transaction is closed the connection will be automatically released.
 func (p *ConnPool) Begin() (*Tx, error) {
 	return p.BeginIso("")
 }
 
+// BeginContext acquires a connection and begins a transaction on it. ctx
+// applies to acquiring the connection and starting the transaction. When the
+// transaction is closed the connection will be automatically released.
+func (p *ConnPool) BeginContext(ctx context.Context) (*Tx, error) {
+	for {
+		c, err := p.AcquireContext(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		tx, err := c.BeginContext(ctx)
+		if err != nil {
+			alive := c.IsAlive()
+			p.Release(c)
+
+			// If the connection is still alive or ctx is done then trying again on
+			// a new connection would not help.
+			if alive || ctx.Err() != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		tx.AfterClose(p.txAfterClose)
+		return tx, nil
+	}
+}
+
 // Prepare creates a prepared statement on a connection in the pool to test the
 // statement is valid. If it succeeds all connections accessed through the pool
 // will have the statement available.
@@ -425,7 +956,8 @@ func (p *ConnPool) PrepareEx(name, sql string, opts *PrepareExOptions) (*Prepare
 		return ps, nil
 	}
 
-	c, err := p.acquire(nil)
+	var waited bool
+	c, err := p.acquire(context.Background(), nil, &waited)
 	if err != nil {
 		return nil, err
 	}
@@ -514,3 +1046,40 @@ func (p *ConnPool) CopyTo(tableName string, columnNames []string, rowSrc CopyToS
 
 	return c.CopyTo(tableName, columnNames, rowSrc)
 }
+
+// CopyToEx acquires a connection, delegates the call to that connection, and releases the connection
+func (p *ConnPool) CopyToEx(tableName string, columnNames []string, rowSrc CopyToSource, opts *CopyToOptions) (CopyToResult, error) {
+	c, err := p.Acquire()
+	if err != nil {
+		return CopyToResult{}, err
+	}
+	defer p.Release(c)
+
+	return c.CopyToEx(tableName, columnNames, rowSrc, opts)
+}
+
+// CopyFromQuery acquires a connection, delegates the call to that connection, and releases the connection
+func (p *ConnPool) CopyFromQuery(sql string, args []interface{}, sink CopyFromSink) (int, error) {
+	c, err := p.Acquire()
+	if err != nil {
+		return 0, err
+	}
+	defer p.Release(c)
+
+	return c.CopyFromQuery(sql, args, sink)
+}
+
+// SendBatch acquires a connection and sends b on it. The connection is
+// released when the returned *BatchResults is closed.
+func (p *ConnPool) SendBatch(b *Batch) *BatchResults {
+	c, err := p.Acquire()
+	if err != nil {
+		return &BatchResults{err: err, done: true}
+	}
+
+	br := c.SendBatch(b)
+	br.afterClose = func(*BatchResults) {
+		p.Release(c)
+	}
+	return br
+}
@@ -1,7 +1,9 @@
 package pgx
 
 import (
+	"sync"
 	"testing"
+	"time"
 )
 
 func compareConnSlices(slice1, slice2 []*Conn) bool {
@@ -42,3 +44,38 @@ func TestConnPoolRemoveFromAllConnections(t *testing.T) {
 		t.Fatal("Last element test failed")
 	}
 }
+
+func TestConnPoolCheckHealthClosesExpiredConnections(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	expired := &Conn{poolCreatedTime: now.Add(-2 * time.Hour), poolReleasedTime: now}
+	idle1 := &Conn{poolCreatedTime: now.Add(-time.Hour / 2), poolReleasedTime: now.Add(-10 * time.Minute)}
+	idle2 := &Conn{poolCreatedTime: now.Add(-time.Hour / 2), poolReleasedTime: now.Add(-5 * time.Minute), lastActivityTime: now.Add(-5 * time.Minute)}
+	fresh := &Conn{poolCreatedTime: now, poolReleasedTime: now, lastActivityTime: now}
+
+	pool := ConnPool{
+		cond:               sync.NewCond(new(sync.Mutex)),
+		maxConnections:     4,
+		maxConnLifetime:    time.Hour,
+		maxConnIdleTime:    time.Minute,
+		minIdleConnections: 2,
+		healthCheckPeriod:  time.Hour,
+	}
+	pool.allConnections = []*Conn{expired, idle1, idle2, fresh}
+	pool.availableConnections = []*Conn{expired, idle1, idle2, fresh}
+
+	pool.checkHealth()
+
+	// expired is past MaxConnLifetime and idle1 is past MaxConnIdleTime, but
+	// idle2 is kept to satisfy MinIdleConnections
+	if !compareConnSlices(pool.availableConnections, []*Conn{idle2, fresh}) {
+		t.Fatalf("Unexpected available connections: %v", pool.availableConnections)
+	}
+	if !compareConnSlices(pool.allConnections, []*Conn{idle2, fresh}) {
+		t.Fatalf("Unexpected connections: %v", pool.allConnections)
+	}
+	if pool.stat.DestroyedConnections != 2 || pool.stat.DestroyedByReason[ConnDestroyMaxLifetime] != 1 || pool.stat.DestroyedByReason[ConnDestroyMaxIdleTime] != 1 {
+		t.Fatalf("Unexpected destroyed connections: %d %v", pool.stat.DestroyedConnections, pool.stat.DestroyedByReason)
+	}
+}
@@ -1,6 +1,7 @@
 package pgx_test
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net"
@@ -93,6 +94,29 @@ func TestNewConnPoolDefaultsTo5MaxConnections(t *testing.T) {
 	}
 }
 
+func TestNewConnPoolValidatesLifecycleOptions(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		config   pgx.ConnPoolConfig
+		expected string
+	}{
+		{pgx.ConnPoolConfig{MaxConnLifetime: -1}, "MaxConnLifetime must be equal to or greater than 0"},
+		{pgx.ConnPoolConfig{MaxConnIdleTime: -1}, "MaxConnIdleTime must be equal to or greater than 0"},
+		{pgx.ConnPoolConfig{MinIdleConnections: -1}, "MinIdleConnections must be equal to or greater than 0"},
+		{pgx.ConnPoolConfig{MaxConnections: 2, MinIdleConnections: 3}, "MinIdleConnections must not be greater than MaxConnections"},
+		{pgx.ConnPoolConfig{HealthCheckPeriod: -1}, "HealthCheckPeriod must be equal to or greater than 0"},
+	}
+
+	for i, tt := range tests {
+		tt.config.ConnConfig = *defaultConnConfig
+		_, err := pgx.NewConnPool(tt.config)
+		if err == nil || err.Error() != tt.expected {
+			t.Errorf("%d. Expected error %q, got %v", i, tt.expected, err)
+		}
+	}
+}
+
 func TestPoolAcquireAndReleaseCycle(t *testing.T) {
 	t.Parallel()
 
@@ -284,6 +308,125 @@ func TestPoolWithAcquireTimeoutSet(t *testing.T) {
 	}
 }
 
+func TestPoolAcquireContextDeadline(t *testing.T) {
+	t.Parallel()
+
+	pool := createConnPool(t, 1)
+	defer pool.Close()
+
+	// Consume all connections ...
+	allConnections := acquireAllConnections(t, pool, 1)
+	defer releaseAllConnections(pool, allConnections)
+
+	// ... then try to consume 1 more. It should fail when the context expires.
+	ctx, cancelFunc := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancelFunc()
+
+	c, err := pool.AcquireContext(ctx)
+	if err != context.DeadlineExceeded {
+		t.Fatalf("Expected error to be context.DeadlineExceeded, instead it was '%v'", err)
+	}
+	if c != nil {
+		t.Fatal("Expected no connection")
+	}
+}
+
+func TestPoolExecContextCancelationCancelsQuery(t *testing.T) {
+	t.Parallel()
+
+	pool := createConnPool(t, 1)
+	defer pool.Close()
+
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(500 * time.Millisecond)
+		cancelFunc()
+	}()
+
+	_, err := pool.ExecContext(ctx, "select pg_sleep(60)")
+	if err != context.Canceled {
+		t.Fatalf("Expected context.Canceled err, got %v", err)
+	}
+
+	// The canceled connection is discarded and the pool remains usable
+	var n int32
+	if err := pool.QueryRow("select 1").Scan(&n); err != nil {
+		t.Fatalf("pool.QueryRow Scan failed: %v", err)
+	}
+	if n != 1 {
+		t.Errorf("Expected 1, got %d", n)
+	}
+}
+
+func TestPoolCancelRequest(t *testing.T) {
+	t.Parallel()
+
+	pool := createConnPool(t, 2)
+	defer pool.Close()
+
+	conn, err := pool.Acquire()
+	if err != nil {
+		t.Fatalf("Unable to acquire connection: %v", err)
+	}
+
+	go func() {
+		time.Sleep(500 * time.Millisecond)
+		if err := pool.CancelRequest(conn); err != nil {
+			t.Errorf("pool.CancelRequest failed: %v", err)
+		}
+	}()
+
+	_, err = conn.Exec("select pg_sleep(60)")
+	if pgErr, ok := err.(pgx.PgError); !ok || pgErr.Code != "57014" {
+		t.Fatalf("Expected query_canceled error, got %v", err)
+	}
+
+	ensureConnValid(t, conn)
+	pool.Release(conn)
+
+	if err := pool.CancelRequest(conn); err != pgx.ErrConnNotAcquired {
+		t.Fatalf("Expected pgx.ErrConnNotAcquired for released connection, got %v", err)
+	}
+}
+
+func TestPoolPreparedStatementCache(t *testing.T) {
+	t.Parallel()
+
+	config := pgx.ConnPoolConfig{ConnConfig: *defaultConnConfig, MaxConnections: 2}
+	config.PreparedStatementCacheSize = 4
+
+	pool, err := pgx.NewConnPool(config)
+	if err != nil {
+		t.Fatalf("Unable to create connection pool: %v", err)
+	}
+	defer pool.Close()
+
+	for i := 0; i < 10; i++ {
+		var n int32
+		if err := pool.QueryRow("select $1::int4", int32(i)).Scan(&n); err != nil {
+			t.Fatalf("pool.QueryRow failed: %v", err)
+		}
+		if n != int32(i) {
+			t.Fatalf("Expected %d, got %d", i, n)
+		}
+	}
+
+	conn, err := pool.Acquire()
+	if err != nil {
+		t.Fatalf("Unable to acquire connection: %v", err)
+	}
+	defer pool.Release(conn)
+
+	var count int64
+	err = conn.QueryRow("select count(*) from pg_prepared_statements where name like 'pgx_stmtcache_%'").Scan(&count)
+	if err != nil {
+		t.Fatalf("Unable to count prepared statements: %v", err)
+	}
+	if count == 0 {
+		t.Fatal("Expected pooled connection to have cached prepared statements")
+	}
+}
+
 func TestPoolWithoutAcquireTimeoutSet(t *testing.T) {
 	t.Parallel()
 
@@ -465,6 +608,320 @@ func TestPoolReleaseDiscardsDeadConnections(t *testing.T) {
 	}
 }
 
+// waitForPoolStat polls pool until check returns true or a few seconds pass.
+func waitForPoolStat(t *testing.T, pool *pgx.ConnPool, check func(pgx.ConnPoolStat) bool) {
+	deadline := time.Now().Add(5 * time.Second)
+	for !check(pool.Stat()) {
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out waiting for pool, last stat: %+v", pool.Stat())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestConnPoolMaxConnLifetime(t *testing.T) {
+	t.Parallel()
+
+	config := pgx.ConnPoolConfig{
+		ConnConfig:        *defaultConnConfig,
+		MaxConnections:    2,
+		MaxConnLifetime:   100 * time.Millisecond,
+		HealthCheckPeriod: time.Hour,
+	}
+	pool, err := pgx.NewConnPool(config)
+	if err != nil {
+		t.Fatalf("Unable to create connection pool: %v", err)
+	}
+	defer pool.Close()
+
+	c, err := pool.Acquire()
+	if err != nil {
+		t.Fatalf("Unable to acquire connection: %v", err)
+	}
+	pid := c.Pid
+
+	time.Sleep(200 * time.Millisecond)
+
+	// A connection that expires while acquired is closed on release
+	pool.Release(c)
+	if c.IsAlive() {
+		t.Fatal("Expected expired connection to be closed on release")
+	}
+	if n := pool.Stat().CurrentConnections; n != 0 {
+		t.Fatalf("Expected 0 connections, got %d", n)
+	}
+
+	c, err = pool.Acquire()
+	if err != nil {
+		t.Fatalf("Unable to acquire connection: %v", err)
+	}
+	if c.Pid == pid {
+		t.Fatal("Expected a new connection after the old one expired")
+	}
+	pid = c.Pid
+	pool.Release(c)
+
+	time.Sleep(200 * time.Millisecond)
+
+	// A connection that expires while available is closed instead of acquired
+	c, err = pool.Acquire()
+	if err != nil {
+		t.Fatalf("Unable to acquire connection: %v", err)
+	}
+	defer pool.Release(c)
+	if c.Pid == pid {
+		t.Fatal("Expected a new connection after the old one expired")
+	}
+	if n := pool.Stat().CurrentConnections; n != 1 {
+		t.Fatalf("Expected 1 connection, got %d", n)
+	}
+}
+
+func TestConnPoolMinIdleConnections(t *testing.T) {
+	t.Parallel()
+
+	config := pgx.ConnPoolConfig{
+		ConnConfig:         *defaultConnConfig,
+		MaxConnections:     4,
+		MinIdleConnections: 3,
+		HealthCheckPeriod:  20 * time.Millisecond,
+	}
+	pool, err := pgx.NewConnPool(config)
+	if err != nil {
+		t.Fatalf("Unable to create connection pool: %v", err)
+	}
+	defer pool.Close()
+
+	waitForPoolStat(t, pool, func(s pgx.ConnPoolStat) bool { return s.AvailableConnections == 3 })
+
+	// The pool never grows beyond MaxConnections to keep connections idle
+	connections := acquireAllConnections(t, pool, 3)
+	waitForPoolStat(t, pool, func(s pgx.ConnPoolStat) bool { return s.CurrentConnections == 4 })
+	time.Sleep(100 * time.Millisecond)
+	if stat := pool.Stat(); stat.CurrentConnections != 4 || stat.AvailableConnections != 1 {
+		t.Fatalf("Unexpected stat: %+v", stat)
+	}
+	releaseAllConnections(pool, connections)
+}
+
+func TestConnPoolMaxConnIdleTime(t *testing.T) {
+	t.Parallel()
+
+	config := pgx.ConnPoolConfig{
+		ConnConfig:         *defaultConnConfig,
+		MaxConnections:     4,
+		MaxConnIdleTime:    100 * time.Millisecond,
+		MinIdleConnections: 1,
+		HealthCheckPeriod:  20 * time.Millisecond,
+	}
+	pool, err := pgx.NewConnPool(config)
+	if err != nil {
+		t.Fatalf("Unable to create connection pool: %v", err)
+	}
+	defer pool.Close()
+
+	releaseAllConnections(pool, acquireAllConnections(t, pool, 4))
+	if n := pool.Stat().AvailableConnections; n != 4 {
+		t.Fatalf("Expected 4 available connections, got %d", n)
+	}
+
+	// Idle connections are closed down to MinIdleConnections
+	waitForPoolStat(t, pool, func(s pgx.ConnPoolStat) bool { return s.CurrentConnections == 1 })
+	time.Sleep(200 * time.Millisecond)
+	if stat := pool.Stat(); stat.CurrentConnections != 1 || stat.AvailableConnections != 1 {
+		t.Fatalf("Unexpected stat: %+v", stat)
+	}
+}
+
+func TestConnPoolHealthCheckDiscardsDeadConnections(t *testing.T) {
+	t.Parallel()
+
+	config := pgx.ConnPoolConfig{
+		ConnConfig:        *defaultConnConfig,
+		MaxConnections:    2,
+		HealthCheckPeriod: 50 * time.Millisecond,
+	}
+	pool, err := pgx.NewConnPool(config)
+	if err != nil {
+		t.Fatalf("Unable to create connection pool: %v", err)
+	}
+	defer pool.Close()
+
+	connections := acquireAllConnections(t, pool, 2)
+	if _, err := connections[1].Exec("select pg_terminate_backend($1)", connections[0].Pid); err != nil {
+		t.Fatalf("Unable to kill backend PostgreSQL process: %v", err)
+	}
+	releaseAllConnections(pool, connections)
+
+	// The health check pings the killed connection and discards it before
+	// anything acquires it
+	waitForPoolStat(t, pool, func(s pgx.ConnPoolStat) bool { return s.CurrentConnections == 1 })
+
+	for i := 0; i < 2; i++ {
+		c, err := pool.Acquire()
+		if err != nil {
+			t.Fatalf("Unable to acquire connection: %v", err)
+		}
+		if _, err := c.Exec("select 1"); err != nil {
+			t.Fatalf("Unexpected failure on acquired connection: %v", err)
+		}
+		pool.Release(c)
+	}
+}
+
+type recordingPoolObserver struct {
+	mux       sync.Mutex
+	acquires  int
+	waited    int
+	errors    []error
+	created   []pgx.ConnCreateReason
+	destroyed []pgx.ConnDestroyReason
+	queries   int
+}
+
+func (o *recordingPoolObserver) AcquireDone(duration time.Duration, waited bool, err error) {
+	o.mux.Lock()
+	defer o.mux.Unlock()
+	o.acquires++
+	if waited {
+		o.waited++
+	}
+	if err != nil {
+		o.errors = append(o.errors, err)
+	}
+}
+
+func (o *recordingPoolObserver) ConnCreated(reason pgx.ConnCreateReason) {
+	o.mux.Lock()
+	defer o.mux.Unlock()
+	o.created = append(o.created, reason)
+}
+
+func (o *recordingPoolObserver) ConnDestroyed(reason pgx.ConnDestroyReason) {
+	o.mux.Lock()
+	defer o.mux.Unlock()
+	o.destroyed = append(o.destroyed, reason)
+}
+
+func (o *recordingPoolObserver) QueryDone(duration time.Duration, err error) {
+	o.mux.Lock()
+	defer o.mux.Unlock()
+	o.queries++
+}
+
+func TestConnPoolStatCounters(t *testing.T) {
+	t.Parallel()
+
+	observer := &recordingPoolObserver{}
+	config := pgx.ConnPoolConfig{
+		ConnConfig:     *defaultConnConfig,
+		MaxConnections: 2,
+		AcquireTimeout: 50 * time.Millisecond,
+		Observer:       observer,
+	}
+	pool, err := pgx.NewConnPool(config)
+	if err != nil {
+		t.Fatalf("Unable to create connection pool: %v", err)
+	}
+
+	if _, err := pool.Exec("select 1"); err != nil {
+		t.Fatalf("Unexpected failure: %v", err)
+	}
+	if _, err := pool.Exec("select invalid"); err == nil {
+		t.Fatal("Expected error but none occurred")
+	}
+	var n int32
+	if err := pool.QueryRow("select 42").Scan(&n); err != nil {
+		t.Fatalf("Unexpected failure: %v", err)
+	}
+
+	connections := acquireAllConnections(t, pool, 2)
+	if _, err := pool.Acquire(); err != pgx.ErrAcquireTimeout {
+		t.Fatalf("Expected ErrAcquireTimeout, got %v", err)
+	}
+	if _, err := connections[1].Exec("select pg_terminate_backend($1)", connections[0].Pid); err != nil {
+		t.Fatalf("Unable to kill backend PostgreSQL process: %v", err)
+	}
+	connections[0].Exec("select 1")
+	releaseAllConnections(pool, connections)
+
+	stat := pool.Stat()
+	if stat.AcquireCount != 5 {
+		t.Errorf("Expected AcquireCount 5, got %d", stat.AcquireCount)
+	}
+	if stat.AcquireWaitCount != 0 {
+		t.Errorf("Expected AcquireWaitCount 0, got %d", stat.AcquireWaitCount)
+	}
+	if stat.AcquireDuration <= 0 {
+		t.Errorf("Expected AcquireDuration to be positive, got %v", stat.AcquireDuration)
+	}
+	if stat.AcquireTimeoutCount != 1 {
+		t.Errorf("Expected AcquireTimeoutCount 1, got %d", stat.AcquireTimeoutCount)
+	}
+	if stat.CreatedConnections != 2 || stat.CreatedByReason[pgx.ConnCreateInitial] != 1 || stat.CreatedByReason[pgx.ConnCreateAcquire] != 1 {
+		t.Errorf("Unexpected created connections: %d %v", stat.CreatedConnections, stat.CreatedByReason)
+	}
+	if stat.DestroyedConnections != 1 || stat.DestroyedByReason[pgx.ConnDestroyDead] != 1 {
+		t.Errorf("Unexpected destroyed connections: %d %v", stat.DestroyedConnections, stat.DestroyedByReason)
+	}
+	// 3 through the pool, pg_terminate_backend, and the query on the killed connection
+	if stat.QueryCount != 5 || stat.QueryErrorCount != 2 {
+		t.Errorf("Expected 5 queries with 2 errors, got %d with %d errors", stat.QueryCount, stat.QueryErrorCount)
+	}
+	if stat.QueryDuration <= 0 {
+		t.Errorf("Expected QueryDuration to be positive, got %v", stat.QueryDuration)
+	}
+
+	pool.Close()
+
+	stat = pool.Stat()
+	if stat.DestroyedConnections != 2 || stat.DestroyedByReason[pgx.ConnDestroyPoolClosed] != 1 {
+		t.Errorf("Unexpected destroyed connections: %d %v", stat.DestroyedConnections, stat.DestroyedByReason)
+	}
+
+	observer.mux.Lock()
+	defer observer.mux.Unlock()
+	if observer.acquires != 6 || len(observer.errors) != 1 || observer.errors[0] != pgx.ErrAcquireTimeout {
+		t.Errorf("Unexpected observed acquires: %d %v", observer.acquires, observer.errors)
+	}
+	if len(observer.created) != 2 {
+		t.Errorf("Unexpected observed created connections: %v", observer.created)
+	}
+	if len(observer.destroyed) != 2 || observer.destroyed[0] != pgx.ConnDestroyDead || observer.destroyed[1] != pgx.ConnDestroyPoolClosed {
+		t.Errorf("Unexpected observed destroyed connections: %v", observer.destroyed)
+	}
+	if observer.queries != 5 {
+		t.Errorf("Expected 5 observed queries, got %d", observer.queries)
+	}
+}
+
+func TestConnPoolStatAcquireWait(t *testing.T) {
+	t.Parallel()
+
+	pool := createConnPool(t, 1)
+	defer pool.Close()
+
+	c, err := pool.Acquire()
+	if err != nil {
+		t.Fatalf("Unable to acquire connection: %v", err)
+	}
+	time.AfterFunc(50*time.Millisecond, func() { pool.Release(c) })
+
+	c, err = pool.Acquire()
+	if err != nil {
+		t.Fatalf("Unable to acquire connection: %v", err)
+	}
+	pool.Release(c)
+
+	stat := pool.Stat()
+	if stat.AcquireCount != 2 || stat.AcquireWaitCount != 1 {
+		t.Errorf("Expected 2 acquires with 1 wait, got %d with %d waits", stat.AcquireCount, stat.AcquireWaitCount)
+	}
+	if stat.AcquireDuration < 50*time.Millisecond {
+		t.Errorf("Expected AcquireDuration to include the wait, got %v", stat.AcquireDuration)
+	}
+}
+
 func TestConnPoolResetClosesCheckedOutConnectionsOnRelease(t *testing.T) {
 	t.Parallel()
 
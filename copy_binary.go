@@ -0,0 +1,383 @@
+package pgx
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// copyBinarySignature starts the header of the PostgreSQL binary copy format.
+var copyBinarySignature = []byte("PGCOPY\n\377\r\n\000")
+
+// copyBinaryOids is the header flag bit for rows that include their OID.
+const copyBinaryOids = 1 << 16
+
+// CopyBinaryWriter writes rows in the PostgreSQL binary copy format (the
+// format of COPY ... WITH (FORMAT binary)) to an io.Writer. Values are
+// encoded with Encode so any value that can be used as a query argument can
+// be written.
+//
+// The output can be sent to PostgreSQL with COPY ... FROM STDIN or read with
+// CopyBinaryReader.
+type CopyBinaryWriter struct {
+	w             io.Writer
+	wbuf          *WriteBuf
+	oids          []Oid
+	headerWritten bool
+	err           error
+}
+
+// NewCopyBinaryWriter returns a CopyBinaryWriter that writes rows of values
+// of the types oids to w. conn is used to find registered types and may be
+// nil. inet and cidr values cannot be encoded without a conn.
+func NewCopyBinaryWriter(w io.Writer, conn *Conn, oids []Oid) *CopyBinaryWriter {
+	return &CopyBinaryWriter{w: w, wbuf: &WriteBuf{conn: conn}, oids: oids}
+}
+
+// WriteRow encodes and buffers a row. It returns an error if values cannot be
+// encoded, in which case the row is discarded, or if writing the buffered
+// rows fails, in which case all later writes fail.
+func (cw *CopyBinaryWriter) WriteRow(values []interface{}) error {
+	if cw.err != nil {
+		return cw.err
+	}
+	if len(values) != len(cw.oids) {
+		return fmt.Errorf("expected %d values, got %d values", len(cw.oids), len(values))
+	}
+
+	cw.writeHeader()
+
+	rowStart := len(cw.wbuf.buf)
+	cw.wbuf.WriteInt16(int16(len(values)))
+	for i, v := range values {
+		if err := Encode(cw.wbuf, cw.oids[i], v); err != nil {
+			cw.wbuf.buf = cw.wbuf.buf[:rowStart]
+			return err
+		}
+	}
+
+	if len(cw.wbuf.buf) > 65536 {
+		return cw.Flush()
+	}
+	return nil
+}
+
+func (cw *CopyBinaryWriter) writeHeader() {
+	if cw.headerWritten {
+		return
+	}
+	cw.wbuf.WriteBytes(copyBinarySignature)
+	cw.wbuf.WriteInt32(0) // flags
+	cw.wbuf.WriteInt32(0) // header extension length
+	cw.headerWritten = true
+}
+
+// Flush writes the buffered rows to the underlying io.Writer.
+func (cw *CopyBinaryWriter) Flush() error {
+	if cw.err != nil {
+		return cw.err
+	}
+	if len(cw.wbuf.buf) == 0 {
+		return nil
+	}
+
+	_, cw.err = cw.w.Write(cw.wbuf.buf)
+	cw.wbuf.buf = cw.wbuf.buf[:0]
+	return cw.err
+}
+
+// Close writes the end of the copy data and flushes. It does not close the
+// underlying io.Writer.
+func (cw *CopyBinaryWriter) Close() error {
+	if cw.err != nil {
+		return cw.err
+	}
+
+	cw.writeHeader()
+	cw.wbuf.WriteInt16(-1)
+	if err := cw.Flush(); err != nil {
+		return err
+	}
+
+	cw.err = errors.New("CopyBinaryWriter is closed")
+	return nil
+}
+
+// CopyBinaryReader reads rows in the PostgreSQL binary copy format from an
+// io.Reader. Its Next, Scan, Values and Err methods work like those of Rows.
+// It implements CopyToSource so the rows it reads can be passed to
+// *Conn.CopyTo.
+type CopyBinaryReader struct {
+	r          *bufio.Reader
+	conn       *Conn
+	fields     []FieldDescription
+	headerRead bool
+	done       bool
+	err        error
+
+	row   []byte // the current row in the DataRow field format
+	rowBr *bufio.Reader
+	mr    msgReader
+}
+
+// NewCopyBinaryReader returns a CopyBinaryReader that reads rows of values of
+// the types oids from r. conn is used to find registered types and may be
+// nil.
+func NewCopyBinaryReader(r io.Reader, conn *Conn, oids []Oid) *CopyBinaryReader {
+	ci := connInfoOf(conn)
+	fields := make([]FieldDescription, len(oids))
+	for i, oid := range oids {
+		fields[i] = FieldDescription{DataType: oid, FormatCode: BinaryFormatCode}
+		if dt, ok := ci.DataTypeForOid(oid); ok {
+			fields[i].DataTypeName = dt.Name
+		}
+	}
+
+	cr := &CopyBinaryReader{r: bufio.NewReader(r), conn: conn, fields: fields}
+	cr.mr.shouldLog = func(int) bool { return false }
+	if conn != nil {
+		cr.mr.log = conn.log
+		cr.mr.shouldLog = conn.shouldLog
+	}
+	return cr
+}
+
+func (cr *CopyBinaryReader) readHeader() error {
+	header := make([]byte, len(copyBinarySignature)+8)
+	if _, err := io.ReadFull(cr.r, header); err != nil {
+		return err
+	}
+	if !bytes.Equal(header[:len(copyBinarySignature)], copyBinarySignature) {
+		return ProtocolError("Invalid binary copy signature")
+	}
+
+	flags := binary.BigEndian.Uint32(header[len(copyBinarySignature):])
+	if flags&copyBinaryOids != 0 {
+		return ProtocolError("Binary copy data with OIDs is not supported")
+	}
+
+	extensionLen := int(binary.BigEndian.Uint32(header[len(copyBinarySignature)+4:]))
+	_, err := cr.r.Discard(extensionLen)
+	return err
+}
+
+// Next reads the next row. It returns false when there are no more rows or an
+// error has occurred.
+func (cr *CopyBinaryReader) Next() bool {
+	if cr.err != nil || cr.done {
+		return false
+	}
+
+	if err := cr.readRow(); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		cr.err = err
+		return false
+	}
+
+	return !cr.done
+}
+
+// readRow reads the next row into cr.row. It sets cr.done at the end of the
+// copy data.
+func (cr *CopyBinaryReader) readRow() error {
+	if !cr.headerRead {
+		if err := cr.readHeader(); err != nil {
+			return err
+		}
+		cr.headerRead = true
+	}
+
+	buf := make([]byte, 4)
+	if _, err := io.ReadFull(cr.r, buf[:2]); err != nil {
+		return err
+	}
+	fieldCount := int16(binary.BigEndian.Uint16(buf))
+	if fieldCount == -1 {
+		cr.done = true
+		return nil
+	}
+	if int(fieldCount) != len(cr.fields) {
+		return ProtocolError(fmt.Sprintf("Expected %d fields, got %d fields", len(cr.fields), fieldCount))
+	}
+
+	// The field sizes come from the copy data so the row buffer only grows as
+	// bytes actually arrive rather than by the declared size.
+	row := bytes.NewBuffer(cr.row[:0])
+	for i := 0; i < len(cr.fields); i++ {
+		if _, err := io.ReadFull(cr.r, buf); err != nil {
+			return err
+		}
+		row.Write(buf)
+
+		size := int32(binary.BigEndian.Uint32(buf))
+		if size < -1 {
+			return ProtocolError(fmt.Sprintf("Received an invalid size for a field: %d", size))
+		}
+		if size > 0 {
+			if _, err := io.CopyN(row, cr.r, int64(size)); err != nil {
+				if err == io.EOF {
+					err = io.ErrUnexpectedEOF
+				}
+				return err
+			}
+		}
+	}
+	cr.row = row.Bytes()
+
+	return nil
+}
+
+// startRow prepares cr.mr to read the values of the current row.
+func (cr *CopyBinaryReader) startRow() {
+	if cr.rowBr == nil {
+		cr.rowBr = bufio.NewReader(bytes.NewReader(cr.row))
+	} else {
+		cr.rowBr.Reset(bytes.NewReader(cr.row))
+	}
+	cr.mr.reader = cr.rowBr
+	cr.mr.msgBytesRemaining = int32(len(cr.row))
+	cr.mr.err = nil
+}
+
+// nextColumn returns a ValueReader for the value of column i. The previous
+// column's value must have been read or skipped.
+func (cr *CopyBinaryReader) nextColumn(i int, prev *ValueReader) *ValueReader {
+	if prev != nil && prev.Len() > 0 {
+		cr.mr.readBytes(prev.Len())
+	}
+	size := cr.mr.readInt32()
+	return &ValueReader{mr: &cr.mr, fd: &cr.fields[i], conn: cr.conn, valueBytesRemaining: size}
+}
+
+// Scan reads the values of the current row into dest.
+func (cr *CopyBinaryReader) Scan(dest ...interface{}) error {
+	if cr.err != nil {
+		return cr.err
+	}
+	if len(dest) != len(cr.fields) {
+		return fmt.Errorf("Scan received wrong number of arguments, got %d but expected %d", len(dest), len(cr.fields))
+	}
+
+	cr.startRow()
+	var vr *ValueReader
+	for i, d := range dest {
+		vr = cr.nextColumn(i, vr)
+		if d == nil {
+			continue
+		}
+
+		if err := scanValue(vr, d); err != nil {
+			return scanArgError{col: i, err: err}
+		}
+		if vr.Err() != nil {
+			return scanArgError{col: i, err: vr.Err()}
+		}
+		if cr.mr.Err() != nil {
+			return cr.mr.Err()
+		}
+	}
+
+	return nil
+}
+
+// Values returns the values of the current row. They are decoded to the same
+// types as with Rows.Values, except that values of types pgx reads in text
+// format other than enums and text types such as bpchar are returned as raw
+// []byte in their binary format.
+func (cr *CopyBinaryReader) Values() ([]interface{}, error) {
+	if cr.err != nil {
+		return nil, cr.err
+	}
+
+	cr.startRow()
+	values := make([]interface{}, 0, len(cr.fields))
+	var vr *ValueReader
+	for i := range cr.fields {
+		vr = cr.nextColumn(i, vr)
+		if vr.Len() == -1 {
+			values = append(values, nil)
+			continue
+		}
+
+		values = append(values, decodeValue(vr))
+		if vr.Err() != nil {
+			return nil, vr.Err()
+		}
+		if cr.mr.Err() != nil {
+			return nil, cr.mr.Err()
+		}
+	}
+
+	return values, nil
+}
+
+// Err returns any error that occurred while reading.
+func (cr *CopyBinaryReader) Err() error {
+	return cr.err
+}
+
+// copyDataWriter sends everything written to it to the server as CopyData
+// messages.
+type copyDataWriter struct {
+	conn *Conn
+	buf  []byte
+}
+
+func (w *copyDataWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf[:0], copyData, 0, 0, 0, 0)
+	w.buf = append(w.buf, p...)
+	binary.BigEndian.PutUint32(w.buf[1:], uint32(len(p)+4))
+
+	if _, err := w.conn.conn.Write(w.buf); err != nil {
+		w.conn.die(err)
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// copyOutReader reads the data of the CopyData messages the server sends
+// after CopyOutResponse. It returns io.EOF at CopyDone.
+type copyOutReader struct {
+	conn *Conn
+	r    *msgReader
+	err  error
+}
+
+func (cr *copyOutReader) Read(p []byte) (int, error) {
+	for cr.r == nil || cr.r.msgBytesRemaining == 0 {
+		if cr.err != nil {
+			return 0, cr.err
+		}
+
+		t, r, err := cr.conn.rxMsg()
+		if err != nil {
+			cr.err = err
+			return 0, err
+		}
+
+		switch t {
+		case copyData:
+			cr.r = r
+		case copyDone:
+			cr.err = io.EOF
+		case errorResponse:
+			cr.err = cr.conn.rxErrorResponse(r)
+		default:
+			if err := cr.conn.processContextFreeMsg(t, r); err != nil {
+				cr.err = err
+			}
+		}
+	}
+
+	n := len(p)
+	if int(cr.r.msgBytesRemaining) < n {
+		n = int(cr.r.msgBytesRemaining)
+	}
+	copy(p, cr.r.readBytes(int32(n)))
+	return n, cr.r.Err()
+}
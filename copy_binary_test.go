@@ -0,0 +1,191 @@
+package pgx_test
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx"
+)
+
+func TestCopyBinaryWriterReaderRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	oids := []pgx.Oid{pgx.Int4Oid, pgx.TextOid, pgx.ByteaOid, pgx.TimestampTzOid, pgx.Int4ArrayOid}
+	tzedTime := time.Date(2016, 1, 2, 3, 4, 5, 0, time.UTC).Local()
+	inputRows := [][]interface{}{
+		{int32(1), "foo", []byte{1, 2}, tzedTime, []int32{1, 2}},
+		{nil, nil, nil, nil, nil},
+	}
+
+	buf := &bytes.Buffer{}
+	cw := pgx.NewCopyBinaryWriter(buf, nil, oids)
+	for _, row := range inputRows {
+		if err := cw.WriteRow(row); err != nil {
+			t.Fatalf("Unexpected failure: %v", err)
+		}
+	}
+	if err := cw.WriteRow([]interface{}{struct{}{}, "foo", nil, nil, nil}); err == nil {
+		t.Error("Expected error writing row with invalid value")
+	}
+	if err := cw.WriteRow([]interface{}{int32(1)}); err == nil {
+		t.Error("Expected error writing row with too few values")
+	}
+	if err := cw.Close(); err != nil {
+		t.Fatalf("Unexpected failure: %v", err)
+	}
+
+	var outputRows [][]interface{}
+	cr := pgx.NewCopyBinaryReader(bytes.NewReader(buf.Bytes()), nil, oids)
+	for cr.Next() {
+		values, err := cr.Values()
+		if err != nil {
+			t.Fatalf("Unexpected failure: %v", err)
+		}
+		outputRows = append(outputRows, values)
+	}
+	if cr.Err() != nil {
+		t.Fatalf("Unexpected failure: %v", cr.Err())
+	}
+	if !reflect.DeepEqual(inputRows, outputRows) {
+		t.Errorf("Expected %v, got %v", inputRows, outputRows)
+	}
+
+	cr = pgx.NewCopyBinaryReader(bytes.NewReader(buf.Bytes()), nil, oids)
+	if !cr.Next() {
+		t.Fatalf("Expected a row, got error %v", cr.Err())
+	}
+	var n int32
+	var s string
+	var ts time.Time
+	var ints []int32
+	if err := cr.Scan(&n, &s, nil, &ts, &ints); err != nil {
+		t.Fatalf("Unexpected failure: %v", err)
+	}
+	if n != 1 || s != "foo" || !ts.Equal(tzedTime) || !reflect.DeepEqual(ints, []int32{1, 2}) {
+		t.Errorf("Unexpected values: %v %v %v %v", n, s, ts, ints)
+	}
+	if !cr.Next() || cr.Scan(&n, &s, nil, &ts, &ints) == nil {
+		t.Error("Expected error scanning null into int32")
+	}
+}
+
+func TestCopyBinaryReaderErrors(t *testing.T) {
+	t.Parallel()
+
+	buf := &bytes.Buffer{}
+	cw := pgx.NewCopyBinaryWriter(buf, nil, []pgx.Oid{pgx.Int4Oid})
+	if err := cw.WriteRow([]interface{}{int32(1)}); err != nil {
+		t.Fatalf("Unexpected failure: %v", err)
+	}
+	if err := cw.Close(); err != nil {
+		t.Fatalf("Unexpected failure: %v", err)
+	}
+	data := buf.Bytes()
+
+	// The row's only field size follows the 19 byte header and the field count.
+	withFieldSize := func(size []byte) []byte {
+		b := append([]byte(nil), data...)
+		copy(b[21:25], size)
+		return b
+	}
+
+	tests := []struct {
+		data     []byte
+		oids     []pgx.Oid
+		expected error
+	}{
+		{[]byte("not copy data at all"), []pgx.Oid{pgx.Int4Oid}, nil},
+		{data[:len(data)-2], []pgx.Oid{pgx.Int4Oid}, io.ErrUnexpectedEOF},
+		{data, []pgx.Oid{pgx.Int4Oid, pgx.Int4Oid}, nil},
+		{withFieldSize([]byte{255, 255, 255, 254}), []pgx.Oid{pgx.Int4Oid}, pgx.ProtocolError("Received an invalid size for a field: -2")},
+		{withFieldSize([]byte{127, 255, 255, 255}), []pgx.Oid{pgx.Int4Oid}, io.ErrUnexpectedEOF},
+	}
+
+	for i, tt := range tests {
+		cr := pgx.NewCopyBinaryReader(bytes.NewReader(tt.data), nil, tt.oids)
+		for cr.Next() {
+		}
+		if cr.Err() == nil {
+			t.Errorf("%d. Expected error", i)
+		} else if tt.expected != nil && cr.Err() != tt.expected {
+			t.Errorf("%d. Expected %v, got %v", i, tt.expected, cr.Err())
+		}
+	}
+}
+
+func TestCopierBinaryRows(t *testing.T) {
+	t.Parallel()
+
+	conn := mustConnect(t, *defaultConnConfig)
+	defer closeConn(t, conn)
+
+	mustExec(t, conn, "create temporary table foo(a int4, b text)")
+
+	copier, err := pgx.NewCopier(pgx.BinaryFormat)
+	if err != nil {
+		t.Fatalf("Unexpected failure: %v", err)
+	}
+
+	inputRows := [][]interface{}{{int32(1), "foo"}, {nil, "bar"}}
+	copyCount, err := copier.CopyConn(conn).Table(pgx.Identifier{"foo"}).FromSource(pgx.CopyToRows(inputRows))
+	if err != nil {
+		t.Fatalf("Unexpected failure: %v", err)
+	}
+	if copyCount != len(inputRows) {
+		t.Errorf("Expected FromSource to return %d copied rows, but got %d", len(inputRows), copyCount)
+	}
+
+	var outputRows [][]interface{}
+	err = copier.CopyConn(conn).Query("select a, b from foo where b <> $1", "").ToRows(func(rows *pgx.CopyBinaryReader) error {
+		for rows.Next() {
+			values, err := rows.Values()
+			if err != nil {
+				return err
+			}
+			outputRows = append(outputRows, values)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		t.Fatalf("Unexpected failure: %v", err)
+	}
+	if !reflect.DeepEqual(inputRows, outputRows) {
+		t.Errorf("Expected %v, got %v", inputRows, outputRows)
+	}
+
+	// Copy data written offline can be loaded later
+	buf := &bytes.Buffer{}
+	cw := pgx.NewCopyBinaryWriter(buf, nil, []pgx.Oid{pgx.Int4Oid, pgx.TextOid})
+	cw.WriteRow([]interface{}{int32(2), "baz"})
+	if err := cw.Close(); err != nil {
+		t.Fatalf("Unexpected failure: %v", err)
+	}
+	if err := copier.CopyConn(conn).Table(pgx.Identifier{"foo"}).From(buf); err != nil {
+		t.Fatalf("Unexpected failure: %v", err)
+	}
+
+	// ToRows must leave the connection usable when f stops early
+	err = copier.CopyConn(conn).Table(pgx.Identifier{"foo"}).ToRows(func(rows *pgx.CopyBinaryReader) error {
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Unexpected failure: %v", err)
+	}
+
+	var n int64
+	if err := conn.QueryRow("select count(*) from foo").Scan(&n); err != nil {
+		t.Fatalf("Unexpected failure: %v", err)
+	}
+	if n != 3 {
+		t.Errorf("Expected 3 rows, got %d", n)
+	}
+
+	if _, err := copier.CopyConn(conn).Table(pgx.Identifier{"foo"}).FromSource(pgx.CopyToRows([][]interface{}{{struct{}{}, "foo"}})); err == nil {
+		t.Error("Expected error copying invalid value")
+	}
+
+	ensureConnValid(t, conn)
+}
@@ -0,0 +1,100 @@
+package pgx
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+)
+
+// CopyFromSink is the interface used by *Conn.CopyFromQuery as the destination
+// for copy data. It is the counterpart of CopyToSource. *CopyBinaryWriter
+// implements it so query results can be written directly to a file.
+type CopyFromSink interface {
+	// WriteRow is called with the values of each row. values is not reused
+	// between calls. If it returns an error *Conn.CopyFromQuery cancels the
+	// query with a CancelRequest, which aborts the transaction if there is
+	// one.
+	WriteRow(values []interface{}) error
+}
+
+// CopyFromQuery uses the PostgreSQL copy protocol to efficiently read the
+// results of a query. Each row is decoded like with CopyBinaryReader.Values
+// and passed to sink. args are interpolated into sql as SQL literals as COPY
+// does not accept parameters. It returns the number of rows copied.
+//
+// CopyFromQuery requires all result values use the binary format. Types with
+// a Codec can only be used if it decodes the binary format. Enums and text
+// types such as bpchar, varchar, citext and xml are decoded to string as with
+// Rows.Values. Values of other types pgx reads in text format are returned as
+// raw []byte in their binary format.
+func (c *Conn) CopyFromQuery(sql string, args []interface{}, sink CopyFromSink) (int, error) {
+	query, err := sanitizeSQL(sql, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	ps, err := c.Prepare("", query)
+	if err != nil {
+		return 0, err
+	}
+
+	oids := make([]Oid, len(ps.FieldDescriptions))
+	for i, fd := range ps.FieldDescriptions {
+		if codec := connInfoOf(c).codec(fd.DataType); codec != nil && codec.FormatCode() != BinaryFormatCode {
+			return 0, fmt.Errorf("CopyFromQuery cannot copy column %s of type %s with a text format codec", fd.Name, fd.DataTypeName)
+		}
+		oids[i] = fd.DataType
+	}
+
+	var copyCount int
+	err = c.copyOutBinary(fmt.Sprintf("copy (%s\n) to stdout binary;", query), oids, func(rows *CopyBinaryReader) error {
+		for rows.Next() {
+			values, err := rows.Values()
+			if err != nil {
+				return err
+			}
+			if err := sink.WriteRow(values); err != nil {
+				return err
+			}
+			copyCount++
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return 0, err
+	}
+	return copyCount, nil
+}
+
+// copyOutBinary sends sql, a COPY ... TO STDOUT statement for data in the
+// binary format, and calls f with a *CopyBinaryReader reading the columns of
+// types oids. Data f does not read is discarded. If f returns an error before
+// all data was read the query is canceled so the rest is not sent.
+func (c *Conn) copyOutBinary(sql string, oids []Oid, f func(*CopyBinaryReader) error) error {
+	if err := c.sendSimpleQuery(sql); err != nil {
+		return err
+	}
+	if _, err := getmessage(c, copyOutResponse); err != nil {
+		return err
+	}
+
+	r := &copyOutReader{conn: c}
+	ferr := f(NewCopyBinaryReader(r, c, oids))
+
+	// The server stops sending data and responds with a query_canceled
+	// error. If the cancel request cannot be sent the data is discarded.
+	if ferr != nil && r.err == nil {
+		c.CancelRequest()
+	}
+
+	_, err := io.Copy(ioutil.Discard, r)
+	if _, ok := err.(PgError); err == nil || ok {
+		if rerr := awaitready(c); err == nil {
+			err = rerr
+		}
+	}
+	if ferr != nil {
+		return ferr
+	}
+	return err
+}
@@ -0,0 +1,169 @@
+package pgx_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx"
+)
+
+type copyFromRows struct {
+	rows [][]interface{}
+	err  error
+}
+
+func (s *copyFromRows) WriteRow(values []interface{}) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.rows = append(s.rows, values)
+	return nil
+}
+
+func TestConnCopyFromQuery(t *testing.T) {
+	t.Parallel()
+
+	conn := mustConnect(t, *defaultConnConfig)
+	defer closeConn(t, conn)
+
+	mustExec(t, conn, `create temporary table foo(
+		a int2,
+		b int4,
+		c int8,
+		d varchar,
+		e text,
+		f date,
+		g timestamptz
+	)`)
+
+	inputRows := [][]interface{}{
+		{int16(0), int32(1), int64(2), "abc", "efg", time.Date(2000, 1, 1, 0, 0, 0, 0, time.Local), time.Date(2010, 2, 3, 4, 5, 6, 0, time.Local)},
+		{nil, nil, nil, nil, nil, nil, nil},
+	}
+
+	if _, err := conn.CopyTo("foo", []string{"a", "b", "c", "d", "e", "f", "g"}, pgx.CopyToRows(inputRows)); err != nil {
+		t.Fatalf("Unexpected error for CopyTo: %v", err)
+	}
+
+	sink := &copyFromRows{}
+	copyCount, err := conn.CopyFromQuery("select * from foo where e = $1 or e is null order by a", []interface{}{"efg"}, sink)
+	if err != nil {
+		t.Fatalf("Unexpected error for CopyFromQuery: %v", err)
+	}
+	if copyCount != len(inputRows) {
+		t.Errorf("Expected CopyFromQuery to return %d copied rows, but got %d", len(inputRows), copyCount)
+	}
+	if !reflect.DeepEqual(inputRows, sink.rows) {
+		t.Errorf("Input rows and output rows do not equal: %v -> %v", inputRows, sink.rows)
+	}
+
+	ensureConnValid(t, conn)
+}
+
+func TestConnCopyFromQueryTextTypes(t *testing.T) {
+	t.Parallel()
+
+	conn := mustConnect(t, *defaultConnConfig)
+	defer closeConn(t, conn)
+
+	tx := createEnumType(t, conn, "pgx_test_enum_copy_from")
+	defer tx.Rollback()
+
+	if _, err := conn.RegisterEnumType("pgx_test_enum_copy_from"); err != nil {
+		t.Fatalf("conn.RegisterEnumType failed: %v", err)
+	}
+
+	sink := &copyFromRows{}
+	_, err := tx.CopyFromQuery("select 'happy'::pgx_test_enum_copy_from, 'ab'::char(4), 'cd'::varchar(4)", nil, sink)
+	if err != nil {
+		t.Fatalf("Unexpected error for CopyFromQuery: %v", err)
+	}
+
+	expected := [][]interface{}{{"happy", "ab  ", "cd"}}
+	if !reflect.DeepEqual(expected, sink.rows) {
+		t.Errorf("Expected %v, got %v", expected, sink.rows)
+	}
+
+	ensureConnValid(t, conn)
+}
+
+func TestConnCopyFromQuerySinkError(t *testing.T) {
+	t.Parallel()
+
+	conn := mustConnect(t, *defaultConnConfig)
+	defer closeConn(t, conn)
+
+	// The query is canceled instead of reading all the rows
+	sinkErr := errors.New("sink failed")
+	copyCount, err := conn.CopyFromQuery("select n from generate_series(1, 100000000) n", nil, &copyFromRows{err: sinkErr})
+	if err != sinkErr {
+		t.Errorf("Expected CopyFromQuery to return %v, but got %v", sinkErr, err)
+	}
+	if copyCount != 0 {
+		t.Errorf("Expected CopyFromQuery to return 0 copied rows, but got %d", copyCount)
+	}
+
+	ensureConnValid(t, conn)
+}
+
+func TestConnCopyFromQueryServerError(t *testing.T) {
+	t.Parallel()
+
+	conn := mustConnect(t, *defaultConnConfig)
+	defer closeConn(t, conn)
+
+	sink := &copyFromRows{}
+	_, err := conn.CopyFromQuery("select 1 / (3 - n) from generate_series(1, 5) n", nil, sink)
+	if pgErr, ok := err.(pgx.PgError); !ok || pgErr.Code != "22012" {
+		t.Errorf("Expected division by zero error, but got %v", err)
+	}
+
+	ensureConnValid(t, conn)
+}
+
+func TestTxCopyFromQuery(t *testing.T) {
+	t.Parallel()
+
+	conn := mustConnect(t, *defaultConnConfig)
+	defer closeConn(t, conn)
+
+	tx, err := conn.Begin()
+	if err != nil {
+		t.Fatalf("conn.Begin failed: %v", err)
+	}
+
+	sink := &copyFromRows{}
+	if _, err := tx.CopyFromQuery("select $1::text", []interface{}{"foo"}, sink); err != nil {
+		t.Fatalf("Unexpected error for CopyFromQuery: %v", err)
+	}
+	if expected := [][]interface{}{{"foo"}}; !reflect.DeepEqual(sink.rows, expected) {
+		t.Errorf("Expected %v, got %v", expected, sink.rows)
+	}
+
+	if err := tx.Rollback(); err != nil {
+		t.Fatalf("tx.Rollback failed: %v", err)
+	}
+	if _, err := tx.CopyFromQuery("select 1", nil, sink); err != pgx.ErrTxClosed {
+		t.Errorf("Expected %v, got %v", pgx.ErrTxClosed, err)
+	}
+
+	ensureConnValid(t, conn)
+}
+
+func TestConnPoolCopyFromQuery(t *testing.T) {
+	t.Parallel()
+
+	pool := createConnPool(t, 2)
+	defer pool.Close()
+
+	sink := &copyFromRows{}
+	copyCount, err := pool.CopyFromQuery("select n from generate_series(1, $1) n", []interface{}{3}, sink)
+	if err != nil {
+		t.Fatalf("Unexpected error for CopyFromQuery: %v", err)
+	}
+	if expected := [][]interface{}{{int32(1)}, {int32(2)}, {int32(3)}}; copyCount != 3 || !reflect.DeepEqual(sink.rows, expected) {
+		t.Errorf("Expected %v, got %d rows %v", expected, copyCount, sink.rows)
+	}
+}
@@ -8,15 +8,15 @@ import (
 
 type CopierOptions interface {
 	o() int
-	string() string
+	string() (string, error)
 }
 
 type copierformat string
 
 func (copierformat) o() int { return 1 }
 
-func (c copierformat) string() string {
-	return fmt.Sprintf(`FORMAT '%s'`, c)
+func (c copierformat) string() (string, error) {
+	return copierStringOption(`FORMAT`, string(c))
 }
 
 const (
@@ -29,85 +29,96 @@ type CopierOIDs bool
 
 func (CopierOIDs) o() int { return 2 }
 
-func (c CopierOIDs) string() string {
-	return fmt.Sprintf(`OIDS %v`, c)
+func (c CopierOIDs) string() (string, error) {
+	return fmt.Sprintf(`OIDS %v`, c), nil
 }
 
 type CopierDelimiter rune
 
 func (CopierDelimiter) o() int { return 3 }
 
-func (c CopierDelimiter) string() string {
-	return fmt.Sprintf(`DELIMITER '%s'`, string(c))
+func (c CopierDelimiter) string() (string, error) {
+	return copierStringOption(`DELIMITER`, string(c))
 }
 
 type CopierNullString string
 
 func (CopierNullString) o() int { return 4 }
 
-func (c CopierNullString) string() string {
-	return fmt.Sprintf(`NULL '%s'`, c)
+func (c CopierNullString) string() (string, error) {
+	return copierStringOption(`NULL`, string(c))
 }
 
 type CopierHeader bool
 
 func (CopierHeader) o() int { return 5 }
 
-func (c CopierHeader) string() string {
-	return fmt.Sprintf(`HEADER %v`, c)
+func (c CopierHeader) string() (string, error) {
+	return fmt.Sprintf(`HEADER %v`, c), nil
 }
 
 type CopierQuote rune
 
 func (CopierQuote) o() int { return 6 }
 
-func (c CopierQuote) string() string {
-	s := string(c)
-	if s == `'` {
-		s = `''`
-	}
-	return fmt.Sprintf(`QUOTE '%s'`, s)
+func (c CopierQuote) string() (string, error) {
+	return copierStringOption(`QUOTE`, string(c))
 }
 
 type CopierEscape rune
 
 func (CopierEscape) o() int { return 7 }
 
-func (c CopierEscape) string() string {
-	return fmt.Sprintf(`ESCAPE '%s'`, string(c))
+func (c CopierEscape) string() (string, error) {
+	return copierStringOption(`ESCAPE`, string(c))
 }
 
 type CopierForceQuoteColumns []string
 
 func (CopierForceQuoteColumns) o() int { return 8 }
 
-func (c CopierForceQuoteColumns) string() string {
+func (c CopierForceQuoteColumns) string() (string, error) {
 	if len(c) == 0 {
-		return ""
+		return "", nil
 	}
 	if len(c) == 1 && c[0] == `*` {
-		return fmt.Sprintf(`FORCE_QUOTE *`)
+		return `FORCE_QUOTE *`, nil
+	}
+	if err := validateIdentifier(c...); err != nil {
+		return "", err
 	}
-	return fmt.Sprintf(`FORCE_QUOTE (%s)`, strings.Join(c, `,`))
+	return fmt.Sprintf(`FORCE_QUOTE (%s)`, sanitizeColumnNames(c)), nil
 }
 
 type CopierForceNotNullColumns []string
 
 func (CopierForceNotNullColumns) o() int { return 9 }
 
-func (c CopierForceNotNullColumns) string() string {
+func (c CopierForceNotNullColumns) string() (string, error) {
 	if len(c) == 0 {
-		return ""
+		return "", nil
 	}
-	return fmt.Sprintf(`FORCE_NOT_NULL (%s)`, strings.Join(c, `,`))
+	if err := validateIdentifier(c...); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf(`FORCE_NOT_NULL (%s)`, sanitizeColumnNames(c)), nil
 }
 
 type CopierEncoding string
 
 func (CopierEncoding) o() int { return 10 }
 
-func (s CopierEncoding) string() string {
-	return fmt.Sprintf(`ENCODING '%s'`, s)
+func (s CopierEncoding) string() (string, error) {
+	return copierStringOption(`ENCODING`, string(s))
+}
+
+// copierStringOption returns the option name with the string literal value.
+func copierStringOption(name, value string) (string, error) {
+	quoted, err := quoteString(value)
+	if err != nil {
+		return "", err
+	}
+	return name + ` ` + quoted, nil
 }
 
 type Copier struct {
@@ -128,18 +139,26 @@ func NewCopier(options ...CopierOptions) (*Copier, error) {
 	return c, nil
 }
 
-func (co *Copier) options() string {
+func (co *Copier) binary() bool {
+	format, ok := co.opts[BinaryFormat.o()]
+	return ok && format == BinaryFormat
+}
+
+func (co *Copier) options() (string, error) {
 	if len(co.opts) == 0 {
-		return ""
+		return "", nil
 	}
 	options := make([]string, 0, len(co.opts))
 	for _, v := range co.opts {
-		s := v.string()
+		s, err := v.string()
+		if err != nil {
+			return "", err
+		}
 		if len(s) > 0 {
 			options = append(options, s)
 		}
 	}
-	return fmt.Sprintf(` WITH (%s)`, strings.Join(options, `,`))
+	return fmt.Sprintf(` WITH (%s)`, strings.Join(options, `,`)), nil
 }
 
 type CopyType interface {
@@ -148,14 +167,19 @@ type CopyType interface {
 	ct()
 }
 
+// copytype copies with exactly one of tx, conn or pool.
 type copytype struct {
 	tx      *Tx
+	conn    *Conn
+	pool    *ConnPool
 	options *Copier
 }
 
 func (ct *copytype) Table(name Identifier, columns ...string) FromTo {
 	return &copyprep{
 		tx:      ct.tx,
+		conn:    ct.conn,
+		pool:    ct.pool,
 		options: ct.options,
 		table:   name,
 		columns: columns,
@@ -165,6 +189,8 @@ func (ct *copytype) Table(name Identifier, columns ...string) FromTo {
 func (ct *copytype) Query(q string, args ...interface{}) To {
 	return &copyprep{
 		tx:      ct.tx,
+		conn:    ct.conn,
+		pool:    ct.pool,
 		options: ct.options,
 		query:   q,
 		args:    args,
@@ -175,17 +201,26 @@ func (*copytype) ct() {}
 
 type To interface {
 	To(io.Writer) error
+	// ToRows calls f with a CopyBinaryReader reading the copied rows. The
+	// Copier must use BinaryFormat.
+	ToRows(f func(*CopyBinaryReader) error) error
 	cp()
 }
 
 type FromTo interface {
 	From(io.Reader) error
+	// FromSource copies the rows of rowSrc and returns the number of rows
+	// copied. The Copier must use BinaryFormat.
+	FromSource(rowSrc CopyToSource) (int, error)
 	To(io.Writer) error
+	ToRows(f func(*CopyBinaryReader) error) error
 	cp()
 }
 
 type copyprep struct {
 	tx      *Tx
+	conn    *Conn
+	pool    *ConnPool
 	options *Copier
 	table   Identifier
 	columns []string
@@ -193,7 +228,18 @@ type copyprep struct {
 	args    []interface{}
 }
 
-func (cp *copyprep) conn() (*Conn, error) {
+// acquire returns the connection to copy with. It must be returned with
+// release.
+func (cp *copyprep) acquire() (*Conn, error) {
+	switch {
+	case cp.pool != nil:
+		return cp.pool.Acquire()
+	case cp.conn != nil:
+		if !cp.conn.IsAlive() {
+			return nil, ErrDeadConn
+		}
+		return cp.conn, nil
+	}
 	if cp.tx == nil {
 		return nil, fmt.Errorf("copy: nil transaction")
 	}
@@ -203,7 +249,20 @@ func (cp *copyprep) conn() (*Conn, error) {
 	return cp.tx.Conn(), nil
 }
 
+func (cp *copyprep) release(conn *Conn) {
+	if cp.pool != nil {
+		cp.pool.Release(conn)
+	}
+}
+
 func (cp *copyprep) checktq() (table bool, err error) {
+	if err := validateIdentifier(cp.table...); err != nil {
+		return false, err
+	}
+	if err := validateIdentifier(cp.columns...); err != nil {
+		return false, err
+	}
+
 	switch {
 	case len(cp.table) > 0 && len(cp.query) > 0:
 		return false, fmt.Errorf("copy: both table and query prepped")
@@ -217,7 +276,7 @@ func (cp *copyprep) checktq() (table bool, err error) {
 }
 
 func (cp *copyprep) copyout(conn *Conn, w io.Writer) error {
-	_, err := getmessage(conn, int('H'))
+	_, err := getmessage(conn, copyOutResponse)
 	if err != nil {
 		return err
 	}
@@ -239,7 +298,7 @@ func (cp *copyprep) copyout(conn *Conn, w io.Writer) error {
 			}
 		case errorResponse:
 			err = conn.rxErrorResponse(r)
-			conn.die(err)
+			awaitready(conn)
 			return err
 		default:
 			err = conn.processContextFreeMsg(t, r)
@@ -249,15 +308,7 @@ func (cp *copyprep) copyout(conn *Conn, w io.Writer) error {
 			}
 		}
 	}
-	_, err = getmessage(conn, commandComplete)
-	if err != nil {
-		return err
-	}
-	_, err = getmessage(conn, readyForQuery)
-	if err != nil {
-		return err
-	}
-	return nil
+	return awaitready(conn)
 }
 
 func (cp *copyprep) copyin(conn *Conn, r io.Reader) error {
@@ -289,6 +340,7 @@ func (cp *copyprep) copyin(conn *Conn, r io.Reader) error {
 			conn.die(err)
 			return err
 		}
+		awaitready(conn)
 		return err
 	}
 	wb = newWriteBuf(conn, copyDone)
@@ -298,15 +350,7 @@ func (cp *copyprep) copyin(conn *Conn, r io.Reader) error {
 		conn.die(err)
 		return err
 	}
-	_, err = getmessage(conn, commandComplete)
-	if err != nil {
-		return err
-	}
-	_, err = getmessage(conn, readyForQuery)
-	if err != nil {
-		return err
-	}
-	return nil
+	return awaitready(conn)
 }
 
 func (cp *copyprep) fromtable(conn *Conn, r io.Reader) error {
@@ -314,7 +358,7 @@ func (cp *copyprep) fromtable(conn *Conn, r io.Reader) error {
 	if len(cp.columns) > 0 {
 		columns = make([]string, len(cp.columns))
 		for i := range cp.columns {
-			columns[i] = quoteIdentifier(cp.columns[i])
+			columns[i] = Identifier{cp.columns[i]}.Sanitize()
 		}
 	}
 	var cols string
@@ -322,8 +366,11 @@ func (cp *copyprep) fromtable(conn *Conn, r io.Reader) error {
 		cols = fmt.Sprintf(`(%s)`, strings.Join(columns, `,`))
 	}
 	table := cp.table.Sanitize()
-	options := cp.options.options()
-	err := conn.sendSimpleQuery(fmt.Sprintf("copy %s%s from stdin %s;", table, cols, options))
+	options, err := cp.options.options()
+	if err != nil {
+		return err
+	}
+	err = conn.sendSimpleQuery(fmt.Sprintf("copy %s%s from stdin %s;", table, cols, options))
 	if err != nil {
 		return err
 	}
@@ -335,7 +382,7 @@ func (cp *copyprep) totable(conn *Conn, w io.Writer) error {
 	if len(cp.columns) > 0 {
 		columns = make([]string, len(cp.columns))
 		for i := range cp.columns {
-			columns[i] = quoteIdentifier(cp.columns[i])
+			columns[i] = Identifier{cp.columns[i]}.Sanitize()
 		}
 	}
 	var cols string
@@ -343,8 +390,11 @@ func (cp *copyprep) totable(conn *Conn, w io.Writer) error {
 		cols = fmt.Sprintf(`(%s)`, strings.Join(columns, `,`))
 	}
 	table := cp.table.Sanitize()
-	options := cp.options.options()
-	err := conn.sendSimpleQuery(fmt.Sprintf("copy %s%s to stdout %s;", table, cols, options))
+	options, err := cp.options.options()
+	if err != nil {
+		return err
+	}
+	err = conn.sendSimpleQuery(fmt.Sprintf("copy %s%s to stdout %s;", table, cols, options))
 	if err != nil {
 		return err
 	}
@@ -352,33 +402,163 @@ func (cp *copyprep) totable(conn *Conn, w io.Writer) error {
 }
 
 func (cp *copyprep) toquery(conn *Conn, w io.Writer) error {
-	return fmt.Errorf("TODO")
+	query, err := sanitizeSQL(cp.query, cp.args...)
+	if err != nil {
+		return err
+	}
+	options, err := cp.options.options()
+	if err != nil {
+		return err
+	}
+	// The newline ends a trailing line comment in query
+	err = conn.sendSimpleQuery(fmt.Sprintf("copy (%s\n) to stdout %s;", query, options))
+	if err != nil {
+		return err
+	}
+	return cp.copyout(conn, w)
 }
 
-func (cp *copyprep) From(r io.Reader) error {
-	conn, err := cp.conn()
+// selectsql returns a query selecting the copied rows.
+func (cp *copyprep) selectsql(istable bool) (string, error) {
+	if !istable {
+		return sanitizeSQL(cp.query, cp.args...)
+	}
+	cols := "*"
+	if len(cp.columns) > 0 {
+		columns := make([]string, len(cp.columns))
+		for i := range cp.columns {
+			columns[i] = Identifier{cp.columns[i]}.Sanitize()
+		}
+		cols = strings.Join(columns, `,`)
+	}
+	return fmt.Sprintf("select %s from %s", cols, cp.table.Sanitize()), nil
+}
+
+// oids returns the types of the columns query returns.
+func (cp *copyprep) oids(conn *Conn, query string) ([]Oid, error) {
+	ps, err := conn.Prepare("", query)
+	if err != nil {
+		return nil, err
+	}
+	oids := make([]Oid, len(ps.FieldDescriptions))
+	for i, fd := range ps.FieldDescriptions {
+		oids[i] = fd.DataType
+	}
+	return oids, nil
+}
+
+func (cp *copyprep) FromSource(rowSrc CopyToSource) (int, error) {
+	if !cp.options.binary() {
+		return 0, fmt.Errorf("copy: FromSource requires binary format")
+	}
+	istable, err := cp.checktq()
+	if err != nil {
+		return 0, err
+	}
+	if !istable {
+		return 0, fmt.Errorf("copy: query not possible here")
+	}
+	conn, err := cp.acquire()
+	if err != nil {
+		return 0, err
+	}
+	defer cp.release(conn)
+	query, err := cp.selectsql(istable)
+	if err != nil {
+		return 0, err
+	}
+	oids, err := cp.oids(conn, query)
+	if err != nil {
+		return 0, err
+	}
+
+	pr, pw := io.Pipe()
+	done := make(chan struct{})
+	var count int
+	go func() {
+		defer close(done)
+		cw := NewCopyBinaryWriter(pw, conn, oids)
+		for rowSrc.Next() {
+			values, err := rowSrc.Values()
+			if err == nil {
+				err = cw.WriteRow(values)
+			}
+			if err != nil {
+				pw.CloseWithError(err)
+				return
+			}
+			count++
+		}
+		err := rowSrc.Err()
+		if err == nil {
+			err = cw.Close()
+		}
+		pw.CloseWithError(err)
+	}()
+
+	err = cp.fromtable(conn, pr)
+	pr.Close()
+	<-done
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+func (cp *copyprep) ToRows(f func(*CopyBinaryReader) error) error {
+	if !cp.options.binary() {
+		return fmt.Errorf("copy: ToRows requires binary format")
+	}
+	istable, err := cp.checktq()
+	if err != nil {
+		return err
+	}
+	conn, err := cp.acquire()
 	if err != nil {
 		return err
 	}
+	defer cp.release(conn)
+	query, err := cp.selectsql(istable)
+	if err != nil {
+		return err
+	}
+	oids, err := cp.oids(conn, query)
+	if err != nil {
+		return err
+	}
+	options, err := cp.options.options()
+	if err != nil {
+		return err
+	}
+	return conn.copyOutBinary(fmt.Sprintf("copy (%s\n) to stdout %s;", query, options), oids, f)
+}
+
+func (cp *copyprep) From(r io.Reader) error {
 	istable, err := cp.checktq()
 	if err != nil {
 		return err
 	}
-	if istable {
-		return cp.fromtable(conn, r)
+	if !istable {
+		return fmt.Errorf("copy: query not possible here")
+	}
+	conn, err := cp.acquire()
+	if err != nil {
+		return err
 	}
-	return fmt.Errorf("copy: query not possible here")
+	defer cp.release(conn)
+	return cp.fromtable(conn, r)
 }
 
 func (cp *copyprep) To(w io.Writer) error {
-	conn, err := cp.conn()
+	istable, err := cp.checktq()
 	if err != nil {
 		return err
 	}
-	istable, err := cp.checktq()
+	conn, err := cp.acquire()
 	if err != nil {
 		return err
 	}
+	defer cp.release(conn)
 	if istable {
 		return cp.totable(conn, w)
 	}
@@ -387,10 +567,25 @@ func (cp *copyprep) To(w io.Writer) error {
 
 func (*copyprep) cp() {}
 
+// Copy returns a CopyType that copies within tx.
 func (co *Copier) Copy(tx *Tx) CopyType {
 	return &copytype{options: co, tx: tx}
 }
 
+// CopyConn returns a CopyType that copies with conn. No transaction is
+// required.
+func (co *Copier) CopyConn(conn *Conn) CopyType {
+	return &copytype{options: co, conn: conn}
+}
+
+// CopyPool returns a CopyType that acquires a connection from pool for each
+// copy and releases it when the copy is done.
+func (co *Copier) CopyPool(pool *ConnPool) CopyType {
+	return &copytype{options: co, pool: pool}
+}
+
+// getmessage reads messages until one of type want. If the server sends an
+// error instead it reads until ReadyForQuery so conn can be used again.
 func getmessage(conn *Conn, want int) (*msgReader, error) {
 	for {
 		t, r, err := conn.rxMsg()
@@ -401,7 +596,9 @@ func getmessage(conn *Conn, want int) (*msgReader, error) {
 		case byte(want):
 			return r, nil
 		case errorResponse:
-			return nil, conn.rxErrorResponse(r)
+			err = conn.rxErrorResponse(r)
+			awaitready(conn)
+			return nil, err
 		default:
 			err = conn.processContextFreeMsg(t, r)
 			if err != nil {
@@ -410,3 +607,29 @@ func getmessage(conn *Conn, want int) (*msgReader, error) {
 		}
 	}
 }
+
+// awaitready reads messages until ReadyForQuery and returns the first error
+// the server sent.
+func awaitready(conn *Conn) error {
+	var pgErr error
+	for {
+		t, r, err := conn.rxMsg()
+		if err != nil {
+			return err
+		}
+		switch t {
+		case readyForQuery:
+			conn.rxReadyForQuery(r)
+			return pgErr
+		case commandComplete:
+		case errorResponse:
+			if err := conn.rxErrorResponse(r); pgErr == nil {
+				pgErr = err
+			}
+		default:
+			if err := conn.processContextFreeMsg(t, r); err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package pgx
+
+import (
+	"testing"
+)
+
+func TestCopierOptionsString(t *testing.T) {
+	tests := []struct {
+		option   CopierOptions
+		expected string
+	}{
+		{CSVFormat, `FORMAT 'csv'`},
+		{CopierDelimiter('\''), `DELIMITER ''''`},
+		{CopierDelimiter('\\'), `DELIMITER E'\\'`},
+		{CopierNullString(`'); drop table foo; --`), `NULL '''); drop table foo; --'`},
+		{CopierQuote('\''), `QUOTE ''''`},
+		{CopierEscape('\\'), `ESCAPE E'\\'`},
+		{CopierEncoding(`UTF8'`), `ENCODING 'UTF8'''`},
+		{CopierForceQuoteColumns{"*"}, `FORCE_QUOTE *`},
+		{CopierForceQuoteColumns{"a", `b") from stdin; --`}, `FORCE_QUOTE ("a", "b"") from stdin; --")`},
+		{CopierForceNotNullColumns{"a", "B"}, `FORCE_NOT_NULL ("a", "B")`},
+	}
+
+	for i, tt := range tests {
+		s, err := tt.option.string()
+		if err != nil {
+			t.Errorf("%d. Unexpected error: %v", i, err)
+			continue
+		}
+		if s != tt.expected {
+			t.Errorf("%d. Expected %s, got %s", i, tt.expected, s)
+		}
+	}
+}
+
+func TestCopierOptionsZeroByte(t *testing.T) {
+	tests := []CopierOptions{
+		CopierNullString("\x00"),
+		CopierForceQuoteColumns{"a\x00"},
+		CopierForceNotNullColumns{"a", "\x00b"},
+	}
+
+	for i, opt := range tests {
+		c, err := NewCopier(opt)
+		if err != nil {
+			t.Fatalf("%d. Unexpected error: %v", i, err)
+		}
+		if _, err := c.options(); err == nil {
+			t.Errorf("%d. Expected error for option containing a zero byte", i)
+		}
+	}
+}
+
+func TestCopyprepZeroByteIdentifier(t *testing.T) {
+	tests := []copyprep{
+		{table: Identifier{"foo\x00"}},
+		{table: Identifier{"public", "foo"}, columns: []string{"a", "b\x00"}},
+	}
+
+	for i, cp := range tests {
+		if _, err := cp.checktq(); err == nil {
+			t.Errorf("%d. Expected error for identifier containing a zero byte", i)
+		}
+	}
+}
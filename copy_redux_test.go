@@ -0,0 +1,171 @@
+package pgx_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/jackc/pgx"
+)
+
+func TestCopierQueryTo(t *testing.T) {
+	t.Parallel()
+
+	conn := mustConnect(t, *defaultConnConfig)
+	defer closeConn(t, conn)
+
+	copier, err := pgx.NewCopier(pgx.CSVFormat)
+	if err != nil {
+		t.Fatalf("Unexpected failure: %v", err)
+	}
+
+	tx, err := conn.Begin()
+	if err != nil {
+		t.Fatalf("conn.Begin failed: %v", err)
+	}
+	defer tx.Rollback()
+
+	buf := &bytes.Buffer{}
+	err = copier.Copy(tx).Query("select n, $1::text from generate_series(1, $2) n -- trailing comment", "o'clock", 2).To(buf)
+	if err != nil {
+		t.Fatalf("Unexpected failure: %v", err)
+	}
+
+	if expected := "1,o'clock\n2,o'clock\n"; buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+
+	ensureConnValid(t, conn)
+}
+
+func TestCopierTableFromTo(t *testing.T) {
+	t.Parallel()
+
+	conn := mustConnect(t, *defaultConnConfig)
+	defer closeConn(t, conn)
+
+	mustExec(t, conn, `create temporary table "copier test"(a int4, b text)`)
+
+	copier, err := pgx.NewCopier(pgx.CSVFormat)
+	if err != nil {
+		t.Fatalf("Unexpected failure: %v", err)
+	}
+
+	tx, err := conn.Begin()
+	if err != nil {
+		t.Fatalf("conn.Begin failed: %v", err)
+	}
+	defer tx.Rollback()
+
+	input := "1,foo\n2,bar\n"
+	if err := copier.Copy(tx).Table(pgx.Identifier{"copier test"}, "a", "b").From(bytes.NewBufferString(input)); err != nil {
+		t.Fatalf("Unexpected failure: %v", err)
+	}
+
+	buf := &bytes.Buffer{}
+	if err := copier.Copy(tx).Table(pgx.Identifier{"copier test"}).To(buf); err != nil {
+		t.Fatalf("Unexpected failure: %v", err)
+	}
+	if buf.String() != input {
+		t.Errorf("Expected %q, got %q", input, buf.String())
+	}
+
+	ensureConnValid(t, conn)
+}
+
+func TestCopierCopyConn(t *testing.T) {
+	t.Parallel()
+
+	conn := mustConnect(t, *defaultConnConfig)
+	defer closeConn(t, conn)
+
+	mustExec(t, conn, "create temporary table foo(a int4)")
+
+	copier, err := pgx.NewCopier(pgx.CSVFormat)
+	if err != nil {
+		t.Fatalf("Unexpected failure: %v", err)
+	}
+
+	if err := copier.CopyConn(conn).Table(pgx.Identifier{"foo"}).From(bytes.NewBufferString("1\n2\n")); err != nil {
+		t.Fatalf("Unexpected failure: %v", err)
+	}
+
+	buf := &bytes.Buffer{}
+	if err := copier.CopyConn(conn).Query("select a * $1 from foo order by a", 10).To(buf); err != nil {
+		t.Fatalf("Unexpected failure: %v", err)
+	}
+	if expected := "10\n20\n"; buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+
+	ensureConnValid(t, conn)
+}
+
+func TestCopierCopyPool(t *testing.T) {
+	t.Parallel()
+
+	// A single connection so the temporary table is visible to every copy
+	pool, err := pgx.NewConnPool(pgx.ConnPoolConfig{ConnConfig: *defaultConnConfig, MaxConnections: 1})
+	if err != nil {
+		t.Fatalf("Unable to create connection pool: %v", err)
+	}
+	defer pool.Close()
+
+	if _, err := pool.Exec("create temporary table foo(a int4)"); err != nil {
+		t.Fatalf("Unexpected failure: %v", err)
+	}
+
+	copier, err := pgx.NewCopier(pgx.CSVFormat)
+	if err != nil {
+		t.Fatalf("Unexpected failure: %v", err)
+	}
+
+	if err := copier.CopyPool(pool).Table(pgx.Identifier{"foo"}, "a").From(bytes.NewBufferString("1\n2\n")); err != nil {
+		t.Fatalf("Unexpected failure: %v", err)
+	}
+
+	buf := &bytes.Buffer{}
+	if err := copier.CopyPool(pool).Table(pgx.Identifier{"foo"}).To(buf); err != nil {
+		t.Fatalf("Unexpected failure: %v", err)
+	}
+	if expected := "1\n2\n"; buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+
+	if stat := pool.Stat(); stat.CurrentConnections != 1 || stat.AvailableConnections != 1 {
+		t.Errorf("Expected the connection to be released, got %+v", stat)
+	}
+}
+
+func TestCopierServerErrorLeavesConnValid(t *testing.T) {
+	t.Parallel()
+
+	conn := mustConnect(t, *defaultConnConfig)
+	defer closeConn(t, conn)
+
+	mustExec(t, conn, "create temporary table foo(a int4)")
+
+	copier, err := pgx.NewCopier(pgx.CSVFormat)
+	if err != nil {
+		t.Fatalf("Unexpected failure: %v", err)
+	}
+
+	if err := copier.CopyConn(conn).Table(pgx.Identifier{"missing_table"}).To(&bytes.Buffer{}); err == nil {
+		t.Error("Expected error copying from missing table")
+	}
+	ensureConnValid(t, conn)
+
+	if err := copier.CopyConn(conn).Table(pgx.Identifier{"foo"}, "missing_column").From(bytes.NewBufferString("1\n")); err == nil {
+		t.Error("Expected error copying to missing column")
+	}
+	ensureConnValid(t, conn)
+
+	if err := copier.CopyConn(conn).Table(pgx.Identifier{"foo"}).From(bytes.NewBufferString("not a number\n")); err == nil {
+		t.Error("Expected error copying invalid row")
+	}
+	ensureConnValid(t, conn)
+
+	if err := copier.CopyConn(conn).Query("selec 1").To(&bytes.Buffer{}); err == nil {
+		t.Error("Expected error copying query with syntax error")
+	}
+	ensureConnValid(t, conn)
+}
@@ -2,6 +2,7 @@ package pgx
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 )
 
@@ -86,21 +87,33 @@ func (ct *copyTo) waitForReaderDone() error {
 }
 
 func (ct *copyTo) run() (int, error) {
-	quotedTableName := quoteIdentifier(ct.tableName)
-	buf := &bytes.Buffer{}
-	for i, cn := range ct.columnNames {
-		if i != 0 {
-			buf.WriteString(", ")
-		}
-		buf.WriteString(quoteIdentifier(cn))
+	if err := validateIdentifier(ct.tableName); err != nil {
+		return 0, err
+	}
+	if err := validateIdentifier(ct.columnNames...); err != nil {
+		return 0, err
 	}
-	quotedColumnNames := buf.String()
+
+	quotedTableName := Identifier{ct.tableName}.Sanitize()
+	quotedColumnNames := sanitizeColumnNames(ct.columnNames)
 
 	ps, err := ct.conn.Prepare("", fmt.Sprintf("select %s from %s", quotedColumnNames, quotedTableName))
 	if err != nil {
 		return 0, err
 	}
 
+	// Values are sent in binary format so text format codecs cannot be used
+	for _, fd := range ps.FieldDescriptions {
+		if codec := connInfoOf(ct.conn).codec(fd.DataType); codec != nil && codec.FormatCode() != BinaryFormatCode {
+			return 0, fmt.Errorf("CopyTo cannot copy column %s of type %s with a text format codec", fd.Name, fd.DataTypeName)
+		}
+	}
+
+	oids := make([]Oid, len(ps.FieldDescriptions))
+	for i, fd := range ps.FieldDescriptions {
+		oids[i] = fd.DataType
+	}
+
 	err = ct.conn.sendSimpleQuery(fmt.Sprintf("copy %s ( %s ) from stdin binary;", quotedTableName, quotedColumnNames))
 	if err != nil {
 		return 0, err
@@ -114,11 +127,7 @@ func (ct *copyTo) run() (int, error) {
 	go ct.readUntilReadyForQuery()
 	defer ct.waitForReaderDone()
 
-	wbuf := newWriteBuf(ct.conn, copyData)
-
-	wbuf.WriteBytes([]byte("PGCOPY\n\377\r\n\000"))
-	wbuf.WriteInt32(0)
-	wbuf.WriteInt32(0)
+	cw := NewCopyBinaryWriter(&copyDataWriter{conn: ct.conn}, ct.conn, oids)
 
 	var sentCount int
 
@@ -129,41 +138,17 @@ func (ct *copyTo) run() (int, error) {
 		default:
 		}
 
-		if len(wbuf.buf) > 65536 {
-			wbuf.closeMsg()
-			_, err = ct.conn.conn.Write(wbuf.buf)
-			if err != nil {
-				ct.conn.die(err)
-				return 0, err
-			}
-
-			// Directly manipulate wbuf to reset to reuse the same buffer
-			wbuf.buf = wbuf.buf[0:5]
-			wbuf.buf[0] = copyData
-			wbuf.sizeIdx = 1
-		}
-
-		sentCount++
-
 		values, err := ct.rowSrc.Values()
 		if err != nil {
 			ct.cancelCopyIn()
 			return 0, err
 		}
-		if len(values) != len(ct.columnNames) {
+		if err = cw.WriteRow(values); err != nil {
 			ct.cancelCopyIn()
-			return 0, fmt.Errorf("expected %d values, got %d values", len(ct.columnNames), len(values))
+			return 0, err
 		}
 
-		wbuf.WriteInt16(int16(len(ct.columnNames)))
-		for i, val := range values {
-			err = Encode(wbuf, ps.FieldDescriptions[i].DataType, val)
-			if err != nil {
-				ct.cancelCopyIn()
-				return 0, err
-			}
-
-		}
+		sentCount++
 	}
 
 	if ct.rowSrc.Err() != nil {
@@ -171,9 +156,11 @@ func (ct *copyTo) run() (int, error) {
 		return 0, ct.rowSrc.Err()
 	}
 
-	wbuf.WriteInt16(-1) // terminate the copy stream
+	if err = cw.Close(); err != nil {
+		return 0, err
+	}
 
-	wbuf.startMsg(copyDone)
+	wbuf := newWriteBuf(ct.conn, copyDone)
 	wbuf.closeMsg()
 	_, err = ct.conn.conn.Write(wbuf.buf)
 	if err != nil {
@@ -239,3 +226,212 @@ func (c *Conn) CopyTo(tableName string, columnNames []string, rowSrc CopyToSourc
 
 	return ct.run()
 }
+
+// CopyToConflictAction is what CopyToEx does with rows that conflict with
+// existing rows.
+type CopyToConflictAction int
+
+const (
+	// CopyToConflictError fails the copy on the first conflicting row. This
+	// is the behavior of CopyTo.
+	CopyToConflictError CopyToConflictAction = iota
+	// CopyToConflictDoNothing skips conflicting rows.
+	CopyToConflictDoNothing
+	// CopyToConflictUpdate updates the conflicting existing rows.
+	CopyToConflictUpdate
+)
+
+// CopyToOptions is an option struct that can be passed to CopyToEx
+type CopyToOptions struct {
+	OnConflict CopyToConflictAction
+
+	// ConflictColumns are the columns of the unique index or constraint
+	// that conflicts are detected with. They are required for
+	// CopyToConflictUpdate.
+	ConflictColumns []string
+
+	// UpdateColumns are the columns CopyToConflictUpdate sets from the
+	// copied row. They default to the copied columns that are not
+	// ConflictColumns.
+	UpdateColumns []string
+}
+
+// CopyToResult is the result of CopyToEx
+type CopyToResult struct {
+	Copied   int // rows read from the CopyToSource
+	Inserted int // rows inserted into the table
+	Updated  int // existing rows updated
+}
+
+// copyToStagingTable is the temporary table CopyToEx copies into before
+// inserting into the target table.
+const copyToStagingTable = "pgx_copy_to_staging"
+
+// CopyToEx is CopyTo with options for handling rows that conflict with
+// existing rows. With CopyToConflictDoNothing or CopyToConflictUpdate the
+// rows are copied into a temporary staging table with the types of the
+// copied columns and then inserted into tableName with INSERT ... ON
+// CONFLICT. This requires PostgreSQL 9.5 or later. When c is not in a
+// transaction both steps run in a transaction CopyToEx starts; otherwise they
+// run in the current transaction.
+//
+// Rows that conflict with each other are not handled; with
+// CopyToConflictUpdate PostgreSQL returns an error for them.
+func (c *Conn) CopyToEx(tableName string, columnNames []string, rowSrc CopyToSource, opts *CopyToOptions) (CopyToResult, error) {
+	if opts == nil || opts.OnConflict == CopyToConflictError {
+		n, err := c.CopyTo(tableName, columnNames, rowSrc)
+		if err != nil {
+			return CopyToResult{}, err
+		}
+		return CopyToResult{Copied: n, Inserted: n}, nil
+	}
+
+	insertSQL, err := copyToInsertSQL(tableName, columnNames, opts)
+	if err != nil {
+		return CopyToResult{}, err
+	}
+
+	if c.TxStatus == 'I' {
+		tx, err := c.Begin()
+		if err != nil {
+			return CopyToResult{}, err
+		}
+		defer tx.Rollback()
+
+		result, err := c.copyToStaged(tableName, columnNames, rowSrc, insertSQL)
+		if err != nil {
+			return CopyToResult{}, err
+		}
+		if err := tx.Commit(); err != nil {
+			return CopyToResult{}, err
+		}
+		return result, nil
+	}
+
+	return c.copyToStaged(tableName, columnNames, rowSrc, insertSQL)
+}
+
+// copyToInsertSQL returns the statement that inserts the rows of the staging
+// table into tableName and counts the inserted and updated rows.
+func copyToInsertSQL(tableName string, columnNames []string, opts *CopyToOptions) (string, error) {
+	for _, names := range [][]string{{tableName}, columnNames, opts.ConflictColumns, opts.UpdateColumns} {
+		if err := validateIdentifier(names...); err != nil {
+			return "", err
+		}
+	}
+
+	var conflictTarget string
+	if len(opts.ConflictColumns) > 0 {
+		conflictTarget = fmt.Sprintf("(%s) ", sanitizeColumnNames(opts.ConflictColumns))
+	}
+
+	var action string
+	switch opts.OnConflict {
+	case CopyToConflictDoNothing:
+		action = "do nothing"
+	case CopyToConflictUpdate:
+		if len(opts.ConflictColumns) == 0 {
+			return "", errors.New("CopyToConflictUpdate requires ConflictColumns")
+		}
+
+		updateColumns := opts.UpdateColumns
+		if len(updateColumns) == 0 {
+			conflictColumns := make(map[string]struct{}, len(opts.ConflictColumns))
+			for _, cn := range opts.ConflictColumns {
+				conflictColumns[cn] = struct{}{}
+			}
+			for _, cn := range columnNames {
+				if _, ok := conflictColumns[cn]; !ok {
+					updateColumns = append(updateColumns, cn)
+				}
+			}
+		}
+		if len(updateColumns) == 0 {
+			return "", errors.New("CopyToConflictUpdate requires a column to update")
+		}
+
+		buf := &bytes.Buffer{}
+		buf.WriteString("do update set ")
+		for i, cn := range updateColumns {
+			if i != 0 {
+				buf.WriteString(", ")
+			}
+			cn = Identifier{cn}.Sanitize()
+			fmt.Fprintf(buf, "%s = excluded.%s", cn, cn)
+		}
+		action = buf.String()
+	default:
+		return "", fmt.Errorf("Unknown CopyToConflictAction: %d", opts.OnConflict)
+	}
+
+	// xmax is 0 for a row version created by an insert so it tells inserted
+	// and updated rows apart.
+	columns := sanitizeColumnNames(columnNames)
+	return fmt.Sprintf(`with upserted as (
+	insert into %s (%s) select %s from %s
+	on conflict %s%s
+	returning xmax = 0 as inserted
+)
+select count(*) filter (where inserted), count(*) filter (where not inserted) from upserted`,
+		Identifier{tableName}.Sanitize(), columns, columns, Identifier{copyToStagingTable}.Sanitize(), conflictTarget, action), nil
+}
+
+func sanitizeColumnNames(columnNames []string) string {
+	buf := &bytes.Buffer{}
+	for i, cn := range columnNames {
+		if i != 0 {
+			buf.WriteString(", ")
+		}
+		buf.WriteString(Identifier{cn}.Sanitize())
+	}
+	return buf.String()
+}
+
+// copyToStaged copies the rows into a staging table and inserts them into
+// tableName with insertSQL.
+func (c *Conn) copyToStaged(tableName string, columnNames []string, rowSrc CopyToSource, insertSQL string) (CopyToResult, error) {
+	staging := Identifier{copyToStagingTable}.Sanitize()
+	columns := sanitizeColumnNames(columnNames)
+
+	// Unlike create table ... (like ...) this does not copy not null
+	// constraints of columns that are not copied.
+	_, err := c.Exec(fmt.Sprintf("create temporary table %s on commit drop as select %s from %s with no data", staging, columns, Identifier{tableName}.Sanitize()))
+	if err != nil {
+		return CopyToResult{}, err
+	}
+
+	result, err := c.copyToStagedInsert(columnNames, rowSrc, insertSQL)
+
+	// The table must be dropped before the end of the transaction so it can
+	// be created again by a later CopyToEx. It cannot be dropped in a failed
+	// transaction, but there the rollback drops it.
+	if c.TxStatus == 'T' {
+		if _, dropErr := c.Exec("drop table " + staging); dropErr != nil && err == nil {
+			err = dropErr
+		}
+	}
+	if err != nil {
+		return CopyToResult{}, err
+	}
+
+	return result, nil
+}
+
+// copyToStagedInsert copies the rows into the staging table and inserts them
+// with insertSQL.
+func (c *Conn) copyToStagedInsert(columnNames []string, rowSrc CopyToSource, insertSQL string) (CopyToResult, error) {
+	var result CopyToResult
+	var err error
+	result.Copied, err = c.CopyTo(copyToStagingTable, columnNames, rowSrc)
+	if err != nil {
+		return CopyToResult{}, err
+	}
+
+	var inserted, updated int64
+	if err := c.QueryRow(insertSQL).Scan(&inserted, &updated); err != nil {
+		return CopyToResult{}, err
+	}
+	result.Inserted, result.Updated = int(inserted), int(updated)
+
+	return result, nil
+}
@@ -0,0 +1,46 @@
+package pgx
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCopyToInsertSQL(t *testing.T) {
+	tests := []struct {
+		opts     CopyToOptions
+		expected string
+	}{
+		{CopyToOptions{OnConflict: CopyToConflictDoNothing}, `on conflict do nothing`},
+		{CopyToOptions{OnConflict: CopyToConflictDoNothing, ConflictColumns: []string{"a"}}, `on conflict ("a") do nothing`},
+		{CopyToOptions{OnConflict: CopyToConflictUpdate, ConflictColumns: []string{"a"}}, `on conflict ("a") do update set "b" = excluded."b", "c" = excluded."c"`},
+		{CopyToOptions{OnConflict: CopyToConflictUpdate, ConflictColumns: []string{"a", "b"}, UpdateColumns: []string{"c"}}, `on conflict ("a", "b") do update set "c" = excluded."c"`},
+	}
+
+	for i, tt := range tests {
+		sql, err := copyToInsertSQL("foo", []string{"a", "b", "c"}, &tt.opts)
+		if err != nil {
+			t.Errorf("%d. Unexpected failure: %v", i, err)
+			continue
+		}
+		if !strings.Contains(sql, `insert into "foo" ("a", "b", "c") select "a", "b", "c" from "pgx_copy_to_staging"`) {
+			t.Errorf("%d. Unexpected insert: %s", i, sql)
+		}
+		if !strings.Contains(sql, tt.expected) {
+			t.Errorf("%d. Expected %s in %s", i, tt.expected, sql)
+		}
+	}
+
+	errorTests := []CopyToOptions{
+		{OnConflict: CopyToConflictUpdate},
+		{OnConflict: CopyToConflictUpdate, ConflictColumns: []string{"a", "b", "c"}},
+		{OnConflict: CopyToConflictAction(42)},
+		{OnConflict: CopyToConflictDoNothing, ConflictColumns: []string{"a\x00"}},
+		{OnConflict: CopyToConflictUpdate, ConflictColumns: []string{"a"}, UpdateColumns: []string{"\x00b"}},
+	}
+
+	for i, opts := range errorTests {
+		if _, err := copyToInsertSQL("foo", []string{"a", "b", "c"}, &opts); err == nil {
+			t.Errorf("%d. Expected error for %+v", i, opts)
+		}
+	}
+}
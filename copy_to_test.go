@@ -63,6 +63,62 @@ func TestConnCopyToSmall(t *testing.T) {
 	ensureConnValid(t, conn)
 }
 
+func TestConnCopyToTimeTypes(t *testing.T) {
+	t.Parallel()
+
+	conn := mustConnect(t, *defaultConnConfig)
+	defer closeConn(t, conn)
+
+	mustExec(t, conn, `create temporary table foo(
+		a interval,
+		b time,
+		c timetz,
+		d date[]
+	)`)
+
+	inputRows := [][]interface{}{
+		{
+			pgx.Interval{Microseconds: 3723000004, Days: 5, Months: 14},
+			pgx.TimeOfDay{Microseconds: 45296789000},
+			pgx.TimeOfDay{Microseconds: 45296789000, Offset: -7 * 3600},
+			[]time.Time{time.Date(2000, 1, 1, 0, 0, 0, 0, time.Local), time.Date(2015, 6, 30, 0, 0, 0, 0, time.Local)},
+		},
+		{nil, nil, nil, nil},
+	}
+
+	copyCount, err := conn.CopyTo("foo", []string{"a", "b", "c", "d"}, pgx.CopyToRows(inputRows))
+	if err != nil {
+		t.Errorf("Unexpected error for CopyTo: %v", err)
+	}
+	if copyCount != len(inputRows) {
+		t.Errorf("Expected CopyTo to return %d copied rows, but got %d", len(inputRows), copyCount)
+	}
+
+	rows, err := conn.Query("select * from foo")
+	if err != nil {
+		t.Errorf("Unexpected error for Query: %v", err)
+	}
+
+	var outputRows [][]interface{}
+	for rows.Next() {
+		row, err := rows.Values()
+		if err != nil {
+			t.Errorf("Unexpected error for rows.Values(): %v", err)
+		}
+		outputRows = append(outputRows, row)
+	}
+
+	if rows.Err() != nil {
+		t.Errorf("Unexpected error for rows.Err(): %v", rows.Err())
+	}
+
+	if !reflect.DeepEqual(inputRows, outputRows) {
+		t.Errorf("Input rows and output rows do not equal: %v -> %v", inputRows, outputRows)
+	}
+
+	ensureConnValid(t, conn)
+}
+
 func TestConnCopyToLarge(t *testing.T) {
 	t.Parallel()
 
@@ -426,3 +482,209 @@ func TestConnCopyToCopyToSourceErrorEnd(t *testing.T) {
 
 	ensureConnValid(t, conn)
 }
+
+func TestConnCopyToExConflict(t *testing.T) {
+	t.Parallel()
+
+	conn := mustConnect(t, *defaultConnConfig)
+	defer closeConn(t, conn)
+
+	mustExec(t, conn, `create temporary table foo(
+		id serial primary key,
+		a int4 not null unique,
+		b text
+	)`)
+	mustExec(t, conn, `insert into foo(a, b) values (1, 'old'), (2, 'old')`)
+
+	inputRows := [][]interface{}{{int32(2), "new"}, {int32(3), "new"}}
+
+	result, err := conn.CopyToEx("foo", []string{"a", "b"}, pgx.CopyToRows(inputRows), &pgx.CopyToOptions{OnConflict: pgx.CopyToConflictDoNothing})
+	if err != nil {
+		t.Fatalf("Unexpected error for CopyToEx: %v", err)
+	}
+	if expected := (pgx.CopyToResult{Copied: 2, Inserted: 1}); result != expected {
+		t.Errorf("Expected %+v, got %+v", expected, result)
+	}
+
+	inputRows = [][]interface{}{{int32(1), "new"}, {int32(4), "new"}}
+	result, err = conn.CopyToEx("foo", []string{"a", "b"}, pgx.CopyToRows(inputRows), &pgx.CopyToOptions{
+		OnConflict:      pgx.CopyToConflictUpdate,
+		ConflictColumns: []string{"a"},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error for CopyToEx: %v", err)
+	}
+	if expected := (pgx.CopyToResult{Copied: 2, Inserted: 1, Updated: 1}); result != expected {
+		t.Errorf("Expected %+v, got %+v", expected, result)
+	}
+
+	rows, err := conn.Query("select a, b from foo order by a")
+	if err != nil {
+		t.Fatalf("Unexpected error for Query: %v", err)
+	}
+	var outputRows [][]interface{}
+	for rows.Next() {
+		row, err := rows.Values()
+		if err != nil {
+			t.Errorf("Unexpected error for rows.Values(): %v", err)
+		}
+		outputRows = append(outputRows, row)
+	}
+	if rows.Err() != nil {
+		t.Errorf("Unexpected error for rows.Err(): %v", rows.Err())
+	}
+
+	expectedRows := [][]interface{}{{int32(1), "new"}, {int32(2), "old"}, {int32(3), "new"}, {int32(4), "new"}}
+	if !reflect.DeepEqual(expectedRows, outputRows) {
+		t.Errorf("Expected %v, got %v", expectedRows, outputRows)
+	}
+
+	ensureConnValid(t, conn)
+}
+
+func TestConnCopyToExFailureRollsBack(t *testing.T) {
+	t.Parallel()
+
+	conn := mustConnect(t, *defaultConnConfig)
+	defer closeConn(t, conn)
+
+	mustExec(t, conn, `create temporary table foo(a int4 primary key, b int4 not null)`)
+
+	// Conflicting rows within the copied data cannot be updated
+	inputRows := [][]interface{}{{int32(1), int32(1)}, {int32(1), int32(2)}}
+	_, err := conn.CopyToEx("foo", []string{"a", "b"}, pgx.CopyToRows(inputRows), &pgx.CopyToOptions{
+		OnConflict:      pgx.CopyToConflictUpdate,
+		ConflictColumns: []string{"a"},
+	})
+	if err == nil {
+		t.Fatal("Expected error for CopyToEx")
+	}
+	if conn.TxStatus != 'I' {
+		t.Errorf("Expected the transaction to be rolled back, but TxStatus is %c", conn.TxStatus)
+	}
+
+	var n int64
+	if err := conn.QueryRow("select count(*) from foo").Scan(&n); err != nil {
+		t.Fatalf("Unexpected failure: %v", err)
+	}
+	if n != 0 {
+		t.Errorf("Expected no rows, got %d", n)
+	}
+
+	ensureConnValid(t, conn)
+}
+
+// textCodec is a text format codec for the text type.
+type textCodec struct{}
+
+func (textCodec) FormatCode() int16 { return pgx.TextFormatCode }
+
+func (textCodec) Encode(w *pgx.WriteBuf, oid pgx.Oid, value interface{}) error {
+	s, ok := value.(string)
+	if !ok {
+		return fmt.Errorf("Cannot encode %T", value)
+	}
+	w.WriteInt32(int32(len(s)))
+	w.WriteBytes([]byte(s))
+	return nil
+}
+
+func (textCodec) Decode(vr *pgx.ValueReader, d interface{}) error {
+	s, ok := d.(*string)
+	if !ok {
+		return fmt.Errorf("Cannot decode into %T", d)
+	}
+	if vr.Len() == -1 {
+		return fmt.Errorf("Cannot decode null into %T", d)
+	}
+	*s = vr.ReadString(vr.Len())
+	return vr.Err()
+}
+
+func (c textCodec) DecodeValue(vr *pgx.ValueReader) (interface{}, error) {
+	var s string
+	err := c.Decode(vr, &s)
+	return s, err
+}
+
+func TestTxCopyToExClientErrorDropsStagingTable(t *testing.T) {
+	t.Parallel()
+
+	conn := mustConnect(t, *defaultConnConfig)
+	defer closeConn(t, conn)
+
+	// CopyTo rejects the text column before it starts copying so the
+	// transaction is not aborted
+	if err := conn.ConnInfo().RegisterCodec("text", textCodec{}); err != nil {
+		t.Fatalf("Unable to register codec: %v", err)
+	}
+
+	mustExec(t, conn, `create temporary table foo(a int4 primary key, b text)`)
+
+	tx, err := conn.Begin()
+	if err != nil {
+		t.Fatalf("conn.Begin failed: %v", err)
+	}
+	defer tx.Rollback()
+
+	opts := &pgx.CopyToOptions{OnConflict: pgx.CopyToConflictDoNothing}
+	inputRows := [][]interface{}{{int32(1), "a"}}
+	if _, err := tx.CopyToEx("foo", []string{"a", "b"}, pgx.CopyToRows(inputRows), opts); err == nil {
+		t.Fatal("Expected error for CopyToEx")
+	}
+	if conn.TxStatus != 'T' {
+		t.Fatalf("Expected the transaction to still be usable, but TxStatus is %c", conn.TxStatus)
+	}
+
+	inputRows = [][]interface{}{{int32(1)}}
+	result, err := tx.CopyToEx("foo", []string{"a"}, pgx.CopyToRows(inputRows), opts)
+	if err != nil {
+		t.Fatalf("Unexpected error for CopyToEx: %v", err)
+	}
+	if expected := (pgx.CopyToResult{Copied: 1, Inserted: 1}); result != expected {
+		t.Errorf("Expected %+v, got %+v", expected, result)
+	}
+
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("tx.Commit failed: %v", err)
+	}
+
+	ensureConnValid(t, conn)
+}
+
+func TestTxCopyToEx(t *testing.T) {
+	t.Parallel()
+
+	conn := mustConnect(t, *defaultConnConfig)
+	defer closeConn(t, conn)
+
+	mustExec(t, conn, `create temporary table foo(a int4 primary key)`)
+
+	tx, err := conn.Begin()
+	if err != nil {
+		t.Fatalf("conn.Begin failed: %v", err)
+	}
+
+	inputRows := [][]interface{}{{int32(1)}, {int32(1)}}
+	result, err := tx.CopyToEx("foo", []string{"a"}, pgx.CopyToRows(inputRows), &pgx.CopyToOptions{OnConflict: pgx.CopyToConflictDoNothing})
+	if err != nil {
+		t.Fatalf("Unexpected error for CopyToEx: %v", err)
+	}
+	if expected := (pgx.CopyToResult{Copied: 2, Inserted: 1}); result != expected {
+		t.Errorf("Expected %+v, got %+v", expected, result)
+	}
+
+	// The rows were inserted in tx
+	if err := tx.Rollback(); err != nil {
+		t.Fatalf("tx.Rollback failed: %v", err)
+	}
+	var n int64
+	if err := conn.QueryRow("select count(*) from foo").Scan(&n); err != nil {
+		t.Fatalf("Unexpected failure: %v", err)
+	}
+	if n != 0 {
+		t.Errorf("Expected no rows, got %d", n)
+	}
+
+	ensureConnValid(t, conn)
+}
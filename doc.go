@@ -50,6 +50,21 @@ pgx also implements QueryRow in the same style as database/sql.
         return err
     }
 
+ScanStruct scans a row into a struct. Columns are matched to fields by a `db`
+tag or by the field name in snake_case. Select scans every row into a slice.
+
+    type Widget struct {
+        ID     int32
+        Name   string
+        Weight *int64 `db:"weight_kg"` // nil when NULL
+    }
+
+    var widgets []Widget
+    err := conn.Select(&widgets, "select id, name, weight_kg from widgets")
+    if err != nil {
+        return err
+    }
+
 Use Exec to execute a query that does not return a result set.
 
     commandTag, err := conn.Exec("delete from widgets where id=$1", 42)
@@ -60,6 +75,41 @@ Use Exec to execute a query that does not return a result set.
         return errors.New("No row found to delete")
     }
 
+Context Support
+
+ExecContext, QueryContext, QueryRowContext and BeginContext accept a
+context.Context. If the context is canceled or its deadline passes while the
+query is in progress, a cancel request is sent to the server, the connection
+is closed, and ctx.Err() is returned. ConnPool additionally provides
+AcquireContext to bound the time spent waiting for an available connection.
+
+    ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+    defer cancel()
+
+    rows, err := conn.QueryContext(ctx, "select * from widgets")
+
+Batch Queries
+
+A Batch queues multiple queries that are sent to the server together instead
+of paying a network round trip per query. Results are read in order from the
+BatchResults returned by SendBatch, which must be closed.
+
+    batch := &pgx.Batch{}
+    batch.Queue("insert into widgets(name) values($1)", "foo")
+    batch.Queue("select count(*) from widgets")
+
+    br := conn.SendBatch(batch)
+    defer br.Close()
+
+    if _, err := br.ExecResults(); err != nil {
+        return err
+    }
+
+    var count int64
+    if err := br.QueryRowResults().Scan(&count); err != nil {
+        return err
+    }
+
 Connection Pool
 
 Connection pool usage is explicit and configurable. In pgx, a connection can
@@ -81,6 +131,22 @@ releasing connections when you do not need that level of control.
         return err
     }
 
+By default pooled connections are kept open until they are found to be dead
+when released. MaxConnLifetime, MaxConnIdleTime, and MinIdleConnections in
+ConnPoolConfig enable a background health check that closes connections that
+are too old or have been unused for too long, keeps a minimum number of
+connections ready, and pings idle connections so dead ones are discarded
+before they are acquired. This is useful behind load balancers and when the
+server can fail over.
+
+    config := pgx.ConnPoolConfig{
+        ConnConfig:         connConfig,
+        MaxConnections:     10,
+        MaxConnLifetime:    time.Hour,
+        MaxConnIdleTime:    10 * time.Minute,
+        MinIdleConnections: 2,
+    }
+
 Base Type Mapping
 
 pgx maps between all common base types directly between Go and PostgreSQL. In
@@ -135,6 +201,15 @@ and the equivalent PostgreSQL array type. Go slices of native types do not
 support nulls, so if a PostgreSQL array that contains a null is read into a
 native Go slice an error will occur.
 
+Arrays that contain nulls can be read into slices of Null* types (e.g.
+[]NullInt32) or slices of pointers (e.g. []*int32). Multi-dimensional arrays
+map to nested slices with one level of nesting per dimension (e.g. [][]int32
+for int[][]). Both are also supported as query arguments. Go slices always
+start at 0, so array lower bounds are not preserved.
+
+    var matrix [][]int32
+    err := conn.QueryRow("select '{{1,2},{3,4}}'::int[]").Scan(&matrix)
+
 Hstore Mapping
 
 pgx includes an Hstore type and a NullHstore type. Hstore is simply a
@@ -152,11 +227,103 @@ pgx encodes from net.IPNet to and from inet and cidr PostgreSQL types. In
 addition, as a convenience pgx will encode from a net.IP; it will assume a /32
 netmask for IPv4 and a /128 for IPv6.
 
+Interval and Time Mapping
+
+pgx includes an Interval type that holds the separate months, days, and
+microseconds components of a PostgreSQL interval. An interval can also be
+encoded from and scanned into a time.Duration. Scanning an interval that
+includes months into a time.Duration is an error as months do not have a fixed
+length.
+
+pgx includes a TimeOfDay type for the time and timetz types. timetz also uses
+the TimeOfDay Offset. A time.Time may also be used as an argument for time and
+timetz. NullInterval and NullTimeOfDay follow the Null* pattern.
+
+date[] maps to []time.Time.
+
+UUID Mapping
+
+pgx includes a UUID type that is encoded in the binary format. A [16]byte may
+also be used. A uuid can be scanned into a string, in which case it is
+formatted in the standard xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx form. uuid[]
+maps to []UUID, [][16]byte, and []string. NullUUID follows the Null* pattern.
+
+String arguments and types implementing driver.Valuer by returning a string
+are sent as text.
+
+Range Mapping
+
+pgx includes a Range type for the built-in range types int4range, int8range,
+numrange, tsrange, tstzrange, and daterange. The bounds are int32, int64,
+Numeric, or time.Time depending on the range type. Range also records whether
+each bound is inclusive or unbounded and whether the range is empty. NullRange
+follows the Null* pattern.
+
+    var during pgx.Range
+    err := conn.QueryRow("select during from reservations where id=$1", 42).Scan(&during)
+
+Numeric Mapping
+
+pgx maps numeric to and from *big.Rat and *big.Int without loss of precision.
+Scanning a numeric with a fractional part into a *big.Int is an error. A numeric
+may also be scanned into a float64 or string. pgx includes a Numeric type that
+additionally supports NaN, Infinity, -Infinity, and null values. numeric[] maps
+to []*big.Rat and []Numeric.
+
+    var price big.Rat
+    err := conn.QueryRow("select price from products where id=$1", 42).Scan(&price)
+
+Only *big.Rat, *big.Int, Numeric, and their slices are sent in the binary
+format. Other values such as strings are sent as text so types like decimals
+implementing driver.Valuer continue to work.
+
+Composite Type Mapping
+
+Composite types, such as those created with CREATE TYPE ... AS or the row type
+of a table, must be registered on a connection with RegisterCompositeType
+before they can be used. Registration loads the attributes of the type from
+the database. A registered composite type maps to a struct whose fields are
+matched to attributes in the same way as ScanStruct, or to a []interface{} of
+the attributes in order. Arrays of composite types map to slices and composite
+types may be nested. Anonymous records can be read into a []interface{}
+without registration.
+
+    type Address struct {
+        Street string
+        City   string
+    }
+
+    _, err := conn.RegisterCompositeType("address")
+    if err != nil {
+        return err
+    }
+
+    var addresses []Address
+    err = conn.QueryRow("select addresses from customers where id=$1", 42).Scan(&addresses)
+
+Enum Mapping
+
+Enum values are sent and received as text, so they can be used with string or
+any type whose underlying type is string. Arrays of enum types that exist when
+a connection is established map to slices of such types. Register an enum type
+with RegisterEnumType to validate values against its labels and to use enum
+types created after the connection was established.
+
+    type Mood string
+
+    _, err := conn.RegisterEnumType("mood")
+    if err != nil {
+        return err
+    }
+
+    var moods []Mood
+    err = conn.QueryRow("select moods from people where id=$1", 42).Scan(&moods)
+
 Custom Type Support
 
 pgx includes support for the common data types like integers, floats, strings,
 dates, and times that have direct mappings between Go and SQL. Support can be
-added for additional types like point, hstore, numeric, etc. that do not have
+added for additional types like point, hstore, etc. that do not have
 direct mappings in Go by the types implementing ScannerPgx and Encoder.
 
 Custom types can support text or binary formats. Binary format can provide a
@@ -173,11 +340,24 @@ Note that the type is referred to by name, not by OID. This is because custom
 PostgreSQL types like hstore will have different OIDs on different servers. When
 pgx establishes a connection it queries the pg_type table for all types. It then
 matches the names in DefaultTypeFormats with the returned OIDs and stores it in
-Conn.PgTypes.
+the ConnInfo of the connection.
 
 See example_custom_type_test.go for an example of a custom type for the
 PostgreSQL point type.
 
+Type Registry
+
+The data types of a connection, including types from extensions, are held in
+its ConnInfo. A Codec registered for a data type by name encodes and decodes
+all values of the type that are not strings, []byte, Encoders, or Scanners in
+Query, Exec, Scan, Values, and CopyTo. Codecs in ConnConfig.Codecs are
+registered on every connection, including every connection of a ConnPool.
+
+        config.Codecs = map[string]pgx.Codec{"geometry": geometryCodec{}}
+
+Values of the type are requested in the format of its Codec. Arrays of the
+type can be used as well if the Codec uses the binary format.
+
 pgx also includes support for custom types implementing the database/sql.Scanner
 and database/sql/driver.Valuer interfaces.
 
@@ -232,6 +412,37 @@ implement CopyToSource to avoid buffering the entire data set in memory.
 
 CopyTo can be faster than an insert with as few as 5 rows.
 
+CopyTo fails on the first row that conflicts with an existing row. CopyToEx
+can instead skip or update conflicting rows. It copies into a temporary
+staging table and inserts from it with INSERT ... ON CONFLICT.
+
+    result, err := conn.CopyToEx(
+        "people",
+        []string{"id", "first_name", "last_name"},
+        pgx.CopyToRows(rows),
+        &pgx.CopyToOptions{OnConflict: pgx.CopyToConflictUpdate, ConflictColumns: []string{"id"}},
+    )
+
+Use CopyFromQuery to efficiently read the results of a query using the copy
+protocol. Each row is passed to a CopyFromSink.
+
+CopyBinaryWriter and CopyBinaryReader write and read the PostgreSQL binary copy
+format over any io.Writer or io.Reader using the same encoding as query
+arguments and results. They do not need a connection so copy data can be
+written to a file and loaded later. A Copier using BinaryFormat can copy typed
+rows with FromSource and ToRows.
+
+    err := copier.CopyConn(conn).Query("select id, name from people").ToRows(func(rows *pgx.CopyBinaryReader) error {
+        for rows.Next() {
+            var id int32
+            var name string
+            if err := rows.Scan(&id, &name); err != nil {
+                return err
+            }
+        }
+        return rows.Err()
+    })
+
 Listen and Notify
 
 pgx can listen to the PostgreSQL notification system with the
@@ -247,6 +458,27 @@ notification.
         // do something with notification
     }
 
+ConnConfig.OnNotification is called with every notification as soon as it is
+received, even while other queries are running.
+
+A ConnPool can create a Listener that holds a dedicated connection and
+delivers notifications on a Go channel. If the connection is lost the Listener
+reconnects and listens on all of its channels again.
+
+    listener, err := pool.NewListener()
+    if err != nil {
+        return err
+    }
+    defer listener.Close()
+
+    if err := listener.Listen("channelname"); err != nil {
+        return err
+    }
+
+    for notification := range listener.Notifications() {
+        // do something with notification
+    }
+
 TLS
 
 The pgx ConnConfig struct has a TLSConfig field. If this field is
@@ -0,0 +1,110 @@
+package pgx
+
+import (
+	"fmt"
+)
+
+// EnumType describes an enum type registered with RegisterEnumType.
+type EnumType struct {
+	Name     string
+	Oid      Oid
+	ArrayOid Oid      // 0 if the type has no array type
+	Labels   []string // in sort order
+}
+
+// RegisterEnumType loads the labels of the enum type name. name may be schema
+// qualified. Values of a registered enum type and its array type are
+// validated against the labels when they are encoded and decoded.
+//
+// Enum values map to string or any type whose underlying type is string (e.g.
+// type Mood string). Arrays of enums map to slices of such types. Arrays of
+// enums found when the connection was established can be used without
+// registering the enum type, but their values are not validated.
+//
+// Registrations belong to the connection. With a ConnPool, register enum types
+// in ConnPoolConfig.AfterConnect.
+func (c *Conn) RegisterEnumType(name string) (*EnumType, error) {
+	et := &EnumType{}
+
+	var typtype Char
+	var arrayName Name
+	err := c.QueryRow(`select t.oid, t.typname, t.typtype, t.typarray, coalesce(a.typname, '')
+from pg_type t
+left join pg_type a on a.oid=t.typarray
+where t.oid=$1::regtype::oid`, name).Scan(&et.Oid, &et.Name, &typtype, &et.ArrayOid, &arrayName)
+	if err != nil {
+		return nil, err
+	}
+	if typtype != 'e' {
+		return nil, fmt.Errorf("%s is not an enum type", et.Name)
+	}
+
+	rows, err := c.Query("select enumlabel from pg_enum where enumtypid=$1 order by enumsortorder", et.Oid)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var label string
+		rows.Scan(&label)
+		et.Labels = append(et.Labels, label)
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+
+	c.connInfo.enumOids[et.Oid] = struct{}{}
+	c.connInfo.enumTypes[et.Oid] = et
+	c.connInfo.RegisterDataType(DataType{Name: et.Name, Oid: et.Oid, FormatCode: TextFormatCode})
+	if et.ArrayOid != 0 {
+		c.connInfo.RegisterDataType(DataType{Name: string(arrayName), Oid: et.ArrayOid, ElementOid: et.Oid, FormatCode: BinaryFormatCode})
+	}
+	c.PgTypes = c.connInfo.pgTypes()
+
+	return et, nil
+}
+
+// EnumType returns the enum type with oid registered with RegisterEnumType.
+func (c *Conn) EnumType(oid Oid) (*EnumType, bool) {
+	et, ok := connInfoOf(c).enumTypes[oid]
+	return et, ok
+}
+
+func (et *EnumType) hasLabel(s string) bool {
+	for _, label := range et.Labels {
+		if label == s {
+			return true
+		}
+	}
+	return false
+}
+
+// validateEnumLabel returns an error if oid is an enum type registered on c,
+// which may be nil, and s is not one of its labels.
+func validateEnumLabel(c *Conn, oid Oid, s string) error {
+	if et, ok := connInfoOf(c).enumTypes[oid]; ok && !et.hasLabel(s) {
+		return SerializationError(fmt.Sprintf("%q is not a valid label for enum type %s", s, et.Name))
+	}
+	return nil
+}
+
+// isBinaryEnumArray reports whether vr reads an array of an enum type in
+// binary format. Enum arrays are read in binary format but scan into a string
+// in the text format.
+func isBinaryEnumArray(vr *ValueReader) bool {
+	_, ok := enumArrayElementOid(vr.conn, vr.Type().DataType)
+	return ok && vr.Type().FormatCode == BinaryFormatCode
+}
+
+// enumArrayElementOid returns the element type of oid if it is an array of an
+// enum type found by loadPgTypes or registered on c, which may be nil.
+func enumArrayElementOid(c *Conn, oid Oid) (Oid, bool) {
+	ci := connInfoOf(c)
+	elOid, ok := ci.arrayElementOid(oid)
+	if !ok {
+		return 0, false
+	}
+	if _, ok := ci.enumOids[elOid]; !ok {
+		return 0, false
+	}
+	return elOid, true
+}
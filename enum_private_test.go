@@ -0,0 +1,86 @@
+package pgx
+
+import (
+	"reflect"
+	"testing"
+)
+
+type enumMood string
+
+func TestEnumArrayRoundTrip(t *testing.T) {
+	c := newTestConn()
+	happy := enumMood("happy")
+
+	tests := []struct {
+		oid   Oid
+		value interface{}
+	}{
+		{testMoodArrayOid, []string{"sad", "happy"}},
+		{testMoodArrayOid, []enumMood{"ok"}},
+		{testMoodArrayOid, []*enumMood{&happy, nil}},
+		{testMoodArrayOid, [][]string{{"sad"}, {"ok"}}},
+		{testColorArrayOid, []string{"red", "green"}},
+	}
+
+	for i, tt := range tests {
+		result, err := roundTrip(c, tt.oid, tt.value)
+		if err != nil {
+			t.Errorf("%d. Unexpected error: %v", i, err)
+			continue
+		}
+		if !reflect.DeepEqual(result, tt.value) {
+			t.Errorf("%d. Decoded %v, expected %v", i, result, tt.value)
+		}
+	}
+}
+
+func TestEnumArrayScanIntoString(t *testing.T) {
+	c := newTestConn()
+	red := "red"
+
+	vr, err := encodeValueReader(c, testColorArrayOid, []*string{&red, nil})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var s string
+	if err := Decode(vr, &s); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if s != "{red,NULL}" {
+		t.Errorf("Expected {red,NULL}, got %s", s)
+	}
+}
+
+func TestEnumValidation(t *testing.T) {
+	c := newTestConn()
+
+	for i, value := range []interface{}{"angry", enumMood("angry"), NullString{String: "angry", Valid: true}} {
+		w := &WriteBuf{conn: c}
+		if err := Encode(w, testMoodOid, value); err == nil {
+			t.Errorf("%d. Expected error encoding invalid label", i)
+		}
+	}
+
+	for i, value := range []interface{}{[]string{"ok", "angry"}, []enumMood{"angry"}} {
+		w := &WriteBuf{conn: c}
+		if err := Encode(w, testMoodArrayOid, value); err == nil {
+			t.Errorf("%d. Expected error encoding invalid label", i)
+		}
+	}
+
+	// Labels are not known for enums that are not registered
+	w := &WriteBuf{conn: c}
+	if err := Encode(w, testColorOid, "anything"); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	// Decode a label that was added after the enum was registered
+	w = &WriteBuf{}
+	encodeString(w, testMoodOid, "angry")
+	vr := valueReaderFor(w.buf, testMoodOid, TextFormatCode)
+	vr.conn = c
+	var mood enumMood
+	if err := Decode(vr, &mood); err == nil && vr.Err() == nil {
+		t.Error("Expected error decoding invalid label")
+	}
+}
@@ -0,0 +1,143 @@
+package pgx_test
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+
+	"github.com/jackc/pgx"
+)
+
+type enumMood string
+
+// createEnumType begins a transaction and creates an enum type in it so the
+// type is dropped when the transaction is rolled back.
+func createEnumType(t *testing.T, conn *pgx.Conn, name string) *pgx.Tx {
+	tx, err := conn.Begin()
+	if err != nil {
+		t.Fatalf("conn.Begin failed: %v", err)
+	}
+	mustExec(t, conn, "create type "+name+" as enum ('sad', 'ok', 'happy')")
+	return tx
+}
+
+func TestEnumTypeTranscode(t *testing.T) {
+	t.Parallel()
+
+	conn := mustConnect(t, *defaultConnConfig)
+	defer closeConn(t, conn)
+
+	tx := createEnumType(t, conn, "pgx_test_enum_transcode")
+	defer tx.Rollback()
+
+	et, err := conn.RegisterEnumType("pgx_test_enum_transcode")
+	if err != nil {
+		t.Fatalf("conn.RegisterEnumType failed: %v", err)
+	}
+	if !reflect.DeepEqual(et.Labels, []string{"sad", "ok", "happy"}) {
+		t.Errorf("Unexpected labels: %v", et.Labels)
+	}
+
+	var mood enumMood
+	err = conn.QueryRow("select $1::pgx_test_enum_transcode", enumMood("happy")).Scan(&mood)
+	if err != nil {
+		t.Fatalf("Unexpected failure: %v", err)
+	}
+	if mood != "happy" {
+		t.Errorf("Expected happy, got %v", mood)
+	}
+
+	var moods []enumMood
+	err = conn.QueryRow("select $1::pgx_test_enum_transcode[]", []enumMood{"sad", "ok"}).Scan(&moods)
+	if err != nil {
+		t.Fatalf("Unexpected failure: %v", err)
+	}
+	if !reflect.DeepEqual(moods, []enumMood{"sad", "ok"}) {
+		t.Errorf("Unexpected moods: %v", moods)
+	}
+
+	var strs []string
+	err = conn.QueryRow("select $1::pgx_test_enum_transcode[]", []string{"happy"}).Scan(&strs)
+	if err != nil {
+		t.Fatalf("Unexpected failure: %v", err)
+	}
+	if !reflect.DeepEqual(strs, []string{"happy"}) {
+		t.Errorf("Unexpected moods: %v", strs)
+	}
+
+	var nullable []*enumMood
+	err = conn.QueryRow("select array['ok', null]::pgx_test_enum_transcode[]").Scan(&nullable)
+	if err != nil {
+		t.Fatalf("Unexpected failure: %v", err)
+	}
+	if len(nullable) != 2 || *nullable[0] != "ok" || nullable[1] != nil {
+		t.Errorf("Unexpected moods: %v", nullable)
+	}
+
+	ensureConnValid(t, conn)
+}
+
+func TestEnumTypeValidation(t *testing.T) {
+	t.Parallel()
+
+	conn := mustConnect(t, *defaultConnConfig)
+	defer closeConn(t, conn)
+
+	tx := createEnumType(t, conn, "pgx_test_enum_validation")
+	defer tx.Rollback()
+
+	if _, err := conn.RegisterEnumType("pgx_test_enum_validation"); err != nil {
+		t.Fatalf("conn.RegisterEnumType failed: %v", err)
+	}
+
+	var mood string
+	err := conn.QueryRow("select $1::pgx_test_enum_validation", "angry").Scan(&mood)
+	if _, ok := err.(pgx.SerializationError); !ok {
+		t.Errorf("Expected SerializationError, got %v", err)
+	}
+
+	_, err = conn.Exec("select $1::pgx_test_enum_validation[]", []string{"ok", "angry"})
+	if _, ok := err.(pgx.SerializationError); !ok {
+		t.Errorf("Expected SerializationError, got %v", err)
+	}
+
+	if _, err := conn.RegisterEnumType("int4"); err == nil {
+		t.Error("Expected error registering a non-enum type")
+	}
+}
+
+func TestUnregisteredEnumArrayScanIntoString(t *testing.T) {
+	t.Parallel()
+
+	setupConn := mustConnect(t, *defaultConnConfig)
+	defer closeConn(t, setupConn)
+
+	// The enum type must exist when the connection is established for its
+	// array type to be read in binary format
+	mustExec(t, setupConn, "drop type if exists pgx_test_enum_unregistered")
+	mustExec(t, setupConn, "create type pgx_test_enum_unregistered as enum ('sad', 'ok', 'happy')")
+	defer mustExec(t, setupConn, "drop type pgx_test_enum_unregistered")
+
+	conn := mustConnect(t, *defaultConnConfig)
+	defer closeConn(t, conn)
+
+	var s string
+	err := conn.QueryRow("select '{sad,NULL,happy}'::pgx_test_enum_unregistered[]").Scan(&s)
+	if err != nil {
+		t.Fatalf("Unexpected failure: %v", err)
+	}
+	if s != "{sad,NULL,happy}" {
+		t.Errorf("Expected {sad,NULL,happy}, got %v", s)
+	}
+
+	var ns sql.NullString
+	err = conn.QueryRow("select '{sad,happy}'::pgx_test_enum_unregistered[]").Scan(&ns)
+	if err != nil {
+		t.Fatalf("Unexpected failure: %v", err)
+	}
+	if !ns.Valid || ns.String != "{sad,happy}" {
+		t.Errorf("Expected {sad,happy}, got %v", ns)
+	}
+
+	ensureConnValid(t, conn)
+}
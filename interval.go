@@ -0,0 +1,213 @@
+package pgx
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	microsecondsPerSecond = 1000000
+	microsecondsPerMinute = 60 * microsecondsPerSecond
+	microsecondsPerHour   = 60 * microsecondsPerMinute
+	microsecondsPerDay    = 24 * microsecondsPerHour
+)
+
+// Interval represents a PostgreSQL interval. It does not support a null
+// value (use NullInterval for this). PostgreSQL stores months, days, and time
+// separately because the length of a month or a day varies. Interval
+// implements the Scanner and Encoder interfaces so it may be used both as an
+// argument to Query[Row] and a destination for Scan.
+type Interval struct {
+	Microseconds int64
+	Days         int32
+	Months       int32
+}
+
+// Duration returns i as a time.Duration with each day counted as 24 hours. It
+// returns an error if i includes months as they do not have a fixed length.
+func (i Interval) Duration() (time.Duration, error) {
+	if i.Months != 0 {
+		return 0, SerializationError(fmt.Sprintf("Cannot convert interval with %d months to time.Duration", i.Months))
+	}
+	return time.Duration(i.Microseconds+int64(i.Days)*microsecondsPerDay) * time.Microsecond, nil
+}
+
+// String returns i in the PostgreSQL postgres interval style (e.g. "1 year 2
+// mons 3 days 04:05:06.789").
+func (i Interval) String() string {
+	var parts []string
+	var isBefore bool // the previous part was negative
+
+	addPart := func(n int64, unit string) {
+		if n == 0 {
+			return
+		}
+		sign := ""
+		if isBefore && n > 0 {
+			sign = "+"
+		}
+		if n != 1 {
+			unit += "s"
+		}
+		parts = append(parts, fmt.Sprintf("%s%d %s", sign, n, unit))
+		isBefore = n < 0
+	}
+
+	addPart(int64(i.Months/12), "year")
+	addPart(int64(i.Months%12), "mon")
+	addPart(int64(i.Days), "day")
+
+	if i.Microseconds != 0 || len(parts) == 0 {
+		s := formatMicroseconds(i.Microseconds)
+		if isBefore && i.Microseconds > 0 {
+			s = "+" + s
+		}
+		parts = append(parts, s)
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// formatMicroseconds formats a number of microseconds as [-]hh:mm:ss[.ffffff].
+func formatMicroseconds(usec int64) string {
+	sign := ""
+	if usec < 0 {
+		sign = "-"
+		usec = -usec
+	}
+
+	hours := usec / microsecondsPerHour
+	usec -= hours * microsecondsPerHour
+	minutes := usec / microsecondsPerMinute
+	usec -= minutes * microsecondsPerMinute
+	seconds := usec / microsecondsPerSecond
+	usec -= seconds * microsecondsPerSecond
+
+	s := fmt.Sprintf("%s%02d:%02d:%02d", sign, hours, minutes, seconds)
+	if usec != 0 {
+		s += strings.TrimRight(fmt.Sprintf(".%06d", usec), "0")
+	}
+	return s
+}
+
+func (i *Interval) Scan(vr *ValueReader) error {
+	if vr.Type().DataType != IntervalOid {
+		return SerializationError(fmt.Sprintf("Interval.Scan cannot decode OID %d", vr.Type().DataType))
+	}
+
+	if vr.Len() == -1 {
+		return SerializationError("Cannot decode null into Interval")
+	}
+
+	*i = decodeInterval(vr)
+	return vr.Err()
+}
+
+func (i Interval) FormatCode() int16 { return BinaryFormatCode }
+
+func (i Interval) Encode(w *WriteBuf, oid Oid) error {
+	return encodeInterval(w, oid, i)
+}
+
+// NullInterval represents an Interval that may be null. NullInterval
+// implements the Scanner and Encoder interfaces so it may be used both as an
+// argument to Query[Row] and a destination for Scan.
+//
+// If Valid is false then the value is NULL.
+type NullInterval struct {
+	Interval Interval
+	Valid    bool // Valid is true if Interval is not NULL
+}
+
+func (n *NullInterval) Scan(vr *ValueReader) error {
+	if vr.Type().DataType != IntervalOid {
+		return SerializationError(fmt.Sprintf("NullInterval.Scan cannot decode OID %d", vr.Type().DataType))
+	}
+
+	if vr.Len() == -1 {
+		n.Interval, n.Valid = Interval{}, false
+		return nil
+	}
+
+	n.Valid = true
+	n.Interval = decodeInterval(vr)
+	return vr.Err()
+}
+
+func (n NullInterval) FormatCode() int16 { return BinaryFormatCode }
+
+func (n NullInterval) Encode(w *WriteBuf, oid Oid) error {
+	if oid != IntervalOid {
+		return SerializationError(fmt.Sprintf("NullInterval.Encode cannot encode into OID %d", oid))
+	}
+
+	if !n.Valid {
+		w.WriteInt32(-1)
+		return nil
+	}
+
+	return encodeInterval(w, oid, n.Interval)
+}
+
+func decodeInterval(vr *ValueReader) Interval {
+	if vr.Len() == -1 {
+		vr.Fatal(ProtocolError("Cannot decode null into Interval"))
+		return Interval{}
+	}
+
+	if vr.Type().DataType != IntervalOid {
+		vr.Fatal(ProtocolError(fmt.Sprintf("Cannot decode oid %v into Interval", vr.Type().DataType)))
+		return Interval{}
+	}
+
+	if vr.Type().FormatCode != BinaryFormatCode {
+		vr.Fatal(ProtocolError(fmt.Sprintf("Unknown field description format code: %v", vr.Type().FormatCode)))
+		return Interval{}
+	}
+
+	if vr.Len() != 16 {
+		vr.Fatal(ProtocolError(fmt.Sprintf("Received an invalid size for an interval: %d", vr.Len())))
+		return Interval{}
+	}
+
+	var i Interval
+	i.Microseconds = vr.ReadInt64()
+	i.Days = vr.ReadInt32()
+	i.Months = vr.ReadInt32()
+	return i
+}
+
+func encodeInterval(w *WriteBuf, oid Oid, value Interval) error {
+	if oid != IntervalOid {
+		return fmt.Errorf("cannot encode %s into oid %v", "Interval", oid)
+	}
+
+	w.WriteInt32(16)
+	w.WriteInt64(value.Microseconds)
+	w.WriteInt32(value.Days)
+	w.WriteInt32(value.Months)
+	return nil
+}
+
+func decodeDuration(vr *ValueReader) time.Duration {
+	i := decodeInterval(vr)
+	if vr.Err() != nil {
+		return 0
+	}
+
+	d, err := i.Duration()
+	if err != nil {
+		vr.Fatal(err)
+		return 0
+	}
+	return d
+}
+
+func encodeDuration(w *WriteBuf, oid Oid, value time.Duration) error {
+	if oid != IntervalOid {
+		return fmt.Errorf("cannot encode %s into oid %v", "time.Duration", oid)
+	}
+
+	return encodeInterval(w, oid, Interval{Microseconds: int64(value / time.Microsecond)})
+}
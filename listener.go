@@ -0,0 +1,264 @@
+package pgx
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrListenerClosed occurs on an attempt to use a closed Listener.
+var ErrListenerClosed = errors.New("listener is closed")
+
+// listenerReconnectDelay is how long a Listener waits before trying again
+// when it cannot acquire a connection and LISTEN on its channels.
+const listenerReconnectDelay = time.Second
+
+// Listener receives LISTEN/NOTIFY notifications on a dedicated connection
+// acquired from a ConnPool and delivers them on a Go channel. If the
+// connection is lost the Listener acquires a new one and LISTENs on all of its
+// channels again. Notifications sent while it is reconnecting are lost.
+//
+// A Listener holds its connection until it is closed, so it must be closed
+// before the pool is.
+type Listener struct {
+	pool          *ConnPool
+	notifications chan *Notification
+	closeChan     chan struct{} // closed by Close
+	doneChan      chan struct{} // closed when run returns
+
+	mux        sync.Mutex
+	pending    []*listenerRequest // Listen and Unlisten calls not yet handled by run
+	cancelWait context.CancelFunc // interrupts run waiting for a notification
+	closed     bool
+}
+
+type listenerRequest struct {
+	channel string
+	listen  bool // true for LISTEN, false for UNLISTEN
+	result  chan error
+}
+
+// NewListener creates a Listener with a connection acquired from p.
+func (p *ConnPool) NewListener() (*Listener, error) {
+	conn, err := p.Acquire()
+	if err != nil {
+		return nil, err
+	}
+
+	l := &Listener{
+		pool:          p,
+		notifications: make(chan *Notification, 32),
+		closeChan:     make(chan struct{}),
+		doneChan:      make(chan struct{}),
+	}
+	go l.run(conn)
+
+	return l, nil
+}
+
+// Notifications returns the channel notifications are delivered on. It is
+// closed when the Listener is closed. Listen and Unlisten may be called from
+// the goroutine receiving from it even when it is full.
+func (l *Listener) Notifications() <-chan *Notification {
+	return l.notifications
+}
+
+// Listen starts listening on channel. The Listener will listen on channel
+// again whenever it reconnects.
+func (l *Listener) Listen(channel string) error {
+	return l.request(channel, true)
+}
+
+// Unlisten stops listening on channel.
+func (l *Listener) Unlisten(channel string) error {
+	return l.request(channel, false)
+}
+
+func (l *Listener) request(channel string, listen bool) error {
+	req := &listenerRequest{channel: channel, listen: listen, result: make(chan error, 1)}
+
+	l.mux.Lock()
+	if l.closed {
+		l.mux.Unlock()
+		return ErrListenerClosed
+	}
+	l.pending = append(l.pending, req)
+	if l.cancelWait != nil {
+		l.cancelWait()
+	}
+	l.mux.Unlock()
+
+	return <-req.result
+}
+
+// Close stops the Listener, releases its connection to the pool, and closes
+// the Notifications channel.
+func (l *Listener) Close() error {
+	l.mux.Lock()
+	if l.closed {
+		l.mux.Unlock()
+		return nil
+	}
+	l.closed = true
+	close(l.closeChan)
+	if l.cancelWait != nil {
+		l.cancelWait()
+	}
+	l.mux.Unlock()
+
+	<-l.doneChan
+	return nil
+}
+
+// run owns conn and the set of channels being listened to. It handles Listen
+// and Unlisten requests, waits for notifications, and reconnects when conn
+// is lost.
+func (l *Listener) run(conn *Conn) {
+	channels := make(map[string]struct{})
+
+	defer func() {
+		if conn != nil {
+			l.pool.Release(conn)
+		}
+
+		l.mux.Lock()
+		for _, req := range l.pending {
+			req.result <- ErrListenerClosed
+		}
+		l.pending = nil
+		l.mux.Unlock()
+
+		close(l.notifications)
+		close(l.doneChan)
+	}()
+
+	for {
+		pending, ctx, cancel, ok := l.takePending()
+		if !ok {
+			return
+		}
+
+		if conn == nil {
+			var err error
+			conn, err = l.reconnect(channels)
+			if err != nil {
+				for _, req := range pending {
+					req.result <- err
+				}
+				l.sleep(ctx, listenerReconnectDelay)
+				cancel()
+				continue
+			}
+		}
+
+		for _, req := range pending {
+			req.result <- l.apply(conn, channels, req)
+		}
+
+		var notification *Notification
+		var err error
+		if conn.IsAlive() {
+			notification, err = conn.WaitForNotificationContext(ctx)
+		}
+		cancel()
+
+		switch {
+		case !conn.IsAlive() || (err != nil && err != context.Canceled):
+			// The connection is lost or in an unknown state
+			l.pool.Release(conn)
+			conn = nil
+		case notification != nil:
+			l.deliver(conn, channels, notification)
+		}
+	}
+}
+
+// takePending removes the requests waiting to be handled by run and returns
+// them with a context that is canceled when another request arrives or the
+// Listener is closed. ok is false if the Listener is closed.
+func (l *Listener) takePending() (pending []*listenerRequest, ctx context.Context, cancel context.CancelFunc, ok bool) {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+
+	if l.closed {
+		return nil, nil, nil, false
+	}
+	pending = l.pending
+	l.pending = nil
+	ctx, cancel = context.WithCancel(context.Background())
+	l.cancelWait = cancel
+
+	return pending, ctx, cancel, true
+}
+
+// deliver sends notification on l.notifications. Requests are still handled
+// while the send is blocked so a consumer that calls Listen or Unlisten
+// without first draining Notifications does not deadlock.
+func (l *Listener) deliver(conn *Conn, channels map[string]struct{}, notification *Notification) {
+	for {
+		pending, ctx, cancel, ok := l.takePending()
+		if !ok {
+			return
+		}
+
+		for _, req := range pending {
+			req.result <- l.apply(conn, channels, req)
+		}
+
+		select {
+		case l.notifications <- notification:
+			cancel()
+			return
+		case <-l.closeChan:
+			cancel()
+			return
+		case <-ctx.Done():
+		}
+	}
+}
+
+// reconnect acquires a new connection and listens on all channels.
+func (l *Listener) reconnect(channels map[string]struct{}) (*Conn, error) {
+	conn, err := l.pool.Acquire()
+	if err != nil {
+		return nil, err
+	}
+
+	for channel := range channels {
+		if err := conn.Listen(channel); err != nil {
+			l.pool.Release(conn)
+			return nil, err
+		}
+	}
+
+	return conn, nil
+}
+
+// apply executes req on conn and updates channels if it succeeds.
+func (l *Listener) apply(conn *Conn, channels map[string]struct{}, req *listenerRequest) error {
+	if req.listen {
+		if err := conn.Listen(req.channel); err != nil {
+			return err
+		}
+		channels[req.channel] = struct{}{}
+		return nil
+	}
+
+	if err := conn.Unlisten(req.channel); err != nil {
+		return err
+	}
+	delete(channels, req.channel)
+	return nil
+}
+
+// sleep waits for d or until ctx is done.
+func (l *Listener) sleep(ctx context.Context, d time.Duration) {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+}
@@ -0,0 +1,197 @@
+package pgx_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx"
+)
+
+func receiveNotification(t *testing.T, l *pgx.Listener, timeout time.Duration) *pgx.Notification {
+	select {
+	case n := <-l.Notifications():
+		return n
+	case <-time.After(timeout):
+		return nil
+	}
+}
+
+func TestListener(t *testing.T) {
+	t.Parallel()
+
+	pool := createConnPool(t, 2)
+	defer pool.Close()
+
+	l, err := pool.NewListener()
+	if err != nil {
+		t.Fatalf("Unable to create listener: %v", err)
+	}
+	defer l.Close()
+
+	if err := l.Listen("listener_chat"); err != nil {
+		t.Fatalf("Unable to start listening: %v", err)
+	}
+
+	if _, err := pool.Exec("notify listener_chat, 'hello'"); err != nil {
+		t.Fatalf("Unexpected failure: %v", err)
+	}
+
+	n := receiveNotification(t, l, 5*time.Second)
+	if n == nil {
+		t.Fatal("Did not receive notification")
+	}
+	if n.Channel != "listener_chat" || n.Payload != "hello" {
+		t.Errorf("Unexpected notification: %+v", n)
+	}
+
+	if err := l.Unlisten("listener_chat"); err != nil {
+		t.Fatalf("Unable to stop listening: %v", err)
+	}
+	if _, err := pool.Exec("notify listener_chat, 'goodbye'"); err != nil {
+		t.Fatalf("Unexpected failure: %v", err)
+	}
+	if n := receiveNotification(t, l, 100*time.Millisecond); n != nil {
+		t.Errorf("Received notification after Unlisten: %+v", n)
+	}
+}
+
+func TestListenerReconnects(t *testing.T) {
+	t.Parallel()
+
+	pool := createConnPool(t, 2)
+	defer pool.Close()
+
+	l, err := pool.NewListener()
+	if err != nil {
+		t.Fatalf("Unable to create listener: %v", err)
+	}
+	defer l.Close()
+
+	if err := l.Listen("listener_reconnect"); err != nil {
+		t.Fatalf("Unable to start listening: %v", err)
+	}
+
+	if _, err := pool.Exec(`select pg_terminate_backend(pid) from pg_stat_activity where query = 'listen "listener_reconnect"'`); err != nil {
+		t.Fatalf("Unable to kill backend PostgreSQL process: %v", err)
+	}
+
+	// Notifications sent before the listener has reconnected are lost so keep
+	// sending until one arrives
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, err := pool.Exec("notify listener_reconnect, 'again'"); err != nil {
+			t.Fatalf("Unexpected failure: %v", err)
+		}
+		if n := receiveNotification(t, l, 100*time.Millisecond); n != nil {
+			if n.Payload != "again" {
+				t.Errorf("Unexpected notification: %+v", n)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Listener did not reconnect")
+		}
+	}
+}
+
+func TestListenerClose(t *testing.T) {
+	t.Parallel()
+
+	pool := createConnPool(t, 2)
+	defer pool.Close()
+
+	l, err := pool.NewListener()
+	if err != nil {
+		t.Fatalf("Unable to create listener: %v", err)
+	}
+
+	if err := l.Listen("listener_close"); err != nil {
+		t.Fatalf("Unable to start listening: %v", err)
+	}
+
+	if err := l.Close(); err != nil {
+		t.Fatalf("Unable to close listener: %v", err)
+	}
+
+	if _, ok := <-l.Notifications(); ok {
+		t.Error("Expected Notifications channel to be closed")
+	}
+	if err := l.Listen("listener_close"); err != pgx.ErrListenerClosed {
+		t.Errorf("Expected ErrListenerClosed, got %v", err)
+	}
+
+	// The connection is released to the pool
+	if stat := pool.Stat(); stat.AvailableConnections != stat.CurrentConnections {
+		t.Errorf("Expected all connections to be available, got %+v", stat)
+	}
+}
+
+func TestListenerCloseUnlistens(t *testing.T) {
+	t.Parallel()
+
+	pool := createConnPool(t, 1)
+	defer pool.Close()
+
+	l, err := pool.NewListener()
+	if err != nil {
+		t.Fatalf("Unable to create listener: %v", err)
+	}
+
+	if err := l.Listen("listener_unlisten"); err != nil {
+		t.Fatalf("Unable to start listening: %v", err)
+	}
+	if err := l.Close(); err != nil {
+		t.Fatalf("Unable to close listener: %v", err)
+	}
+
+	// The pool has one connection so this is the one the listener used
+	var n int
+	if err := pool.QueryRow("select count(*) from pg_listening_channels()").Scan(&n); err != nil {
+		t.Fatalf("Unexpected failure: %v", err)
+	}
+	if n != 0 {
+		t.Errorf("Expected released connection to listen to no channels, got %d", n)
+	}
+}
+
+func TestListenerListenWithFullNotifications(t *testing.T) {
+	t.Parallel()
+
+	pool := createConnPool(t, 2)
+	defer pool.Close()
+
+	l, err := pool.NewListener()
+	if err != nil {
+		t.Fatalf("Unable to create listener: %v", err)
+	}
+	defer l.Close()
+
+	if err := l.Listen("listener_full"); err != nil {
+		t.Fatalf("Unable to start listening: %v", err)
+	}
+
+	// More notifications than the Notifications channel can buffer
+	if _, err := pool.Exec("select pg_notify('listener_full', n::text) from generate_series(1, 40) n"); err != nil {
+		t.Fatalf("Unexpected failure: %v", err)
+	}
+	time.Sleep(100 * time.Millisecond)
+
+	// Listen without draining Notifications first, as a consumer calling it
+	// from the goroutine that receives notifications would
+	errChan := make(chan error, 1)
+	go func() { errChan <- l.Listen("listener_full_other") }()
+	select {
+	case err := <-errChan:
+		if err != nil {
+			t.Fatalf("Unable to start listening: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Listen blocked while Notifications was full")
+	}
+
+	for i := 0; i < 40; i++ {
+		if n := receiveNotification(t, l, 5*time.Second); n == nil {
+			t.Fatalf("Received %d notifications, expected 40", i)
+		}
+	}
+}
@@ -26,6 +26,7 @@ const (
 	closeComplete        = '3'
 	flush                = 'H'
 	copyInResponse       = 'G'
+	copyOutResponse      = 'H'
 	copyData             = 'd'
 	copyFail             = 'f'
 	copyDone             = 'c'
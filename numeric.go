@@ -0,0 +1,594 @@
+package pgx
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+)
+
+// numeric sign values in the binary format
+const (
+	numericPos  = 0x0000
+	numericNeg  = 0x4000
+	numericNaN  = 0xC000
+	numericPInf = 0xD000
+	numericNInf = 0xF000
+)
+
+var (
+	bigZero  = big.NewInt(0)
+	bigTen   = big.NewInt(10)
+	bigNBase = big.NewInt(10000)
+)
+
+// Numeric represents a PostgreSQL numeric that may be null. A finite value is
+// Int * 10^Exp. Exp is the negative of the scale of the value so trailing
+// zeros are preserved (e.g. 1.50 is Int 150 and Exp -2). Numeric implements
+// the Scanner and Encoder interfaces so it may be used both as an argument to
+// Query[Row] and a destination for Scan.
+//
+// If NaN is true the value is NaN. If Infinity is 1 or -1 the value is
+// Infinity or -Infinity (only supported by PostgreSQL 14 and later). Otherwise
+// the value is finite.
+//
+// If Valid is false then the value is NULL.
+type Numeric struct {
+	Int      *big.Int
+	Exp      int32
+	NaN      bool
+	Infinity int8
+	Valid    bool // Valid is true if Numeric is not NULL
+}
+
+// Rat returns n as a *big.Rat. It returns an error if n is NULL, NaN, or
+// infinite.
+func (n Numeric) Rat() (*big.Rat, error) {
+	switch {
+	case !n.Valid:
+		return nil, SerializationError("Cannot convert NULL numeric to *big.Rat")
+	case n.NaN:
+		return nil, SerializationError("Cannot convert NaN numeric to *big.Rat")
+	case n.Infinity != 0:
+		return nil, SerializationError("Cannot convert infinite numeric to *big.Rat")
+	}
+
+	r := new(big.Rat)
+	if n.Int != nil {
+		r.SetInt(n.Int)
+	}
+	if n.Exp > 0 {
+		r.Mul(r, new(big.Rat).SetInt(pow10(n.Exp)))
+	} else if n.Exp < 0 {
+		r.Quo(r, new(big.Rat).SetInt(pow10(-n.Exp)))
+	}
+	return r, nil
+}
+
+// Float64 returns the nearest float64 to n. NaN and infinite values are
+// converted to their float64 equivalents.
+func (n Numeric) Float64() (float64, error) {
+	switch {
+	case !n.Valid:
+		return 0, SerializationError("Cannot convert NULL numeric to float64")
+	case n.NaN:
+		return math.NaN(), nil
+	case n.Infinity != 0:
+		return math.Inf(int(n.Infinity)), nil
+	}
+
+	r, err := n.Rat()
+	if err != nil {
+		return 0, err
+	}
+	f, _ := r.Float64()
+	return f, nil
+}
+
+// String returns the text representation of n as PostgreSQL would format it.
+func (n Numeric) String() string {
+	switch {
+	case !n.Valid:
+		return "NULL"
+	case n.NaN:
+		return "NaN"
+	case n.Infinity > 0:
+		return "Infinity"
+	case n.Infinity < 0:
+		return "-Infinity"
+	}
+
+	i := n.Int
+	if i == nil {
+		i = bigZero
+	}
+
+	digits := new(big.Int).Abs(i).String()
+	if n.Exp > 0 {
+		digits += strings.Repeat("0", int(n.Exp))
+	} else if n.Exp < 0 {
+		scale := int(-n.Exp)
+		if len(digits) <= scale {
+			digits = strings.Repeat("0", scale-len(digits)+1) + digits
+		}
+		digits = digits[:len(digits)-scale] + "." + digits[len(digits)-scale:]
+	}
+
+	if i.Sign() < 0 {
+		return "-" + digits
+	}
+	return digits
+}
+
+func (n *Numeric) Scan(vr *ValueReader) error {
+	if vr.Type().DataType != NumericOid {
+		return SerializationError(fmt.Sprintf("Numeric.Scan cannot decode OID %d", vr.Type().DataType))
+	}
+
+	*n = decodeNumeric(vr)
+	return vr.Err()
+}
+
+func (n Numeric) FormatCode() int16 { return BinaryFormatCode }
+
+func (n Numeric) Encode(w *WriteBuf, oid Oid) error {
+	if oid != NumericOid {
+		return SerializationError(fmt.Sprintf("Numeric.Encode cannot encode into OID %d", oid))
+	}
+
+	if !n.Valid {
+		w.WriteInt32(-1)
+		return nil
+	}
+
+	writeNumeric(w, n)
+	return nil
+}
+
+// NumericFromRat returns r as a Numeric. r must have a finite decimal
+// representation (i.e. its denominator must have no prime factors other than
+// 2 and 5).
+func NumericFromRat(r *big.Rat) (Numeric, error) {
+	if r == nil {
+		return Numeric{}, nil
+	}
+
+	if r.IsInt() {
+		return Numeric{Int: new(big.Int).Set(r.Num()), Valid: true}, nil
+	}
+
+	// Find the smallest k such that the denominator divides 10^k
+	denom := new(big.Int).Set(r.Denom())
+	rem := new(big.Int)
+	var twos, fives int32
+	for {
+		q, m := new(big.Int).QuoRem(denom, big.NewInt(2), rem)
+		if m.Sign() != 0 {
+			break
+		}
+		denom = q
+		twos++
+	}
+	for {
+		q, m := new(big.Int).QuoRem(denom, big.NewInt(5), rem)
+		if m.Sign() != 0 {
+			break
+		}
+		denom = q
+		fives++
+	}
+	if denom.Cmp(big.NewInt(1)) != 0 {
+		return Numeric{}, SerializationError(fmt.Sprintf("%v cannot be represented exactly as numeric", r.RatString()))
+	}
+
+	k := twos
+	if fives > k {
+		k = fives
+	}
+
+	i := new(big.Int).Mul(r.Num(), pow10(k))
+	i.Quo(i, r.Denom())
+
+	return Numeric{Int: i, Exp: -k, Valid: true}, nil
+}
+
+func pow10(n int32) *big.Int {
+	return new(big.Int).Exp(bigTen, big.NewInt(int64(n)), nil)
+}
+
+// parseNumeric parses the text format of a numeric.
+func parseNumeric(s string) (Numeric, error) {
+	switch s {
+	case "NaN":
+		return Numeric{NaN: true, Valid: true}, nil
+	case "Infinity":
+		return Numeric{Infinity: 1, Valid: true}, nil
+	case "-Infinity":
+		return Numeric{Infinity: -1, Valid: true}, nil
+	}
+
+	var exp int32
+	digits := s
+	if idx := strings.IndexByte(s, '.'); idx >= 0 {
+		exp = -int32(len(s) - idx - 1)
+		digits = s[:idx] + s[idx+1:]
+	}
+
+	i, ok := new(big.Int).SetString(digits, 10)
+	if !ok {
+		return Numeric{}, SerializationError(fmt.Sprintf("Cannot parse %q as numeric", s))
+	}
+
+	return Numeric{Int: i, Exp: exp, Valid: true}, nil
+}
+
+func decodeNumeric(vr *ValueReader) Numeric {
+	if vr.Len() == -1 {
+		return Numeric{}
+	}
+
+	if vr.Type().DataType != NumericOid {
+		vr.Fatal(ProtocolError(fmt.Sprintf("Cannot decode oid %v into numeric", vr.Type().DataType)))
+		return Numeric{}
+	}
+
+	switch vr.Type().FormatCode {
+	case TextFormatCode:
+		n, err := parseNumeric(vr.ReadString(vr.Len()))
+		if err != nil {
+			vr.Fatal(err)
+		}
+		return n
+	case BinaryFormatCode:
+		return readNumeric(vr, vr.Len())
+	default:
+		vr.Fatal(ProtocolError(fmt.Sprintf("Unknown field description format code: %v", vr.Type().FormatCode)))
+		return Numeric{}
+	}
+}
+
+// readNumeric reads a size byte binary numeric from vr.
+func readNumeric(vr *ValueReader, size int32) Numeric {
+	if size < 8 {
+		vr.Fatal(ProtocolError(fmt.Sprintf("Received an invalid size for a numeric: %d", size)))
+		return Numeric{}
+	}
+
+	ndigits := vr.ReadInt16()
+	weight := vr.ReadInt16()
+	sign := vr.ReadUint16()
+	dscale := vr.ReadInt16()
+
+	if size != 8+2*int32(ndigits) {
+		vr.Fatal(ProtocolError(fmt.Sprintf("Received an invalid size for a numeric: %d", size)))
+		return Numeric{}
+	}
+
+	switch sign {
+	case numericNaN:
+		return Numeric{NaN: true, Valid: true}
+	case numericPInf:
+		return Numeric{Infinity: 1, Valid: true}
+	case numericNInf:
+		return Numeric{Infinity: -1, Valid: true}
+	case numericPos, numericNeg:
+	default:
+		vr.Fatal(ProtocolError(fmt.Sprintf("Received an invalid numeric sign: %#x", sign)))
+		return Numeric{}
+	}
+
+	n := new(big.Int)
+	digit := new(big.Int)
+	for i := int16(0); i < ndigits; i++ {
+		n.Mul(n, bigNBase)
+		n.Add(n, digit.SetInt64(int64(vr.ReadInt16())))
+	}
+
+	// The digits are base 10000 so the exponent of the value as read is a
+	// multiple of 4. Rescale it to the display scale.
+	var exp int32
+	if ndigits > 0 {
+		exp = 4 * (int32(weight) - int32(ndigits) + 1)
+	}
+	targetExp := -int32(dscale)
+	if exp > targetExp {
+		n.Mul(n, pow10(exp-targetExp))
+	} else if exp < targetExp {
+		n.Quo(n, pow10(targetExp-exp))
+	}
+
+	if sign == numericNeg {
+		n.Neg(n)
+	}
+
+	return Numeric{Int: n, Exp: targetExp, Valid: true}
+}
+
+// writeNumeric writes the size and binary format of n, which must be valid.
+func writeNumeric(w *WriteBuf, n Numeric) {
+	switch {
+	case n.NaN:
+		writeNumericHeader(w, 0, 0, numericNaN, 0)
+		return
+	case n.Infinity > 0:
+		writeNumericHeader(w, 0, 0, numericPInf, 0)
+		return
+	case n.Infinity < 0:
+		writeNumericHeader(w, 0, 0, numericNInf, 0)
+		return
+	}
+
+	var sign uint16 = numericPos
+	abs := new(big.Int)
+	if n.Int != nil {
+		abs.Abs(n.Int)
+		if n.Int.Sign() < 0 {
+			sign = numericNeg
+		}
+	}
+
+	var dscale int16
+	if n.Exp < 0 {
+		dscale = int16(-n.Exp)
+	}
+
+	// Align the exponent to a multiple of 4 so the value splits into base
+	// 10000 digits.
+	exp := n.Exp
+	if r := ((exp % 4) + 4) % 4; r != 0 {
+		abs.Mul(abs, pow10(r))
+		exp -= r
+	}
+
+	// Base 10000 digits least significant first
+	var digits []int16
+	rem := new(big.Int)
+	for abs.Sign() != 0 {
+		abs.QuoRem(abs, bigNBase, rem)
+		digits = append(digits, int16(rem.Int64()))
+	}
+
+	// Trailing zero digits are implied by the weight
+	trailingZeros := 0
+	for trailingZeros < len(digits) && digits[trailingZeros] == 0 {
+		trailingZeros++
+	}
+	digits = digits[trailingZeros:]
+
+	var weight int16
+	if len(digits) > 0 {
+		weight = int16(len(digits) - 1 + trailingZeros + int(exp/4))
+	}
+
+	writeNumericHeader(w, int16(len(digits)), weight, sign, dscale)
+	for i := len(digits) - 1; i >= 0; i-- {
+		w.WriteInt16(digits[i])
+	}
+}
+
+func writeNumericHeader(w *WriteBuf, ndigits, weight int16, sign uint16, dscale int16) {
+	w.WriteInt32(8 + 2*int32(ndigits))
+	w.WriteInt16(ndigits)
+	w.WriteInt16(weight)
+	w.WriteUint16(sign)
+	w.WriteInt16(dscale)
+}
+
+func encodeNumeric(w *WriteBuf, oid Oid, value Numeric) error {
+	return value.Encode(w, oid)
+}
+
+func encodeBigRat(w *WriteBuf, oid Oid, value *big.Rat) error {
+	if oid != NumericOid {
+		return fmt.Errorf("cannot encode %s into oid %v", "*big.Rat", oid)
+	}
+	n, err := NumericFromRat(value)
+	if err != nil {
+		return err
+	}
+	return n.Encode(w, oid)
+}
+
+func encodeBigInt(w *WriteBuf, oid Oid, value *big.Int) error {
+	if oid != NumericOid {
+		return fmt.Errorf("cannot encode %s into oid %v", "*big.Int", oid)
+	}
+	if value == nil {
+		w.WriteInt32(-1)
+		return nil
+	}
+	return Numeric{Int: value, Valid: true}.Encode(w, oid)
+}
+
+// decodeNumericText decodes a numeric into its text representation regardless
+// of the format it was received in.
+func decodeNumericText(vr *ValueReader) string {
+	if vr.Len() == -1 {
+		vr.Fatal(ProtocolError("Cannot decode null into string"))
+		return ""
+	}
+
+	n := decodeNumeric(vr)
+	if vr.Err() != nil {
+		return ""
+	}
+	return n.String()
+}
+
+// decodeNumericFloat64 decodes a numeric into the nearest float64.
+func decodeNumericFloat64(vr *ValueReader) float64 {
+	if vr.Len() == -1 {
+		vr.Fatal(ProtocolError("Cannot decode null into float64"))
+		return 0
+	}
+
+	n := decodeNumeric(vr)
+	if vr.Err() != nil {
+		return 0
+	}
+
+	f, err := n.Float64()
+	if err != nil {
+		vr.Fatal(err)
+	}
+	return f
+}
+
+func decodeBigRat(vr *ValueReader) *big.Rat {
+	if vr.Len() == -1 {
+		vr.Fatal(ProtocolError("Cannot decode null into *big.Rat"))
+		return nil
+	}
+
+	n := decodeNumeric(vr)
+	if vr.Err() != nil {
+		return nil
+	}
+
+	r, err := n.Rat()
+	if err != nil {
+		vr.Fatal(err)
+		return nil
+	}
+	return r
+}
+
+func decodeBigInt(vr *ValueReader) *big.Int {
+	r := decodeBigRat(vr)
+	if r == nil {
+		return nil
+	}
+
+	if !r.IsInt() {
+		vr.Fatal(ProtocolError(fmt.Sprintf("Cannot decode %v into *big.Int without losing precision", r.RatString())))
+		return nil
+	}
+	return new(big.Int).Set(r.Num())
+}
+
+func decodeNumericArray(vr *ValueReader) []Numeric {
+	if vr.Len() == -1 {
+		return nil
+	}
+
+	if vr.Type().DataType != NumericArrayOid {
+		vr.Fatal(ProtocolError(fmt.Sprintf("Cannot decode oid %v into []Numeric", vr.Type().DataType)))
+		return nil
+	}
+
+	if vr.Type().FormatCode != BinaryFormatCode {
+		vr.Fatal(ProtocolError(fmt.Sprintf("Unknown field description format code: %v", vr.Type().FormatCode)))
+		return nil
+	}
+
+	numElems, err := decode1dArrayHeader(vr)
+	if err != nil {
+		vr.Fatal(err)
+		return nil
+	}
+
+	a := make([]Numeric, int(numElems))
+	for i := 0; i < len(a); i++ {
+		elSize := vr.ReadInt32()
+		if elSize == -1 {
+			continue
+		}
+		a[i] = readNumeric(vr, elSize)
+		if vr.Err() != nil {
+			return nil
+		}
+	}
+
+	return a
+}
+
+// decodeBigRatArray decodes a numeric[] into a []*big.Rat. NULL elements are
+// decoded as nil.
+func decodeBigRatArray(vr *ValueReader) []*big.Rat {
+	numerics := decodeNumericArray(vr)
+	if numerics == nil {
+		return nil
+	}
+
+	a := make([]*big.Rat, len(numerics))
+	for i, n := range numerics {
+		if !n.Valid {
+			continue
+		}
+		r, err := n.Rat()
+		if err != nil {
+			vr.Fatal(err)
+			return nil
+		}
+		a[i] = r
+	}
+
+	return a
+}
+
+func encodeNumericSlice(w *WriteBuf, oid Oid, slice []Numeric) error {
+	if oid != NumericArrayOid {
+		return fmt.Errorf("cannot encode Go %s into oid %d", "[]Numeric", oid)
+	}
+
+	sizeIdx := len(w.buf)
+	w.WriteInt32(0) // size placeholder
+
+	var hasNulls int32
+	for _, n := range slice {
+		if !n.Valid {
+			hasNulls = 1
+			break
+		}
+	}
+
+	w.WriteInt32(1)                 // number of dimensions
+	w.WriteInt32(hasNulls)          // has nulls
+	w.WriteInt32(int32(NumericOid)) // type of elements
+	w.WriteInt32(int32(len(slice))) // number of elements
+	w.WriteInt32(1)                 // index of first element
+
+	for _, n := range slice {
+		if !n.Valid {
+			w.WriteInt32(-1)
+			continue
+		}
+		writeNumeric(w, n)
+	}
+
+	binary.BigEndian.PutUint32(w.buf[sizeIdx:], uint32(len(w.buf)-sizeIdx-4))
+	return nil
+}
+
+func encodeBigRatSlice(w *WriteBuf, oid Oid, slice []*big.Rat) error {
+	if oid != NumericArrayOid {
+		return fmt.Errorf("cannot encode Go %s into oid %d", "[]*big.Rat", oid)
+	}
+
+	numerics := make([]Numeric, len(slice))
+	for i, r := range slice {
+		n, err := NumericFromRat(r)
+		if err != nil {
+			return err
+		}
+		numerics[i] = n
+	}
+
+	return encodeNumericSlice(w, oid, numerics)
+}
+
+// numericArgFormatCode returns the format code to use to send arg as a
+// numeric or numeric[] parameter. Only the types pgx can encode to the binary
+// format are sent as binary. Everything else, such as strings from a
+// driver.Valuer, is sent as text.
+func numericArgFormatCode(arg interface{}) int16 {
+	switch arg.(type) {
+	case *big.Rat, *big.Int, []*big.Rat, []Numeric:
+		return BinaryFormatCode
+	}
+	if binaryArrayArg(arg) {
+		return BinaryFormatCode
+	}
+	return TextFormatCode
+}
@@ -0,0 +1,152 @@
+package pgx
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"math/big"
+	"reflect"
+	"testing"
+)
+
+// valueReaderFor returns a *ValueReader over the single encoded value (size
+// prefix included) in buf.
+func valueReaderFor(buf []byte, oid Oid, formatCode int16) *ValueReader {
+	size := int32(binary.BigEndian.Uint32(buf))
+	mr := &msgReader{
+		reader:            bufio.NewReader(bytes.NewReader(buf[4:])),
+		msgBytesRemaining: int32(len(buf) - 4),
+		shouldLog:         func(int) bool { return false },
+	}
+	return &ValueReader{
+		mr:                  mr,
+		fd:                  &FieldDescription{DataType: oid, FormatCode: formatCode},
+		valueBytesRemaining: size,
+	}
+}
+
+func TestNumericBinaryFormat(t *testing.T) {
+	tests := []struct {
+		n       Numeric
+		ndigits int16
+		weight  int16
+		sign    uint16
+		dscale  int16
+		digits  []int16
+	}{
+		{Numeric{Int: big.NewInt(0), Valid: true}, 0, 0, numericPos, 0, nil},
+		{Numeric{Int: big.NewInt(1), Valid: true}, 1, 0, numericPos, 0, []int16{1}},
+		{Numeric{Int: big.NewInt(12345678), Exp: -3, Valid: true}, 3, 1, numericPos, 3, []int16{1, 2345, 6780}},
+		{Numeric{Int: big.NewInt(-150), Exp: -2, Valid: true}, 2, 0, numericNeg, 2, []int16{1, 5000}},
+		{Numeric{Int: big.NewInt(1), Exp: 8, Valid: true}, 1, 2, numericPos, 0, []int16{1}},
+		{Numeric{Int: big.NewInt(1), Exp: -9, Valid: true}, 1, -3, numericPos, 9, []int16{1000}},
+		{Numeric{NaN: true, Valid: true}, 0, 0, numericNaN, 0, nil},
+		{Numeric{Infinity: 1, Valid: true}, 0, 0, numericPInf, 0, nil},
+		{Numeric{Infinity: -1, Valid: true}, 0, 0, numericNInf, 0, nil},
+	}
+
+	for i, tt := range tests {
+		w := &WriteBuf{}
+		if err := tt.n.Encode(w, NumericOid); err != nil {
+			t.Errorf("%d. Unexpected error: %v", i, err)
+			continue
+		}
+
+		expected := &WriteBuf{}
+		writeNumericHeader(expected, tt.ndigits, tt.weight, tt.sign, tt.dscale)
+		for _, d := range tt.digits {
+			expected.WriteInt16(d)
+		}
+
+		if !bytes.Equal(w.buf, expected.buf) {
+			t.Errorf("%d. %v encoded as %v, expected %v", i, tt.n, w.buf, expected.buf)
+			continue
+		}
+
+		vr := valueReaderFor(w.buf, NumericOid, BinaryFormatCode)
+		var n Numeric
+		if err := n.Scan(vr); err != nil {
+			t.Errorf("%d. Unexpected error: %v", i, err)
+			continue
+		}
+		if n.String() != tt.n.String() {
+			t.Errorf("%d. Decoded %v, expected %v", i, n, tt.n)
+		}
+	}
+}
+
+func TestNumericString(t *testing.T) {
+	tests := []struct {
+		n        Numeric
+		expected string
+	}{
+		{Numeric{Int: big.NewInt(0), Valid: true}, "0"},
+		{Numeric{Int: big.NewInt(0), Exp: -2, Valid: true}, "0.00"},
+		{Numeric{Int: big.NewInt(5), Exp: -3, Valid: true}, "0.005"},
+		{Numeric{Int: big.NewInt(-5), Exp: -3, Valid: true}, "-0.005"},
+		{Numeric{Int: big.NewInt(12345), Exp: -2, Valid: true}, "123.45"},
+		{Numeric{Int: big.NewInt(12), Exp: 3, Valid: true}, "12000"},
+		{Numeric{NaN: true, Valid: true}, "NaN"},
+		{Numeric{Infinity: -1, Valid: true}, "-Infinity"},
+	}
+
+	for i, tt := range tests {
+		if s := tt.n.String(); s != tt.expected {
+			t.Errorf("%d. String() => %v, expected %v", i, s, tt.expected)
+		}
+
+		if tt.n.Int == nil || tt.n.Exp > 0 {
+			continue
+		}
+		n, err := parseNumeric(tt.expected)
+		if err != nil {
+			t.Errorf("%d. Unexpected error: %v", i, err)
+			continue
+		}
+		if !reflect.DeepEqual(n, tt.n) {
+			t.Errorf("%d. parseNumeric(%q) => %#v, expected %#v", i, tt.expected, n, tt.n)
+		}
+	}
+}
+
+func TestNumericFromRat(t *testing.T) {
+	n, err := NumericFromRat(big.NewRat(-3, 8))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n.String() != "-0.375" {
+		t.Errorf("NumericFromRat(-3/8) => %v, expected %v", n, "-0.375")
+	}
+
+	if _, err := NumericFromRat(big.NewRat(1, 3)); err == nil {
+		t.Error("Expected error converting 1/3 to numeric")
+	}
+}
+
+func TestNumericArrayRoundTrip(t *testing.T) {
+	input := []Numeric{
+		{Int: big.NewInt(31415), Exp: -4, Valid: true},
+		{},
+		{NaN: true, Valid: true},
+	}
+
+	w := &WriteBuf{}
+	if err := encodeNumericSlice(w, NumericArrayOid, input); err != nil {
+		t.Fatal(err)
+	}
+
+	vr := valueReaderFor(w.buf, NumericArrayOid, BinaryFormatCode)
+	output := decodeNumericArray(vr)
+	if vr.Err() != nil {
+		t.Fatal(vr.Err())
+	}
+
+	if len(output) != len(input) {
+		t.Fatalf("Expected %d elements, got %d", len(input), len(output))
+	}
+	for i := range input {
+		if output[i].String() != input[i].String() {
+			t.Errorf("%d. Decoded %v, expected %v", i, output[i], input[i])
+		}
+	}
+}
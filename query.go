@@ -1,9 +1,11 @@
 package pgx
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
+	"reflect"
 	"time"
 )
 
@@ -47,8 +49,13 @@ type Rows struct {
 	sql        string
 	args       []interface{}
 	afterClose func(*Rows)
+	batch      *BatchResults // non-nil when rows is a result of a batch
 	unlockConn bool
 	closed     bool
+
+	// cached by ScanStruct
+	scanStructType  reflect.Type
+	scanStructPaths [][]int
 }
 
 func (rows *Rows) FieldDescriptions() []FieldDescription {
@@ -67,6 +74,18 @@ func (rows *Rows) close() {
 
 	rows.closed = true
 
+	rows.err = rows.conn.termContext(rows.err)
+
+	// An error while reading a batch result means the rest of the batch has
+	// been read through ReadyForQuery or the connection is dead.
+	if rows.batch != nil && rows.err != nil {
+		rows.batch.fail(rows.err)
+	}
+
+	if rows.batch == nil {
+		rows.conn.invalidateCachedStatement(rows.sql, rows.err)
+	}
+
 	if rows.err == nil {
 		if rows.conn.shouldLog(LogLevelInfo) {
 			endTime := time.Now()
@@ -76,6 +95,10 @@ func (rows *Rows) close() {
 		rows.conn.log(LogLevelError, "Query", "sql", rows.sql, "args", logQueryArgs(rows.args))
 	}
 
+	if rows.batch == nil && rows.conn.queryDone != nil {
+		rows.conn.queryDone(time.Since(rows.startTime), rows.err)
+	}
+
 	if rows.afterClose != nil {
 		rows.afterClose(rows)
 	}
@@ -92,12 +115,21 @@ func (rows *Rows) readUntilReadyForQuery() {
 		switch t {
 		case readyForQuery:
 			rows.conn.rxReadyForQuery(r)
+			if rows.batch != nil {
+				rows.batch.done = true
+			}
 			rows.close()
 			return
 		case rowDescription:
 		case dataRow:
-		case commandComplete:
-		case bindComplete:
+		case commandComplete, emptyQueryResponse:
+			// A batch result ends at its own CommandComplete unless an error
+			// requires reading through the end of the batch
+			if rows.batch != nil && rows.err == nil {
+				rows.close()
+				return
+			}
+		case parseComplete, bindComplete:
 		case errorResponse:
 			err = rows.conn.rxErrorResponse(r)
 			if rows.err == nil {
@@ -171,6 +203,9 @@ func (rows *Rows) Next() bool {
 		switch t {
 		case readyForQuery:
 			rows.conn.rxReadyForQuery(r)
+			if rows.batch != nil {
+				rows.batch.done = true
+			}
 			rows.close()
 			return false
 		case dataRow:
@@ -182,8 +217,12 @@ func (rows *Rows) Next() bool {
 
 			rows.mr = r
 			return true
-		case commandComplete:
-		case bindComplete:
+		case commandComplete, emptyQueryResponse:
+			if rows.batch != nil {
+				rows.close()
+				return false
+			}
+		case parseComplete, bindComplete:
 		default:
 			err = rows.conn.processContextFreeMsg(t, r)
 			if err != nil {
@@ -215,7 +254,7 @@ func (rows *Rows) nextColumn() (*ValueReader, bool) {
 	fd := &rows.fields[rows.columnIdx]
 	rows.columnIdx++
 	size := rows.mr.readInt32()
-	rows.vr = ValueReader{mr: rows.mr, fd: fd, valueBytesRemaining: size}
+	rows.vr = ValueReader{mr: rows.mr, fd: fd, conn: rows.conn, valueBytesRemaining: size}
 	return &rows.vr, true
 }
 
@@ -246,78 +285,8 @@ func (rows *Rows) Scan(dest ...interface{}) (err error) {
 			continue
 		}
 
-		// Check for []byte first as we allow sidestepping the decoding process and retrieving the raw bytes
-		if b, ok := d.(*[]byte); ok {
-			// If it actually is a bytea then pass it through decodeBytea (so it can be decoded if it is in text format)
-			// Otherwise read the bytes directly regardless of what the actual type is.
-			if vr.Type().DataType == ByteaOid {
-				*b = decodeBytea(vr)
-			} else {
-				if vr.Len() != -1 {
-					*b = vr.ReadBytes(vr.Len())
-				} else {
-					*b = nil
-				}
-			}
-		} else if s, ok := d.(Scanner); ok {
-			err = s.Scan(vr)
-			if err != nil {
-				rows.Fatal(scanArgError{col: i, err: err})
-			}
-		} else if s, ok := d.(PgxScanner); ok {
-			err = s.ScanPgx(vr)
-			if err != nil {
-				rows.Fatal(scanArgError{col: i, err: err})
-			}
-		} else if s, ok := d.(sql.Scanner); ok {
-			var val interface{}
-			if 0 <= vr.Len() {
-				switch vr.Type().DataType {
-				case BoolOid:
-					val = decodeBool(vr)
-				case Int8Oid:
-					val = int64(decodeInt8(vr))
-				case Int2Oid:
-					val = int64(decodeInt2(vr))
-				case Int4Oid:
-					val = int64(decodeInt4(vr))
-				case TextOid, VarcharOid:
-					val = decodeText(vr)
-				case OidOid:
-					val = int64(decodeOid(vr))
-				case Float4Oid:
-					val = float64(decodeFloat4(vr))
-				case Float8Oid:
-					val = decodeFloat8(vr)
-				case DateOid:
-					val = decodeDate(vr)
-				case TimestampOid:
-					val = decodeTimestamp(vr)
-				case TimestampTzOid:
-					val = decodeTimestampTz(vr)
-				default:
-					val = vr.ReadBytes(vr.Len())
-				}
-			}
-			err = s.Scan(val)
-			if err != nil {
-				rows.Fatal(scanArgError{col: i, err: err})
-			}
-		} else if vr.Type().DataType == JsonOid {
-			// Because the argument passed to decodeJSON will escape the heap.
-			// This allows d to be stack allocated and only copied to the heap when
-			// we actually are decoding JSON. This saves one memory allocation per
-			// row.
-			d2 := d
-			decodeJSON(vr, &d2)
-		} else if vr.Type().DataType == JsonbOid {
-			// Same trick as above for getting stack allocation
-			d2 := d
-			decodeJSONB(vr, &d2)
-		} else {
-			if err := Decode(vr, d); err != nil {
-				rows.Fatal(scanArgError{col: i, err: err})
-			}
+		if err := scanValue(vr, d); err != nil {
+			rows.Fatal(scanArgError{col: i, err: err})
 		}
 		if vr.Err() != nil {
 			rows.Fatal(scanArgError{col: i, err: vr.Err()})
@@ -331,7 +300,98 @@ func (rows *Rows) Scan(dest ...interface{}) (err error) {
 	return nil
 }
 
-// Values returns an array of the row values
+// scanValue decodes the value vr reads into d.
+func scanValue(vr *ValueReader, d interface{}) error {
+	// Check for []byte first as we allow sidestepping the decoding process and retrieving the raw bytes
+	if b, ok := d.(*[]byte); ok {
+		// If it actually is a bytea then pass it through decodeBytea (so it can be decoded if it is in text format)
+		// Otherwise read the bytes directly regardless of what the actual type is.
+		if vr.Type().DataType == ByteaOid {
+			*b = decodeBytea(vr)
+		} else {
+			if vr.Len() != -1 {
+				*b = vr.ReadBytes(vr.Len())
+			} else {
+				*b = nil
+			}
+		}
+	} else if s, ok := d.(Scanner); ok {
+		return s.Scan(vr)
+	} else if s, ok := d.(PgxScanner); ok {
+		return s.ScanPgx(vr)
+	} else if s, ok := d.(sql.Scanner); ok {
+		var val interface{}
+		if 0 <= vr.Len() {
+			switch vr.Type().DataType {
+			case BoolOid:
+				val = decodeBool(vr)
+			case Int8Oid:
+				val = int64(decodeInt8(vr))
+			case Int2Oid:
+				val = int64(decodeInt2(vr))
+			case Int4Oid:
+				val = int64(decodeInt4(vr))
+			case TextOid, VarcharOid:
+				val = decodeText(vr)
+			case OidOid:
+				val = int64(decodeOid(vr))
+			case Float4Oid:
+				val = float64(decodeFloat4(vr))
+			case Float8Oid:
+				val = decodeFloat8(vr)
+			case DateOid:
+				val = decodeDate(vr)
+			case TimestampOid:
+				val = decodeTimestamp(vr)
+			case TimestampTzOid:
+				val = decodeTimestampTz(vr)
+			case NumericOid:
+				val = decodeNumericText(vr)
+			case IntervalOid:
+				val = decodeInterval(vr).String()
+			case TimeOid:
+				val = decodeTimeOfDay(vr).String()
+			case TimetzOid:
+				val = decodeTimeOfDay(vr).StringTz()
+			case UuidOid:
+				val = decodeUUID(vr).String()
+			case Int4RangeOid, Int8RangeOid, NumRangeOid, TsRangeOid, TstzRangeOid, DateRangeOid:
+				val = decodeRangeText(vr)
+			case NumericArrayOid, DateArrayOid, UuidArrayOid:
+				val = decodeArrayText(vr)
+			default:
+				if isBinaryEnumArray(vr) {
+					val = decodeArrayText(vr)
+				} else {
+					val = vr.ReadBytes(vr.Len())
+				}
+			}
+		}
+		return s.Scan(val)
+	} else if vr.Type().DataType == JsonOid {
+		// Because the argument passed to decodeJSON will escape the heap.
+		// This allows d to be stack allocated and only copied to the heap when
+		// we actually are decoding JSON. This saves one memory allocation per
+		// row.
+		d2 := d
+		decodeJSON(vr, &d2)
+	} else if vr.Type().DataType == JsonbOid {
+		// Same trick as above for getting stack allocation
+		d2 := d
+		decodeJSONB(vr, &d2)
+	} else {
+		return Decode(vr, d)
+	}
+
+	return nil
+}
+
+// Values returns an array of the row values. Each value is decoded into the
+// same Go type Scan into a *interface{} would produce: the natural type of
+// the data types pgx supports (e.g. int32 for int4 and []net.IPNet for
+// inet[]), the value a registered Codec returns, a string for other text
+// format values, and the raw bytes for other binary format values. NULL is
+// nil.
 func (rows *Rows) Values() ([]interface{}, error) {
 	if rows.closed {
 		return nil, errors.New("rows is closed")
@@ -347,69 +407,7 @@ func (rows *Rows) Values() ([]interface{}, error) {
 			continue
 		}
 
-		switch vr.Type().FormatCode {
-		// All intrinsic types (except string) are encoded with binary
-		// encoding so anything else should be treated as a string
-		case TextFormatCode:
-			values = append(values, vr.ReadString(vr.Len()))
-		case BinaryFormatCode:
-			switch vr.Type().DataType {
-			case TextOid, VarcharOid:
-				values = append(values, decodeText(vr))
-			case BoolOid:
-				values = append(values, decodeBool(vr))
-			case ByteaOid:
-				values = append(values, decodeBytea(vr))
-			case Int8Oid:
-				values = append(values, decodeInt8(vr))
-			case Int2Oid:
-				values = append(values, decodeInt2(vr))
-			case Int4Oid:
-				values = append(values, decodeInt4(vr))
-			case OidOid:
-				values = append(values, decodeOid(vr))
-			case Float4Oid:
-				values = append(values, decodeFloat4(vr))
-			case Float8Oid:
-				values = append(values, decodeFloat8(vr))
-			case BoolArrayOid:
-				values = append(values, decodeBoolArray(vr))
-			case Int2ArrayOid:
-				values = append(values, decodeInt2Array(vr))
-			case Int4ArrayOid:
-				values = append(values, decodeInt4Array(vr))
-			case Int8ArrayOid:
-				values = append(values, decodeInt8Array(vr))
-			case Float4ArrayOid:
-				values = append(values, decodeFloat4Array(vr))
-			case Float8ArrayOid:
-				values = append(values, decodeFloat8Array(vr))
-			case TextArrayOid, VarcharArrayOid:
-				values = append(values, decodeTextArray(vr))
-			case TimestampArrayOid, TimestampTzArrayOid:
-				values = append(values, decodeTimestampArray(vr))
-			case DateOid:
-				values = append(values, decodeDate(vr))
-			case TimestampTzOid:
-				values = append(values, decodeTimestampTz(vr))
-			case TimestampOid:
-				values = append(values, decodeTimestamp(vr))
-			case InetOid, CidrOid:
-				values = append(values, decodeInet(vr))
-			case JsonOid:
-				var d interface{}
-				decodeJSON(vr, &d)
-				values = append(values, d)
-			case JsonbOid:
-				var d interface{}
-				decodeJSONB(vr, &d)
-				values = append(values, d)
-			default:
-				rows.Fatal(errors.New("Values cannot handle binary format non-intrinsic types"))
-			}
-		default:
-			rows.Fatal(errors.New("Unknown format code"))
-		}
+		values = append(values, decodeValue(vr))
 
 		if vr.Err() != nil {
 			rows.Fatal(vr.Err())
@@ -451,18 +449,14 @@ func (c *Conn) Query(sql string, args ...interface{}) (*Rows, error) {
 	}
 	rows.unlockConn = true
 
-	ps, ok := c.preparedStatements[sql]
-	if !ok {
-		var err error
-		ps, err = c.Prepare("", sql)
-		if err != nil {
-			rows.abort(err)
-			return rows, rows.err
-		}
+	ps, err := c.prepareForQuery(sql)
+	if err != nil {
+		rows.abort(err)
+		return rows, rows.err
 	}
 	rows.sql = ps.SQL
 	rows.fields = ps.FieldDescriptions
-	err := c.sendPreparedQuery(ps, args...)
+	err = c.sendPreparedQuery(ps, args...)
 	if err != nil {
 		rows.abort(err)
 	}
@@ -485,6 +479,26 @@ func (c *Conn) getRows(sql string, args []interface{}) *Rows {
 	return r
 }
 
+// QueryContext executes sql with args like Query, but ctx can be used to
+// abandon the query. ctx is watched until the returned *Rows are closed. If ctx
+// is done before then the query is canceled, the connection is closed, and
+// the *Rows will return ctx.Err().
+func (c *Conn) QueryContext(ctx context.Context, sql string, args ...interface{}) (*Rows, error) {
+	if err := c.startContext(ctx); err != nil {
+		// Because checking for errors can be deferred to the *Rows, build one with the error
+		return &Rows{closed: true, err: err}, err
+	}
+
+	return c.Query(sql, args...)
+}
+
+// QueryRowContext is a convenience wrapper over QueryContext. Any error that
+// occurs while querying is deferred until calling Scan on the returned *Row.
+func (c *Conn) QueryRowContext(ctx context.Context, sql string, args ...interface{}) *Row {
+	rows, _ := c.QueryContext(ctx, sql, args...)
+	return (*Row)(rows)
+}
+
 // QueryRow is a convenience wrapper over Query. Any error that occurs while
 // querying is deferred until calling Scan on the returned *Row. That *Row will
 // error with ErrNoRows if no rows are returned.
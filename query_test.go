@@ -2,8 +2,11 @@ package pgx_test
 
 import (
 	"bytes"
+	"context"
 	"database/sql"
 	"fmt"
+	"net"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
@@ -99,6 +102,53 @@ func TestConnQueryValues(t *testing.T) {
 	}
 }
 
+func TestConnQueryValuesTypes(t *testing.T) {
+	t.Parallel()
+
+	conn := mustConnect(t, *defaultConnConfig)
+	defer closeConn(t, conn)
+
+	rows, err := conn.Query(`select 'foo'::name, 'x'::"char", '42'::xid, '43'::cid, '(1,2)'::tid,
+		'00010203-0405-0607-0808-0a0b0c0d0e0f'::uuid, array['127.0.0.1/32'::inet], array['\x0102'::bytea]`)
+	if err != nil {
+		t.Fatalf("conn.Query failed: %v", err)
+	}
+	defer rows.Close()
+
+	_, ipNet, _ := net.ParseCIDR("127.0.0.1/32")
+	expected := []interface{}{
+		pgx.Name("foo"),
+		pgx.Char('x'),
+		pgx.Xid(42),
+		pgx.Cid(43),
+		pgx.Tid{BlockNumber: 1, OffsetNumber: 2},
+		pgx.UUID{0, 1, 2, 3, 4, 5, 6, 7, 8, 8, 10, 11, 12, 13, 14, 15},
+		[]net.IPNet{*ipNet},
+		[][]byte{{1, 2}},
+	}
+
+	for rows.Next() {
+		values, err := rows.Values()
+		if err != nil {
+			t.Fatalf("rows.Values failed: %v", err)
+		}
+		if len(values) != len(expected) {
+			t.Fatalf("Expected rows.Values to return %d values, but it returned %d", len(expected), len(values))
+		}
+		for i := range expected {
+			if !reflect.DeepEqual(values[i], expected[i]) {
+				t.Errorf("Expected values[%d] to be %#v, but it was %#v", i, expected[i], values[i])
+			}
+		}
+	}
+
+	if rows.Err() != nil {
+		t.Fatalf("conn.Query failed: %v", rows.Err())
+	}
+
+	ensureConnValid(t, conn)
+}
+
 // Test that a connection stays valid when query results are closed early
 func TestConnQueryCloseEarly(t *testing.T) {
 	t.Parallel()
@@ -353,6 +403,113 @@ func TestConnQueryPgxScanner(t *testing.T) {
 	ensureConnValid(t, conn)
 }
 
+func TestConnQueryContextSuccess(t *testing.T) {
+	t.Parallel()
+
+	conn := mustConnect(t, *defaultConnConfig)
+	defer closeConn(t, conn)
+
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	defer cancelFunc()
+
+	rows, err := conn.QueryContext(ctx, "select 42::integer")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var result, rowCount int
+	for rows.Next() {
+		rowCount++
+		rows.Scan(&result)
+	}
+
+	if rows.Err() != nil {
+		t.Fatalf("Unexpected error: %v", rows.Err())
+	}
+	if rowCount != 1 {
+		t.Fatalf("Expected 1 row, got %d", rowCount)
+	}
+	if result != 42 {
+		t.Fatalf("Expected result 42, got %d", result)
+	}
+
+	ensureConnValid(t, conn)
+}
+
+func TestConnQueryContextCancelationCancelsQuery(t *testing.T) {
+	t.Parallel()
+
+	conn := mustConnect(t, *defaultConnConfig)
+	defer closeConn(t, conn)
+
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(500 * time.Millisecond)
+		cancelFunc()
+	}()
+
+	rows, err := conn.QueryContext(ctx, "select pg_sleep(5)")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for rows.Next() {
+		t.Fatal("No rows should ever be ready -- context cancel apparently did not happen")
+	}
+
+	if rows.Err() != context.Canceled {
+		t.Fatalf("Expected context.Canceled error, got %v", rows.Err())
+	}
+
+	if conn.IsAlive() {
+		t.Error("Expected connection to be closed after context cancelation")
+	}
+}
+
+func TestConnQueryRowContextSuccess(t *testing.T) {
+	t.Parallel()
+
+	conn := mustConnect(t, *defaultConnConfig)
+	defer closeConn(t, conn)
+
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	defer cancelFunc()
+
+	var result int
+	err := conn.QueryRowContext(ctx, "select 42::integer").Scan(&result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result != 42 {
+		t.Fatalf("Expected result 42, got %d", result)
+	}
+
+	ensureConnValid(t, conn)
+}
+
+func TestConnQueryRowContextCancelationCancelsQuery(t *testing.T) {
+	t.Parallel()
+
+	conn := mustConnect(t, *defaultConnConfig)
+	defer closeConn(t, conn)
+
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(500 * time.Millisecond)
+		cancelFunc()
+	}()
+
+	var result []byte
+	err := conn.QueryRowContext(ctx, "select pg_sleep(5)").Scan(&result)
+	if err != context.Canceled {
+		t.Fatalf("Expected context.Canceled error, got %v", err)
+	}
+
+	if conn.IsAlive() {
+		t.Error("Expected connection to be closed after context cancelation")
+	}
+}
+
 func TestConnQueryErrorWhileReturningRows(t *testing.T) {
 	t.Parallel()
 
@@ -1412,3 +1569,176 @@ func TestConnQueryDatabaseSQLNullX(t *testing.T) {
 
 	ensureConnValid(t, conn)
 }
+
+type scanStructBase struct {
+	ID int32
+}
+
+type scanStructUser struct {
+	scanStructBase
+	FirstName string
+	Surname   string `db:"last_name"`
+	Nickname  *string
+	Ignored   string `db:"-"`
+}
+
+func TestRowsScanStruct(t *testing.T) {
+	t.Parallel()
+
+	conn := mustConnect(t, *defaultConnConfig)
+	defer closeConn(t, conn)
+
+	rows, err := conn.Query(`select n as id, 'John' as first_name, 'Smith' as last_name,
+		case when n % 2 = 0 then 'Johnny' end as nickname
+		from generate_series(1, 2) n`)
+	if err != nil {
+		t.Fatalf("conn.Query failed: %v", err)
+	}
+	defer rows.Close()
+
+	var users []scanStructUser
+	for rows.Next() {
+		var u scanStructUser
+		if err := rows.ScanStruct(&u); err != nil {
+			t.Fatalf("rows.ScanStruct failed: %v", err)
+		}
+		users = append(users, u)
+	}
+	if rows.Err() != nil {
+		t.Fatalf("conn.Query failed: %v", rows.Err())
+	}
+
+	if len(users) != 2 {
+		t.Fatalf("Expected 2 rows, got %d", len(users))
+	}
+	for i, u := range users {
+		if u.ID != int32(i+1) || u.FirstName != "John" || u.Surname != "Smith" {
+			t.Errorf("%d. Unexpected user: %+v", i, u)
+		}
+	}
+	if users[0].Nickname != nil {
+		t.Errorf("Expected NULL nickname to scan to nil, got %v", *users[0].Nickname)
+	}
+	if users[1].Nickname == nil || *users[1].Nickname != "Johnny" {
+		t.Errorf("Expected nickname Johnny, got %v", users[1].Nickname)
+	}
+
+	ensureConnValid(t, conn)
+}
+
+func TestRowsScanStructUnmappedColumn(t *testing.T) {
+	t.Parallel()
+
+	conn := mustConnect(t, *defaultConnConfig)
+	defer closeConn(t, conn)
+
+	var u scanStructUser
+	err := conn.QueryRow("select 1 as id, 'x' as middle_name").ScanStruct(&u)
+	if err == nil || !strings.Contains(err.Error(), "middle_name") {
+		t.Fatalf("Expected unmapped column error, got %v", err)
+	}
+
+	err = conn.QueryRow("select 1 as id, 'x' as ignored").ScanStruct(&u)
+	if err == nil || !strings.Contains(err.Error(), "ignored") {
+		t.Fatalf("Expected unmapped column error, got %v", err)
+	}
+
+	ensureConnValid(t, conn)
+}
+
+func TestRowScanStruct(t *testing.T) {
+	t.Parallel()
+
+	conn := mustConnect(t, *defaultConnConfig)
+	defer closeConn(t, conn)
+
+	var u scanStructUser
+	err := conn.QueryRow("select 7 as id, $1::text as first_name", "Jane").ScanStruct(&u)
+	if err != nil {
+		t.Fatalf("QueryRow.ScanStruct failed: %v", err)
+	}
+	if u.ID != 7 || u.FirstName != "Jane" {
+		t.Errorf("Unexpected user: %+v", u)
+	}
+
+	err = conn.QueryRow("select 1 as id where false").ScanStruct(&u)
+	if err != pgx.ErrNoRows {
+		t.Errorf("Expected ErrNoRows, got %v", err)
+	}
+
+	ensureConnValid(t, conn)
+}
+
+func TestConnSelect(t *testing.T) {
+	t.Parallel()
+
+	conn := mustConnect(t, *defaultConnConfig)
+	defer closeConn(t, conn)
+
+	var users []scanStructUser
+	err := conn.Select(&users, "select n as id, 'user' || n as first_name from generate_series(1, $1) n", 3)
+	if err != nil {
+		t.Fatalf("conn.Select failed: %v", err)
+	}
+	if len(users) != 3 || users[2].ID != 3 || users[2].FirstName != "user3" {
+		t.Errorf("Unexpected users: %+v", users)
+	}
+
+	var ptrs []*scanStructUser
+	err = conn.Select(&ptrs, "select n as id from generate_series(1, 2) n")
+	if err != nil {
+		t.Fatalf("conn.Select failed: %v", err)
+	}
+	if len(ptrs) != 2 || ptrs[1].ID != 2 {
+		t.Errorf("Unexpected users: %+v", ptrs)
+	}
+
+	var ints []int32
+	if err := conn.Select(&ints, "select 1"); err == nil {
+		t.Error("Expected error selecting into a slice of non-structs")
+	}
+
+	ensureConnValid(t, conn)
+}
+
+func TestConnPoolSelect(t *testing.T) {
+	t.Parallel()
+
+	pool := createConnPool(t, 2)
+	defer pool.Close()
+
+	var users []scanStructUser
+	err := pool.Select(&users, "select n as id from generate_series(1, 2) n")
+	if err != nil {
+		t.Fatalf("pool.Select failed: %v", err)
+	}
+	if len(users) != 2 {
+		t.Errorf("Expected 2 rows, got %d", len(users))
+	}
+
+	if stat := pool.Stat(); stat.AvailableConnections != stat.CurrentConnections {
+		t.Errorf("Expected connection to be released, got %+v", stat)
+	}
+}
+
+func TestTxSelect(t *testing.T) {
+	t.Parallel()
+
+	conn := mustConnect(t, *defaultConnConfig)
+	defer closeConn(t, conn)
+
+	tx, err := conn.Begin()
+	if err != nil {
+		t.Fatalf("conn.Begin failed: %v", err)
+	}
+	defer tx.Rollback()
+
+	var users []scanStructUser
+	err = tx.Select(&users, "select 1 as id, 'a' as first_name")
+	if err != nil {
+		t.Fatalf("tx.Select failed: %v", err)
+	}
+	if len(users) != 1 || users[0].FirstName != "a" {
+		t.Errorf("Unexpected users: %+v", users)
+	}
+}
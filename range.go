@@ -0,0 +1,353 @@
+package pgx
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// range flags in the binary format
+const (
+	rangeEmpty          = 0x01
+	rangeLowerInclusive = 0x02
+	rangeUpperInclusive = 0x04
+	rangeLowerUnbounded = 0x08
+	rangeUpperUnbounded = 0x10
+)
+
+// Range represents a value of one of the PostgreSQL built-in range types. It
+// does not support a null value (use NullRange for this). Range implements the
+// Scanner and Encoder interfaces so it may be used both as an argument to
+// Query[Row] and a destination for Scan.
+//
+// The Go type of Lower and Upper depends on the range type:
+//
+//	int4range: int32
+//	int8range: int64
+//	numrange: Numeric
+//	tsrange, tstzrange, daterange: time.Time
+//
+// When encoding, Lower and Upper may be any value that can be encoded as the
+// element type of the range (e.g. an int or *big.Rat). A bound is ignored if
+// the range is empty or the bound is unbounded.
+//
+// PostgreSQL normalizes discrete ranges (int4range, int8range, and daterange)
+// to an inclusive lower bound and an exclusive upper bound, so a value read back
+// may have different bounds than were written.
+type Range struct {
+	Lower          interface{}
+	Upper          interface{}
+	LowerInclusive bool
+	UpperInclusive bool
+	LowerUnbounded bool
+	UpperUnbounded bool
+	Empty          bool
+}
+
+// String returns r in the PostgreSQL text format (e.g. [1,5)). time.Time
+// bounds are formatted as timestamptz values.
+func (r Range) String() string {
+	return formatRange(r, TimestampTzOid)
+}
+
+func (r *Range) Scan(vr *ValueReader) error {
+	if _, ok := rangeElementOid(vr.Type().DataType); !ok {
+		return SerializationError(fmt.Sprintf("Range.Scan cannot decode OID %d", vr.Type().DataType))
+	}
+
+	if vr.Len() == -1 {
+		return SerializationError("Cannot decode null into Range")
+	}
+
+	*r = decodeRange(vr)
+	return vr.Err()
+}
+
+func (r Range) FormatCode() int16 { return BinaryFormatCode }
+
+func (r Range) Encode(w *WriteBuf, oid Oid) error {
+	return encodeRange(w, oid, r)
+}
+
+// NullRange represents a Range that may be null. NullRange implements the
+// Scanner and Encoder interfaces so it may be used both as an argument to
+// Query[Row] and a destination for Scan.
+//
+// If Valid is false then the value is NULL.
+type NullRange struct {
+	Range Range
+	Valid bool // Valid is true if Range is not NULL
+}
+
+func (n *NullRange) Scan(vr *ValueReader) error {
+	if _, ok := rangeElementOid(vr.Type().DataType); !ok {
+		return SerializationError(fmt.Sprintf("NullRange.Scan cannot decode OID %d", vr.Type().DataType))
+	}
+
+	if vr.Len() == -1 {
+		n.Range, n.Valid = Range{}, false
+		return nil
+	}
+
+	n.Valid = true
+	n.Range = decodeRange(vr)
+	return vr.Err()
+}
+
+func (n NullRange) FormatCode() int16 { return BinaryFormatCode }
+
+func (n NullRange) Encode(w *WriteBuf, oid Oid) error {
+	if _, ok := rangeElementOid(oid); !ok {
+		return SerializationError(fmt.Sprintf("NullRange.Encode cannot encode into OID %d", oid))
+	}
+
+	if !n.Valid {
+		w.WriteInt32(-1)
+		return nil
+	}
+
+	return encodeRange(w, oid, n.Range)
+}
+
+// rangeElementOid returns the oid of the element type of the built-in range
+// type oid.
+func rangeElementOid(oid Oid) (Oid, bool) {
+	switch oid {
+	case Int4RangeOid:
+		return Int4Oid, true
+	case Int8RangeOid:
+		return Int8Oid, true
+	case NumRangeOid:
+		return NumericOid, true
+	case TsRangeOid:
+		return TimestampOid, true
+	case TstzRangeOid:
+		return TimestampTzOid, true
+	case DateRangeOid:
+		return DateOid, true
+	default:
+		return 0, false
+	}
+}
+
+func decodeRange(vr *ValueReader) Range {
+	if vr.Len() == -1 {
+		vr.Fatal(ProtocolError("Cannot decode null into Range"))
+		return Range{}
+	}
+
+	elOid, ok := rangeElementOid(vr.Type().DataType)
+	if !ok {
+		vr.Fatal(ProtocolError(fmt.Sprintf("Cannot decode oid %v into Range", vr.Type().DataType)))
+		return Range{}
+	}
+
+	if vr.Type().FormatCode != BinaryFormatCode {
+		vr.Fatal(ProtocolError(fmt.Sprintf("Unknown field description format code: %v", vr.Type().FormatCode)))
+		return Range{}
+	}
+
+	flags := vr.ReadByte()
+
+	r := Range{
+		Empty:          flags&rangeEmpty != 0,
+		LowerInclusive: flags&rangeLowerInclusive != 0,
+		UpperInclusive: flags&rangeUpperInclusive != 0,
+		LowerUnbounded: flags&rangeLowerUnbounded != 0,
+		UpperUnbounded: flags&rangeUpperUnbounded != 0,
+	}
+
+	if r.Empty {
+		return r
+	}
+
+	if !r.LowerUnbounded {
+		r.Lower = decodeRangeBound(vr, elOid)
+	}
+	if !r.UpperUnbounded {
+		r.Upper = decodeRangeBound(vr, elOid)
+	}
+
+	return r
+}
+
+// decodeRangeText decodes a binary format range into its text format.
+func decodeRangeText(vr *ValueReader) string {
+	r := decodeRange(vr)
+	if vr.Err() != nil {
+		return ""
+	}
+	elOid, _ := rangeElementOid(vr.Type().DataType)
+	return formatRange(r, elOid)
+}
+
+// formatRange returns r in the text format of a range of elOid.
+func formatRange(r Range, elOid Oid) string {
+	if r.Empty {
+		return "empty"
+	}
+
+	buf := &bytes.Buffer{}
+	if r.LowerInclusive && !r.LowerUnbounded {
+		buf.WriteByte('[')
+	} else {
+		buf.WriteByte('(')
+	}
+	if !r.LowerUnbounded {
+		buf.WriteString(quoteRangeBound(formatRangeBound(r.Lower, elOid)))
+	}
+	buf.WriteByte(',')
+	if !r.UpperUnbounded {
+		buf.WriteString(quoteRangeBound(formatRangeBound(r.Upper, elOid)))
+	}
+	if r.UpperInclusive && !r.UpperUnbounded {
+		buf.WriteByte(']')
+	} else {
+		buf.WriteByte(')')
+	}
+	return buf.String()
+}
+
+// quoteRangeBound quotes s the way PostgreSQL does in the text format of a
+// range.
+func quoteRangeBound(s string) string {
+	if s != "" && !strings.ContainsAny(s, "\"\\()[], \t\n\r\v\f") {
+		return s
+	}
+	s = strings.Replace(s, `\`, `\\`, -1)
+	s = strings.Replace(s, `"`, `""`, -1)
+	return `"` + s + `"`
+}
+
+// formatRangeBound returns v, a decoded range bound of type elOid, in the
+// PostgreSQL text format.
+func formatRangeBound(v interface{}, elOid Oid) string {
+	switch v := v.(type) {
+	case int32:
+		return strconv.FormatInt(int64(v), 10)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case Numeric:
+		return v.String()
+	case time.Time:
+		switch elOid {
+		case DateOid:
+			return v.Format("2006-01-02")
+		case TimestampOid:
+			// timestamp values are encoded and decoded as UTC
+			return v.UTC().Format("2006-01-02 15:04:05.999999")
+		default:
+			if _, offset := v.Zone(); offset%3600 != 0 {
+				return v.Format("2006-01-02 15:04:05.999999-07:00")
+			}
+			return v.Format("2006-01-02 15:04:05.999999-07")
+		}
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func decodeRangeBound(vr *ValueReader, elOid Oid) interface{} {
+	if vr.Err() != nil {
+		return nil
+	}
+
+	fd := FieldDescription{DataType: elOid, FormatCode: BinaryFormatCode}
+	boundVR := ValueReader{mr: vr.mr, fd: &fd, conn: vr.conn}
+	boundVR.valueBytesRemaining = vr.ReadInt32()
+	if vr.Err() != nil {
+		return nil
+	}
+	if boundVR.valueBytesRemaining < 0 || boundVR.valueBytesRemaining > vr.valueBytesRemaining {
+		vr.Fatal(ProtocolError(fmt.Sprintf("Received an invalid size for a range bound: %d", boundVR.valueBytesRemaining)))
+		return nil
+	}
+	vr.valueBytesRemaining -= boundVR.valueBytesRemaining
+
+	var bound interface{}
+	switch elOid {
+	case Int4Oid:
+		bound = decodeInt4(&boundVR)
+	case Int8Oid:
+		bound = decodeInt8(&boundVR)
+	case NumericOid:
+		bound = decodeNumeric(&boundVR)
+	case TimestampOid:
+		bound = decodeTimestamp(&boundVR)
+	case TimestampTzOid:
+		bound = decodeTimestampTz(&boundVR)
+	case DateOid:
+		bound = decodeDate(&boundVR)
+	}
+
+	if boundVR.Err() != nil {
+		vr.Fatal(boundVR.Err())
+		return nil
+	}
+
+	return bound
+}
+
+func encodeRange(w *WriteBuf, oid Oid, value Range) error {
+	elOid, ok := rangeElementOid(oid)
+	if !ok {
+		return fmt.Errorf("cannot encode %s into oid %v", "Range", oid)
+	}
+
+	var flags byte
+	if value.Empty {
+		flags = rangeEmpty
+	} else {
+		if value.LowerInclusive && !value.LowerUnbounded {
+			flags |= rangeLowerInclusive
+		}
+		if value.UpperInclusive && !value.UpperUnbounded {
+			flags |= rangeUpperInclusive
+		}
+		if value.LowerUnbounded {
+			flags |= rangeLowerUnbounded
+		}
+		if value.UpperUnbounded {
+			flags |= rangeUpperUnbounded
+		}
+	}
+
+	sizeIdx := len(w.buf)
+	w.WriteInt32(0) // size placeholder
+	w.WriteByte(flags)
+
+	if !value.Empty {
+		if !value.LowerUnbounded {
+			if err := encodeRangeBound(w, elOid, value.Lower, "Lower"); err != nil {
+				return err
+			}
+		}
+		if !value.UpperUnbounded {
+			if err := encodeRangeBound(w, elOid, value.Upper, "Upper"); err != nil {
+				return err
+			}
+		}
+	}
+
+	binary.BigEndian.PutUint32(w.buf[sizeIdx:], uint32(len(w.buf)-sizeIdx-4))
+	return nil
+}
+
+func encodeRangeBound(w *WriteBuf, elOid Oid, bound interface{}, name string) error {
+	if bound == nil {
+		return SerializationError(fmt.Sprintf("Cannot encode nil %s of range - set %sUnbounded instead", name, name))
+	}
+
+	sizeIdx := len(w.buf)
+	if err := Encode(w, elOid, bound); err != nil {
+		return err
+	}
+	if int32(binary.BigEndian.Uint32(w.buf[sizeIdx:])) == -1 {
+		return SerializationError(fmt.Sprintf("Cannot encode null %s of range", name))
+	}
+
+	return nil
+}
@@ -0,0 +1,148 @@
+package pgx
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRangeRoundTrip(t *testing.T) {
+	tests := []struct {
+		oid   Oid
+		value Range
+	}{
+		{Int4RangeOid, Range{Lower: int32(1), Upper: int32(10), LowerInclusive: true}},
+		{Int8RangeOid, Range{Lower: int64(-5), UpperUnbounded: true, LowerInclusive: true}},
+		{Int4RangeOid, Range{Empty: true}},
+		{Int4RangeOid, Range{LowerUnbounded: true, UpperUnbounded: true}},
+		{TstzRangeOid, Range{
+			Lower:          time.Date(2016, 1, 1, 9, 0, 0, 0, time.Local),
+			Upper:          time.Date(2016, 1, 1, 17, 30, 0, 0, time.Local),
+			LowerInclusive: true,
+		}},
+		{DateRangeOid, Range{Lower: time.Date(2016, 1, 1, 0, 0, 0, 0, time.Local), UpperUnbounded: true, LowerInclusive: true}},
+	}
+
+	for i, tt := range tests {
+		w := &WriteBuf{}
+		if err := tt.value.Encode(w, tt.oid); err != nil {
+			t.Errorf("%d. Unexpected error: %v", i, err)
+			continue
+		}
+
+		vr := valueReaderFor(w.buf, tt.oid, BinaryFormatCode)
+		var r Range
+		if err := r.Scan(vr); err != nil {
+			t.Errorf("%d. Unexpected error: %v", i, err)
+			continue
+		}
+		if vr.Len() != 0 {
+			t.Errorf("%d. Expected all bytes to be read, %d remaining", i, vr.Len())
+		}
+		if !reflect.DeepEqual(r, tt.value) {
+			t.Errorf("%d. Decoded %#v, expected %#v", i, r, tt.value)
+		}
+	}
+}
+
+func TestRangeEncodeNilBound(t *testing.T) {
+	w := &WriteBuf{}
+	err := Range{Lower: int32(1)}.Encode(w, Int4RangeOid)
+	if err == nil {
+		t.Error("Expected error encoding range with nil Upper")
+	}
+}
+
+func TestRangeString(t *testing.T) {
+	utc := time.FixedZone("", 0)
+	ist := time.FixedZone("", 5*3600+1800)
+
+	tests := []struct {
+		oid      Oid
+		value    Range
+		expected string
+	}{
+		{Int4RangeOid, Range{Lower: int32(1), Upper: int32(10), LowerInclusive: true}, "[1,10)"},
+		{Int8RangeOid, Range{Lower: int64(-5), UpperUnbounded: true, LowerInclusive: true}, "[-5,)"},
+		{Int4RangeOid, Range{Empty: true}, "empty"},
+		{Int4RangeOid, Range{LowerUnbounded: true, UpperUnbounded: true}, "(,)"},
+		{NumRangeOid, Range{Lower: Numeric{Int: big.NewInt(15), Exp: -1, Valid: true}, Upper: Numeric{Int: big.NewInt(2), Valid: true}, UpperInclusive: true}, "(1.5,2]"},
+		{TsRangeOid, Range{LowerUnbounded: true, Upper: time.Date(2016, 1, 1, 17, 30, 0, 500000000, utc)}, `(,"2016-01-01 17:30:00.5")`},
+		{TstzRangeOid, Range{Lower: time.Date(2016, 1, 1, 9, 0, 0, 0, utc), UpperUnbounded: true, LowerInclusive: true}, `["2016-01-01 09:00:00+00",)`},
+		{TstzRangeOid, Range{Lower: time.Date(2016, 1, 1, 9, 0, 0, 0, ist), UpperUnbounded: true, LowerInclusive: true}, `["2016-01-01 09:00:00+05:30",)`},
+		{DateRangeOid, Range{Lower: time.Date(2016, 1, 1, 0, 0, 0, 0, time.Local), Upper: time.Date(2016, 2, 1, 0, 0, 0, 0, time.Local), LowerInclusive: true}, "[2016-01-01,2016-02-01)"},
+	}
+
+	for i, tt := range tests {
+		if s := formatRange(tt.value, mustRangeElementOid(tt.oid)); s != tt.expected {
+			t.Errorf("%d. Expected %s, got %s", i, tt.expected, s)
+		}
+	}
+
+	if s := (Range{Lower: int32(1), Upper: int32(10), LowerInclusive: true}).String(); s != "[1,10)" {
+		t.Errorf("Expected [1,10), got %s", s)
+	}
+}
+
+func mustRangeElementOid(oid Oid) Oid {
+	elOid, ok := rangeElementOid(oid)
+	if !ok {
+		panic(oid)
+	}
+	return elOid
+}
+
+func TestDecodeRangeText(t *testing.T) {
+	w := &WriteBuf{}
+	r := Range{Lower: time.Date(2016, 1, 1, 0, 0, 0, 0, time.Local), Upper: time.Date(2016, 2, 1, 0, 0, 0, 0, time.Local), LowerInclusive: true}
+	if err := r.Encode(w, DateRangeOid); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	vr := valueReaderFor(w.buf, DateRangeOid, BinaryFormatCode)
+	var s string
+	if err := Decode(vr, &s); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if s != "[2016-01-01,2016-02-01)" {
+		t.Errorf("Expected [2016-01-01,2016-02-01), got %s", s)
+	}
+}
+
+func TestQuoteRangeBound(t *testing.T) {
+	tests := []struct {
+		s        string
+		expected string
+	}{
+		{"1", "1"},
+		{"", `""`},
+		{"a b", `"a b"`},
+		{`a"b`, `"a""b"`},
+		{`a\b`, `"a\\b"`},
+		{"a,b", `"a,b"`},
+	}
+
+	for i, tt := range tests {
+		if s := quoteRangeBound(tt.s); s != tt.expected {
+			t.Errorf("%d. Expected %s, got %s", i, tt.expected, s)
+		}
+	}
+}
+
+func TestDecodeRangeInvalidBoundSize(t *testing.T) {
+	// An int8range whose lower bound claims to be longer than the rest of
+	// the range followed by the bytes of another value
+	w := &WriteBuf{}
+	w.WriteInt32(9)
+	w.WriteByte(rangeLowerInclusive | rangeUpperUnbounded)
+	w.WriteInt32(8)
+	w.WriteInt32(1)
+	w.WriteInt32(42)
+
+	vr := valueReaderFor(w.buf, Int8RangeOid, BinaryFormatCode)
+	var r Range
+	if err := r.Scan(vr); err == nil {
+		t.Errorf("Expected error decoding range with invalid bound size, got %#v", r)
+	}
+}
@@ -212,9 +212,9 @@ func (rc *ReplicationConn) readReplicationMessage() (r *ReplicationMessage, err
 
 	switch t {
 	case noticeResponse:
-		pgError := rc.c.rxErrorResponse(reader)
+		notice := rc.c.rxNoticeResponse(reader)
 		if rc.c.shouldLog(LogLevelInfo) {
-			rc.c.log(LogLevelInfo, pgError.Error())
+			rc.c.log(LogLevelInfo, PgError(*notice).Error())
 		}
 	case errorResponse:
 		err = rc.c.rxErrorResponse(reader)
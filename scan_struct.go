@@ -0,0 +1,216 @@
+package pgx
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"unicode"
+)
+
+// ScanStruct reads the values from the current row into the fields of the
+// struct dst points to. Each column is matched to the field with a `db` tag of
+// the same name or, for fields without a tag, the field whose name converted
+// to snake_case (e.g. UserID to user_id) is the column name. Fields tagged
+// `db:"-"` and unexported fields are ignored. The fields of embedded structs
+// are matched as if they were fields of dst. A nil embedded struct pointer is
+// allocated when one of its fields is scanned, so it must be of an exported
+// type. Use pointer or Null* fields for columns that may be NULL.
+//
+// It is an error for a column to have no matching field. Fields without a
+// matching column are left unchanged.
+func (rows *Rows) ScanStruct(dst interface{}) error {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		err := fmt.Errorf("ScanStruct requires a pointer to a struct, got %T", dst)
+		rows.Fatal(err)
+		return err
+	}
+	v = v.Elem()
+
+	if rows.scanStructType != v.Type() {
+		paths, err := structFieldPaths(v.Type(), rows.fields)
+		if err != nil {
+			rows.Fatal(err)
+			return err
+		}
+		rows.scanStructType = v.Type()
+		rows.scanStructPaths = paths
+	}
+
+	dest := make([]interface{}, len(rows.scanStructPaths))
+	for i, path := range rows.scanStructPaths {
+		dest[i] = fieldByIndexAlloc(v, path).Addr().Interface()
+	}
+
+	return rows.Scan(dest...)
+}
+
+// ScanStruct works the same as (*Rows) ScanStruct, but it will return
+// ErrNoRows if the query returned no rows.
+func (r *Row) ScanStruct(dst interface{}) error {
+	rows := (*Rows)(r)
+
+	if rows.Err() != nil {
+		return rows.Err()
+	}
+
+	if !rows.Next() {
+		if rows.Err() == nil {
+			return ErrNoRows
+		}
+		return rows.Err()
+	}
+
+	rows.ScanStruct(dst)
+	rows.Close()
+	return rows.Err()
+}
+
+// Select executes sql with args and scans each row into a new element of the
+// slice dst points to with ScanStruct. The slice elements may be structs or
+// pointers to structs. The rows are appended to the slice.
+func (c *Conn) Select(dst interface{}, sql string, args ...interface{}) error {
+	return selectStructs(dst, func() (*Rows, error) { return c.Query(sql, args...) })
+}
+
+// selectStructs is the implementation of Select for Conn, ConnPool, and Tx.
+func selectStructs(dst interface{}, query func() (*Rows, error)) error {
+	slice := reflect.ValueOf(dst)
+	if slice.Kind() != reflect.Ptr || slice.IsNil() || slice.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("Select requires a pointer to a slice, got %T", dst)
+	}
+	slice = slice.Elem()
+
+	elType := slice.Type().Elem()
+	isPtr := elType.Kind() == reflect.Ptr
+	if isPtr {
+		elType = elType.Elem()
+	}
+	if elType.Kind() != reflect.Struct {
+		return fmt.Errorf("Select requires a slice of structs or pointers to structs, got %T", dst)
+	}
+
+	rows, err := query()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		el := reflect.New(elType)
+		if err := rows.ScanStruct(el.Interface()); err != nil {
+			return err
+		}
+		if isPtr {
+			slice.Set(reflect.Append(slice, el))
+		} else {
+			slice.Set(reflect.Append(slice, el.Elem()))
+		}
+	}
+
+	return rows.Err()
+}
+
+// structFieldPaths returns the index path of the field of t that each of
+// fields is scanned into.
+func structFieldPaths(t reflect.Type, fields []FieldDescription) ([][]int, error) {
+	byName := make(map[string][]int)
+	addStructFields(t, nil, byName)
+
+	paths := make([][]int, len(fields))
+	for i, fd := range fields {
+		path, ok := byName[fd.Name]
+		if !ok {
+			return nil, fmt.Errorf("ScanStruct cannot find field for column %q in %v", fd.Name, t)
+		}
+		paths[i] = path
+	}
+
+	return paths, nil
+}
+
+// addStructFields adds the column names of the fields of t to byName. The
+// fields of embedded structs are added after the fields of t so, as with Go
+// field selectors, a shallower field hides a deeper one of the same name.
+func addStructFields(t reflect.Type, index []int, byName map[string][]int) {
+	var embedded []reflect.StructField
+
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag := sf.Tag.Get("db")
+		if tag == "-" {
+			continue
+		}
+
+		if sf.Anonymous && tag == "" {
+			ft := sf.Type
+			if ft.Kind() == reflect.Ptr {
+				// A nil pointer to an unexported struct cannot be allocated
+				if sf.PkgPath != "" {
+					continue
+				}
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				embedded = append(embedded, sf)
+				continue
+			}
+		}
+
+		if sf.PkgPath != "" {
+			continue // unexported
+		}
+
+		name := tag
+		if name == "" {
+			name = toSnakeCase(sf.Name)
+		}
+		if _, ok := byName[name]; !ok {
+			byName[name] = append(append([]int{}, index...), i)
+		}
+	}
+
+	for _, sf := range embedded {
+		ft := sf.Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		addStructFields(ft, append(append([]int{}, index...), sf.Index...), byName)
+	}
+}
+
+// fieldByIndexAlloc is like reflect.Value.FieldByIndex, but it allocates nil
+// embedded struct pointers instead of panicking.
+func fieldByIndexAlloc(v reflect.Value, index []int) reflect.Value {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v
+}
+
+// toSnakeCase converts a Go field name to snake_case. A run of upper case
+// letters is treated as one word (e.g. HTTPServerID becomes http_server_id).
+func toSnakeCase(s string) string {
+	runes := []rune(s)
+	var b bytes.Buffer
+
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			if i > 0 && (unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1]) ||
+				(i+1 < len(runes) && unicode.IsLower(runes[i+1]) && unicode.IsUpper(runes[i-1]))) {
+				b.WriteByte('_')
+			}
+			b.WriteRune(unicode.ToLower(r))
+		} else {
+			b.WriteRune(r)
+		}
+	}
+
+	return b.String()
+}
@@ -0,0 +1,82 @@
+package pgx
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestToSnakeCase(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{"ID", "id"},
+		{"Name", "name"},
+		{"UserID", "user_id"},
+		{"FirstName", "first_name"},
+		{"HTTPServerID", "http_server_id"},
+		{"Address2", "address2"},
+		{"Int64Value", "int64_value"},
+		{"already_snake", "already_snake"},
+	}
+
+	for i, tt := range tests {
+		if s := toSnakeCase(tt.name); s != tt.expected {
+			t.Errorf("%d. toSnakeCase(%q) => %q, expected %q", i, tt.name, s, tt.expected)
+		}
+	}
+}
+
+type scanStructBase struct {
+	ID      int32
+	Created string
+}
+
+type ScanStructAudit struct {
+	UpdatedBy string
+}
+
+type scanStructUser struct {
+	scanStructBase
+	*ScanStructAudit
+	Created   string // hides scanStructBase.Created
+	FirstName string `db:"given_name"`
+	Password  string `db:"-"`
+	secret    string
+	Nickname  *string
+}
+
+func TestStructFieldPaths(t *testing.T) {
+	typ := reflect.TypeOf(scanStructUser{})
+	fields := []FieldDescription{
+		{Name: "id"},
+		{Name: "created"},
+		{Name: "updated_by"},
+		{Name: "given_name"},
+		{Name: "nickname"},
+	}
+
+	paths, err := structFieldPaths(typ, fields)
+	if err != nil {
+		t.Fatalf("Unexpected failure: %v", err)
+	}
+
+	expected := [][]int{{0, 0}, {2}, {1, 0}, {3}, {6}}
+	if !reflect.DeepEqual(paths, expected) {
+		t.Errorf("structFieldPaths => %v, expected %v", paths, expected)
+	}
+
+	var u scanStructUser
+	fieldByIndexAlloc(reflect.ValueOf(&u).Elem(), paths[2]).SetString("bob")
+	if u.ScanStructAudit == nil || u.UpdatedBy != "bob" {
+		t.Errorf("fieldByIndexAlloc did not allocate embedded pointer: %v", u.ScanStructAudit)
+	}
+
+	for _, name := range []string{"password", "secret", "first_name", "missing"} {
+		_, err := structFieldPaths(typ, []FieldDescription{{Name: name}})
+		if err == nil || !strings.Contains(err.Error(), name) {
+			t.Errorf("Expected error for unmapped column %q, got %v", name, err)
+		}
+	}
+}
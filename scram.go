@@ -0,0 +1,292 @@
+package pgx
+
+// SCRAM-SHA-256 and SCRAM-SHA-256-PLUS authentication as described in
+// RFC 5802, RFC 7677 and the PostgreSQL SASL authentication documentation.
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"hash"
+	"strconv"
+)
+
+const (
+	scramSHA256     = "SCRAM-SHA-256"
+	scramSHA256Plus = "SCRAM-SHA-256-PLUS"
+
+	clientNonceLen = 18
+)
+
+// ErrChannelBindingRequired occurs when ConnConfig.ChannelBinding is "require"
+// and the server does not offer SCRAM-SHA-256-PLUS, the connection is not
+// using TLS, or the server authenticates the client any other way.
+var ErrChannelBindingRequired = errors.New("channel binding required but not available")
+
+// scramClient holds the state of a SCRAM exchange between the
+// AuthenticationSASL, AuthenticationSASLContinue and AuthenticationSASLFinal
+// messages.
+type scramClient struct {
+	mechanism       string
+	password        string
+	gs2Header       string
+	channelBinding  []byte
+	clientNonce     string
+	clientFirstBare string
+	serverSignature []byte
+}
+
+// rxAuthenticationSASL selects a mechanism from those offered by the server
+// and sends the SASLInitialResponse.
+func (c *Conn) rxAuthenticationSASL(r *msgReader) error {
+	var offered []string
+	for {
+		mech := r.readCString()
+		if mech == "" {
+			break
+		}
+		offered = append(offered, mech)
+	}
+
+	sc := &scramClient{password: c.config.Password}
+
+	tlsConn, _ := c.conn.(*tls.Conn)
+	canBind := tlsConn != nil && c.config.ChannelBinding != "disable"
+
+	switch {
+	case canBind && containsString(offered, scramSHA256Plus):
+		cbData, err := tlsServerEndPoint(tlsConn)
+		if err != nil {
+			return err
+		}
+		sc.mechanism = scramSHA256Plus
+		sc.gs2Header = "p=tls-server-end-point,,"
+		sc.channelBinding = cbData
+	case c.config.ChannelBinding == "require":
+		return ErrChannelBindingRequired
+	case containsString(offered, scramSHA256):
+		sc.mechanism = scramSHA256
+		if canBind {
+			// Client supports channel binding but the server did not offer it
+			sc.gs2Header = "y,,"
+		} else {
+			sc.gs2Header = "n,,"
+		}
+	default:
+		return fmt.Errorf("no supported SASL mechanism offered by server: %v", offered)
+	}
+
+	nonce := make([]byte, clientNonceLen)
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	sc.clientNonce = base64.RawStdEncoding.EncodeToString(nonce)
+
+	// PostgreSQL ignores the SCRAM user name in favor of the startup message
+	// user so it is left empty.
+	sc.clientFirstBare = "n=,r=" + sc.clientNonce
+	clientFirst := sc.gs2Header + sc.clientFirstBare
+
+	c.scram = sc
+
+	wbuf := newWriteBuf(c, 'p')
+	wbuf.WriteCString(sc.mechanism)
+	wbuf.WriteInt32(int32(len(clientFirst)))
+	wbuf.WriteBytes([]byte(clientFirst))
+	wbuf.closeMsg()
+
+	_, err := c.conn.Write(wbuf.buf)
+	return err
+}
+
+// rxAuthenticationSASLContinue processes the server-first-message and sends
+// the client-final-message containing the client proof.
+func (c *Conn) rxAuthenticationSASLContinue(r *msgReader) error {
+	if c.scram == nil {
+		return errors.New("received AuthenticationSASLContinue without AuthenticationSASL")
+	}
+
+	clientFinal, err := c.scram.clientFinalMessage(r.readBytes(r.msgBytesRemaining))
+	if err != nil {
+		return err
+	}
+
+	wbuf := newWriteBuf(c, 'p')
+	wbuf.WriteBytes([]byte(clientFinal))
+	wbuf.closeMsg()
+
+	_, err = c.conn.Write(wbuf.buf)
+	return err
+}
+
+// rxAuthenticationSASLFinal verifies the server signature. This proves the
+// server also knows the password and prevents a man-in-the-middle from
+// accepting any client proof.
+func (c *Conn) rxAuthenticationSASLFinal(r *msgReader) error {
+	sc := c.scram
+	c.scram = nil
+	if sc == nil {
+		return errors.New("received AuthenticationSASLFinal without AuthenticationSASL")
+	}
+
+	if err := sc.verifyServerFinal(r.readBytes(r.msgBytesRemaining)); err != nil {
+		return err
+	}
+	c.scramMechanism = sc.mechanism
+	return nil
+}
+
+// clientFinalMessage computes the client-final-message for serverFirst and
+// records the expected server signature.
+func (sc *scramClient) clientFinalMessage(serverFirst []byte) (string, error) {
+	attrs, err := parseSCRAMAttributes(serverFirst)
+	if err != nil {
+		return "", err
+	}
+
+	serverNonce := attrs['r']
+	if len(serverNonce) <= len(sc.clientNonce) || serverNonce[:len(sc.clientNonce)] != sc.clientNonce {
+		return "", errors.New("SCRAM server nonce does not extend client nonce")
+	}
+
+	salt, err := base64.StdEncoding.DecodeString(attrs['s'])
+	if err != nil {
+		return "", fmt.Errorf("invalid SCRAM salt: %v", err)
+	}
+
+	iterations, err := strconv.Atoi(attrs['i'])
+	if err != nil || iterations < 1 {
+		return "", fmt.Errorf("invalid SCRAM iteration count: %q", attrs['i'])
+	}
+
+	cbind := append([]byte(sc.gs2Header), sc.channelBinding...)
+	clientFinalWithoutProof := "c=" + base64.StdEncoding.EncodeToString(cbind) + ",r=" + serverNonce
+	authMessage := []byte(sc.clientFirstBare + "," + string(serverFirst) + "," + clientFinalWithoutProof)
+
+	saltedPassword := pbkdf2SHA256([]byte(sc.password), salt, iterations)
+
+	clientKey := computeHMAC(saltedPassword, []byte("Client Key"))
+	storedKey := sha256.Sum256(clientKey)
+	clientSignature := computeHMAC(storedKey[:], authMessage)
+	clientProof := make([]byte, len(clientKey))
+	for i := range clientKey {
+		clientProof[i] = clientKey[i] ^ clientSignature[i]
+	}
+
+	serverKey := computeHMAC(saltedPassword, []byte("Server Key"))
+	sc.serverSignature = computeHMAC(serverKey, authMessage)
+
+	return clientFinalWithoutProof + ",p=" + base64.StdEncoding.EncodeToString(clientProof), nil
+}
+
+// verifyServerFinal checks the server-final-message against the server
+// signature computed by clientFinalMessage.
+func (sc *scramClient) verifyServerFinal(serverFinal []byte) error {
+	if sc.serverSignature == nil {
+		return errors.New("received SCRAM server-final-message before client-final-message")
+	}
+
+	attrs, err := parseSCRAMAttributes(serverFinal)
+	if err != nil {
+		return err
+	}
+
+	if e, ok := attrs['e']; ok {
+		return fmt.Errorf("SCRAM authentication failed: %s", e)
+	}
+
+	serverSignature, err := base64.StdEncoding.DecodeString(attrs['v'])
+	if err != nil {
+		return fmt.Errorf("invalid SCRAM server signature: %v", err)
+	}
+
+	if !hmac.Equal(serverSignature, sc.serverSignature) {
+		return errors.New("SCRAM server signature does not match")
+	}
+
+	return nil
+}
+
+// parseSCRAMAttributes parses a comma separated list of single letter
+// attributes (e.g. "r=abc,s=def,i=4096").
+func parseSCRAMAttributes(buf []byte) (map[byte]string, error) {
+	attrs := make(map[byte]string)
+	for _, part := range bytes.Split(buf, []byte(",")) {
+		if len(part) < 2 || part[1] != '=' {
+			return nil, fmt.Errorf("invalid SCRAM message: %q", buf)
+		}
+		attrs[part[0]] = string(part[2:])
+	}
+	return attrs, nil
+}
+
+// tlsServerEndPoint returns the tls-server-end-point channel binding data
+// defined by RFC 5929: a hash of the server's certificate using the hash of
+// its signature algorithm, with MD5 and SHA-1 upgraded to SHA-256.
+func tlsServerEndPoint(conn *tls.Conn) ([]byte, error) {
+	state := conn.ConnectionState()
+	if len(state.PeerCertificates) == 0 {
+		return nil, errors.New("no server certificate available for channel binding")
+	}
+	cert := state.PeerCertificates[0]
+
+	var h hash.Hash
+	switch cert.SignatureAlgorithm {
+	case x509.SHA384WithRSA, x509.ECDSAWithSHA384, x509.SHA384WithRSAPSS:
+		h = sha512.New384()
+	case x509.SHA512WithRSA, x509.ECDSAWithSHA512, x509.SHA512WithRSAPSS:
+		h = sha512.New()
+	case x509.PureEd25519, x509.UnknownSignatureAlgorithm:
+		return nil, fmt.Errorf("channel binding is not supported for certificate signature algorithm %v", cert.SignatureAlgorithm)
+	default:
+		h = sha256.New()
+	}
+
+	h.Write(cert.Raw)
+	return h.Sum(nil), nil
+}
+
+func computeHMAC(key, msg []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(msg)
+	return mac.Sum(nil)
+}
+
+// pbkdf2SHA256 computes the SCRAM Hi() function. SCRAM-SHA-256 only needs a
+// single block of output so the general PBKDF2 block loop is unnecessary.
+func pbkdf2SHA256(password, salt []byte, iterations int) []byte {
+	mac := hmac.New(sha256.New, password)
+	mac.Write(salt)
+	mac.Write([]byte{0, 0, 0, 1})
+	u := mac.Sum(nil)
+
+	result := make([]byte, len(u))
+	copy(result, u)
+
+	for i := 1; i < iterations; i++ {
+		mac.Reset()
+		mac.Write(u)
+		u = mac.Sum(u[:0])
+		for j := range result {
+			result[j] ^= u[j]
+		}
+	}
+
+	return result
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,143 @@
+package pgx
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+// Test vector from RFC 7677 section 3
+func TestSCRAMClientRFC7677(t *testing.T) {
+	t.Parallel()
+
+	sc := &scramClient{
+		password:        "pencil",
+		gs2Header:       "n,,",
+		clientNonce:     "rOprNGfwEbeRWgbNEkqO",
+		clientFirstBare: "n=user,r=rOprNGfwEbeRWgbNEkqO",
+	}
+
+	clientFinal, err := sc.clientFinalMessage([]byte("r=rOprNGfwEbeRWgbNEkqO%hvYDpWUa2RaTCAfuxFIlj)hNlF$k0,s=W22ZaJ0SNY7soEsUEjb6gQ==,i=4096"))
+	if err != nil {
+		t.Fatalf("clientFinalMessage failed: %v", err)
+	}
+
+	expected := "c=biws,r=rOprNGfwEbeRWgbNEkqO%hvYDpWUa2RaTCAfuxFIlj)hNlF$k0,p=dHzbZapWIk4jUhN+Ute9ytag9zjfMHgsqmmiz7AndVQ="
+	if clientFinal != expected {
+		t.Errorf("Expected client-final-message %q, got %q", expected, clientFinal)
+	}
+
+	if err := sc.verifyServerFinal([]byte("v=6rriTRBi23WpRR/wtup+mMhUZUn/dB5nLTJRsjl95G4=")); err != nil {
+		t.Errorf("verifyServerFinal failed: %v", err)
+	}
+
+	if err := sc.verifyServerFinal([]byte("v=AAAATRBi23WpRR/wtup+mMhUZUn/dB5nLTJRsjl95G4=")); err == nil {
+		t.Error("Expected verifyServerFinal to reject wrong server signature")
+	}
+
+	if err := sc.verifyServerFinal([]byte("e=invalid-proof")); err == nil {
+		t.Error("Expected verifyServerFinal to return server error")
+	}
+}
+
+func TestSCRAMClientRejectsBadServerNonce(t *testing.T) {
+	t.Parallel()
+
+	sc := &scramClient{
+		password:        "pencil",
+		gs2Header:       "n,,",
+		clientNonce:     "rOprNGfwEbeRWgbNEkqO",
+		clientFirstBare: "n=,r=rOprNGfwEbeRWgbNEkqO",
+	}
+
+	_, err := sc.clientFinalMessage([]byte("r=somethingElse,s=W22ZaJ0SNY7soEsUEjb6gQ==,i=4096"))
+	if err == nil {
+		t.Fatal("Expected error for server nonce that does not extend client nonce")
+	}
+}
+
+// authMsgReader returns a msgReader for an Authentication message body.
+func authMsgReader(authType int32, body []byte) *msgReader {
+	buf := make([]byte, 4, 4+len(body))
+	binary.BigEndian.PutUint32(buf, uint32(authType))
+	buf = append(buf, body...)
+	return &msgReader{
+		reader:            bufio.NewReader(bytes.NewReader(buf)),
+		msgBytesRemaining: int32(len(buf)),
+		shouldLog:         func(int) bool { return false },
+	}
+}
+
+// rfc7677ScramClient returns a scramClient that has sent the
+// client-final-message of the RFC 7677 test vector.
+func rfc7677ScramClient(t *testing.T, mechanism string) *scramClient {
+	sc := &scramClient{
+		mechanism:       mechanism,
+		password:        "pencil",
+		gs2Header:       "n,,",
+		clientNonce:     "rOprNGfwEbeRWgbNEkqO",
+		clientFirstBare: "n=user,r=rOprNGfwEbeRWgbNEkqO",
+	}
+	if _, err := sc.clientFinalMessage([]byte("r=rOprNGfwEbeRWgbNEkqO%hvYDpWUa2RaTCAfuxFIlj)hNlF$k0,s=W22ZaJ0SNY7soEsUEjb6gQ==,i=4096")); err != nil {
+		t.Fatalf("clientFinalMessage failed: %v", err)
+	}
+	return sc
+}
+
+func TestRxAuthenticationXRejectsSkippedSASLFinal(t *testing.T) {
+	t.Parallel()
+
+	c := &Conn{scram: rfc7677ScramClient(t, scramSHA256)}
+	if err := c.rxAuthenticationX(authMsgReader(0, nil)); err == nil {
+		t.Error("Expected AuthenticationOk before AuthenticationSASLFinal to fail")
+	}
+}
+
+func TestRxAuthenticationXSASLFinal(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		mechanism      string
+		channelBinding string
+		err            error
+	}{
+		{scramSHA256, "prefer", nil},
+		{scramSHA256, "require", ErrChannelBindingRequired},
+		{scramSHA256Plus, "require", nil},
+	}
+
+	for i, tt := range tests {
+		c := &Conn{scram: rfc7677ScramClient(t, tt.mechanism)}
+		c.config.ChannelBinding = tt.channelBinding
+
+		if err := c.rxAuthenticationX(authMsgReader(12, []byte("v=6rriTRBi23WpRR/wtup+mMhUZUn/dB5nLTJRsjl95G4="))); err != nil {
+			t.Errorf("%d. AuthenticationSASLFinal failed: %v", i, err)
+			continue
+		}
+		if err := c.rxAuthenticationX(authMsgReader(0, nil)); err != tt.err {
+			t.Errorf("%d. Expected AuthenticationOk to return %v, got %v", i, tt.err, err)
+		}
+	}
+}
+
+func TestRxAuthenticationXChannelBindingRequired(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		authType int32
+		body     []byte
+	}{
+		{0, nil},            // trust
+		{3, nil},            // cleartext password
+		{5, []byte("salt")}, // MD5 password
+	}
+
+	for i, tt := range tests {
+		c := &Conn{}
+		c.config.ChannelBinding = "require"
+		if err := c.rxAuthenticationX(authMsgReader(tt.authType, tt.body)); err != ErrChannelBindingRequired {
+			t.Errorf("%d. Expected %v, got %v", i, ErrChannelBindingRequired, err)
+		}
+	}
+}
@@ -1,7 +1,15 @@
 package pgx
 
 import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"reflect"
 	"strconv"
+	"strings"
+	"time"
 )
 
 // QueryArgs is a container for arguments to an SQL query. It is helpful when
@@ -27,3 +35,220 @@ func (qa *QueryArgs) Append(v interface{}) string {
 	}
 	return "$" + strconv.Itoa(len(*qa))
 }
+
+// Identifier is a PostgreSQL identifier or name. Identifiers can be composed
+// of multiple parts such as []string{"schema", "table"} or
+// []string{"table", "column"}.
+type Identifier []string
+
+// Sanitize returns ident quoted and escaped so it is safe for SQL
+// interpolation. PostgreSQL names cannot contain a zero byte so any are
+// removed. Where pgx interpolates identifiers itself it returns an error for
+// such names instead of silently referencing a different one.
+func (ident Identifier) Sanitize() string {
+	parts := make([]string, len(ident))
+	for i := range ident {
+		s := strings.Replace(ident[i], "\x00", "", -1)
+		parts[i] = `"` + strings.Replace(s, `"`, `""`, -1) + `"`
+	}
+	return strings.Join(parts, ".")
+}
+
+// validateIdentifier returns an error if any of names, which are interpolated
+// into SQL as identifiers, contains a zero byte.
+func validateIdentifier(names ...string) error {
+	for _, s := range names {
+		if strings.IndexByte(s, 0) != -1 {
+			return fmt.Errorf("Cannot interpolate identifier containing a zero byte into SQL")
+		}
+	}
+	return nil
+}
+
+// sanitizeSQL replaces the $1, $2, etc. placeholders in sql with args quoted
+// as SQL literals. It is used where PostgreSQL does not accept bind
+// parameters such as COPY (query) TO. Placeholders in string literals, quoted
+// identifiers and comments are left untouched.
+func sanitizeSQL(sql string, args ...interface{}) (string, error) {
+	buf := &bytes.Buffer{}
+
+	for i := 0; i < len(sql); {
+		start := i
+
+		switch c := sql[i]; {
+		case c == '\'':
+			i = skipQuoted(sql, i, '\'', i > 0 && (sql[i-1] == 'e' || sql[i-1] == 'E') && (i < 2 || !isIdentByte(sql[i-2])))
+		case c == '"':
+			i = skipQuoted(sql, i, '"', false)
+		case c == '-' && strings.HasPrefix(sql[i:], "--"):
+			if n := strings.IndexByte(sql[i:], '\n'); n != -1 {
+				i += n + 1
+			} else {
+				i = len(sql)
+			}
+		case c == '/' && strings.HasPrefix(sql[i:], "/*"):
+			i = skipBlockComment(sql, i)
+		case c == '$' && (i == 0 || !isIdentByte(sql[i-1])):
+			j := i + 1
+			for j < len(sql) && '0' <= sql[j] && sql[j] <= '9' {
+				j++
+			}
+			if j > i+1 {
+				n, err := strconv.Atoi(sql[i+1 : j])
+				if err != nil || n < 1 || n > len(args) {
+					return "", fmt.Errorf("No argument for placeholder %s", sql[i:j])
+				}
+				literal, err := quoteLiteral(args[n-1])
+				if err != nil {
+					return "", err
+				}
+				buf.WriteString(literal)
+				i = j
+				continue
+			}
+			i = skipDollarQuoted(sql, i)
+		default:
+			i++
+		}
+
+		buf.WriteString(sql[start:i])
+	}
+
+	return buf.String(), nil
+}
+
+func isIdentByte(c byte) bool {
+	return c == '_' || c == '$' || c >= 0x80 ||
+		('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9')
+}
+
+// skipQuoted returns the index after the quoted string or identifier starting
+// at sql[i]. Quotes are escaped by doubling them and also by a backslash when
+// backslashEscapes is true.
+func skipQuoted(sql string, i int, quote byte, backslashEscapes bool) int {
+	for i++; i < len(sql); i++ {
+		switch sql[i] {
+		case '\\':
+			if backslashEscapes {
+				i++
+			}
+		case quote:
+			if i+1 < len(sql) && sql[i+1] == quote {
+				i++
+				continue
+			}
+			return i + 1
+		}
+	}
+	return len(sql)
+}
+
+// skipBlockComment returns the index after the possibly nested /* */ comment
+// starting at sql[i].
+func skipBlockComment(sql string, i int) int {
+	depth := 0
+	for i < len(sql) {
+		switch {
+		case strings.HasPrefix(sql[i:], "/*"):
+			depth++
+			i += 2
+		case strings.HasPrefix(sql[i:], "*/"):
+			depth--
+			i += 2
+			if depth == 0 {
+				return i
+			}
+		default:
+			i++
+		}
+	}
+	return len(sql)
+}
+
+// skipDollarQuoted returns the index after the dollar quoted string starting
+// at sql[i]. If sql[i] does not start a dollar quoted string it returns i+1.
+func skipDollarQuoted(sql string, i int) int {
+	j := i + 1
+	for j < len(sql) && sql[j] != '$' {
+		if !isIdentByte(sql[j]) {
+			return i + 1
+		}
+		j++
+	}
+	if j == len(sql) {
+		return i + 1
+	}
+
+	tag := sql[i : j+1]
+	if n := strings.Index(sql[j+1:], tag); n != -1 {
+		return j + 1 + n + len(tag)
+	}
+	return len(sql)
+}
+
+// quoteLiteral returns arg as an SQL literal.
+func quoteLiteral(arg interface{}) (string, error) {
+	switch arg := arg.(type) {
+	case nil:
+		return "null", nil
+	case string:
+		return quoteString(arg)
+	case []byte:
+		if arg == nil {
+			return "null", nil
+		}
+		return `E'\\x` + hex.EncodeToString(arg) + `'::bytea`, nil
+	case bool:
+		return strconv.FormatBool(arg), nil
+	case time.Time:
+		return `'` + arg.Format(time.RFC3339Nano) + `'::timestamptz`, nil
+	case driver.Valuer:
+		v, err := arg.Value()
+		if err != nil {
+			return "", err
+		}
+		return quoteLiteral(v)
+	}
+
+	refVal := reflect.ValueOf(arg)
+	switch refVal.Kind() {
+	case reflect.Ptr:
+		if refVal.IsNil() {
+			return "null", nil
+		}
+		return quoteLiteral(refVal.Elem().Interface())
+	case reflect.String:
+		return quoteString(refVal.String())
+	case reflect.Bool:
+		return strconv.FormatBool(refVal.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		// Parenthesized so a negative number following a minus cannot form a
+		// comment
+		return "(" + strconv.FormatInt(refVal.Int(), 10) + ")", nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(refVal.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		f := refVal.Float()
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return `'` + strconv.FormatFloat(f, 'g', -1, 64) + `'::float8`, nil
+		}
+		return "(" + strconv.FormatFloat(f, 'g', -1, 64) + ")", nil
+	}
+
+	return "", fmt.Errorf("Cannot interpolate %T into SQL", arg)
+}
+
+// quoteString returns s as an SQL string literal. An escape string literal is
+// used when s contains a backslash so the result does not depend on
+// standard_conforming_strings.
+func quoteString(s string) (string, error) {
+	if strings.IndexByte(s, 0) != -1 {
+		return "", fmt.Errorf("Cannot interpolate string containing a zero byte into SQL")
+	}
+
+	s = strings.Replace(s, `'`, `''`, -1)
+	if strings.IndexByte(s, '\\') == -1 {
+		return `'` + s + `'`, nil
+	}
+	return `E'` + strings.Replace(s, `\`, `\\`, -1) + `'`, nil
+}
@@ -0,0 +1,68 @@
+package pgx
+
+import (
+	"database/sql/driver"
+	"math"
+	"testing"
+	"time"
+)
+
+type sanitizeValuer string
+
+func (v sanitizeValuer) Value() (driver.Value, error) {
+	return string(v), nil
+}
+
+func TestSanitizeSQL(t *testing.T) {
+	tests := []struct {
+		sql      string
+		args     []interface{}
+		expected string
+	}{
+		{"select $1", []interface{}{int32(1)}, "select (1)"},
+		{"select 1-$1", []interface{}{-1}, "select 1-(-1)"},
+		{"select $1, $2", []interface{}{"foo", nil}, "select 'foo', null"},
+		{"select $1", []interface{}{"o'clock"}, "select 'o''clock'"},
+		{"select $1", []interface{}{`back\slash`}, `select E'back\\slash'`},
+		{"select $1", []interface{}{[]byte{1, 255}}, `select E'\\x01ff'::bytea`},
+		{"select $1, $2", []interface{}{true, uint16(7)}, "select true, 7"},
+		{"select $1, $2", []interface{}{1.5, math.NaN()}, "select (1.5), 'NaN'::float8"},
+		{"select $1", []interface{}{time.Date(2016, 1, 2, 3, 4, 5, 0, time.UTC)}, "select '2016-01-02T03:04:05Z'::timestamptz"},
+		{"select $1", []interface{}{sanitizeValuer("foo")}, "select 'foo'"},
+		{"select $1", []interface{}{(*string)(nil)}, "select null"},
+		{"select $10", []interface{}{1, 2, 3, 4, 5, 6, 7, 8, 9, "ten"}, "select 'ten'"},
+		{"select '$1', \"$1\", $1", []interface{}{1}, "select '$1', \"$1\", (1)"},
+		{"select 'it''s $1', $1", []interface{}{1}, "select 'it''s $1', (1)"},
+		{`select E'\'$1', $1`, []interface{}{1}, `select E'\'$1', (1)`},
+		{"select $tag$ $1 $tag$, $$ $1 $$, $1", []interface{}{1}, "select $tag$ $1 $tag$, $$ $1 $$, (1)"},
+		{"select $1 -- $1\n, /* $1 /* $1 */ $1 */ $1", []interface{}{1}, "select (1) -- $1\n, /* $1 /* $1 */ $1 */ (1)"},
+		{"select a$1 from t", nil, "select a$1 from t"},
+	}
+
+	for i, tt := range tests {
+		actual, err := sanitizeSQL(tt.sql, tt.args...)
+		if err != nil {
+			t.Errorf("%d. Unexpected failure: %v", i, err)
+			continue
+		}
+		if actual != tt.expected {
+			t.Errorf("%d. Expected %q, got %q", i, tt.expected, actual)
+		}
+	}
+
+	errorTests := []struct {
+		sql  string
+		args []interface{}
+	}{
+		{"select $1", nil},
+		{"select $0", []interface{}{1}},
+		{"select $1", []interface{}{"\x00"}},
+		{"select $1", []interface{}{struct{}{}}},
+	}
+
+	for i, tt := range errorTests {
+		if _, err := sanitizeSQL(tt.sql, tt.args...); err == nil {
+			t.Errorf("%d. Expected error for %q", i, tt.sql)
+		}
+	}
+}
@@ -34,3 +34,21 @@ func BenchmarkQueryArgs(b *testing.B) {
 		qa.Append("foo10")
 	}
 }
+
+func TestIdentifierSanitize(t *testing.T) {
+	tests := []struct {
+		ident    pgx.Identifier
+		expected string
+	}{
+		{pgx.Identifier{"foo"}, `"foo"`},
+		{pgx.Identifier{"public", "foo"}, `"public"."foo"`},
+		{pgx.Identifier{`you"re`}, `"you""re"`},
+		{pgx.Identifier{"null\x00byte"}, `"nullbyte"`},
+	}
+
+	for i, tt := range tests {
+		if actual := tt.ident.Sanitize(); actual != tt.expected {
+			t.Errorf("%d. Expected %s, got %s", i, tt.expected, actual)
+		}
+	}
+}
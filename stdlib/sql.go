@@ -77,7 +77,8 @@ func init() {
 }
 
 type Driver struct {
-	Pool *pgx.ConnPool
+	Pool       *pgx.ConnPool
+	connConfig *pgx.ConnConfig // used instead of parsing the data source name if set
 }
 
 func (d *Driver) Open(name string) (driver.Conn, error) {
@@ -90,9 +91,15 @@ func (d *Driver) Open(name string) (driver.Conn, error) {
 		return &Conn{conn: conn, pool: d.Pool}, nil
 	}
 
-	connConfig, err := pgx.ParseURI(name)
-	if err != nil {
-		return nil, err
+	var connConfig pgx.ConnConfig
+	if d.connConfig != nil {
+		connConfig = *d.connConfig
+	} else {
+		var err error
+		connConfig, err = pgx.ParseURI(name)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	conn, err := pgx.Connect(connConfig)
@@ -140,6 +147,17 @@ func OpenFromConnPool(pool *pgx.ConnPool) (*sql.DB, error) {
 	return db, nil
 }
 
+// OpenFromConnConfig returns a *sql.DB that establishes connections with
+// config. Unlike sql.Open with a data source name this allows setting options
+// that cannot be expressed in a string, such as TLSConfig, Dial, or OnNotice.
+func OpenFromConnConfig(config pgx.ConnConfig) (*sql.DB, error) {
+	d := &Driver{connConfig: &config}
+	name := fmt.Sprintf("pgx-%d", openFromConnPoolCount)
+	openFromConnPoolCount++
+	sql.Register(name, d)
+	return sql.Open(name, "")
+}
+
 type Conn struct {
 	conn    *pgx.Conn
 	pool    *pgx.ConnPool
@@ -227,8 +245,8 @@ func (c *Conn) queryPrepared(name string, argsV []driver.Value) (driver.Rows, er
 }
 
 // Anything that isn't a database/sql compatible type needs to be forced to
-// text format so that pgx.Rows.Values doesn't decode it into a native type
-// (e.g. []int32)
+// text format so that Rows.Next returns it as a string instead of decoding it
+// into a native type (e.g. []int32)
 func restrictBinaryToDatabaseSqlTypes(ps *pgx.PreparedStatement) {
 	for i := range ps.FieldDescriptions {
 		intrinsic, _ := databaseSqlOids[ps.FieldDescriptions[i].DataType]
@@ -261,7 +279,8 @@ func (s *Stmt) Query(argsV []driver.Value) (driver.Rows, error) {
 
 // TODO - rename to avoid alloc
 type Rows struct {
-	rows *pgx.Rows
+	rows   *pgx.Rows
+	values []interface{} // Scan destinations for each column
 }
 
 func (r *Rows) Columns() []string {
@@ -288,19 +307,39 @@ func (r *Rows) Next(dest []driver.Value) error {
 		}
 	}
 
-	values, err := r.rows.Values()
-	if err != nil {
+	if r.values == nil {
+		fieldDescriptions := r.rows.FieldDescriptions()
+		r.values = make([]interface{}, len(fieldDescriptions))
+		for i, fd := range fieldDescriptions {
+			// Text format values are returned as strings as pgx.Rows.Values
+			// would decode some of them (e.g. hstore) into native types
+			if fd.FormatCode == pgx.TextFormatCode {
+				r.values[i] = new([]byte)
+			} else {
+				r.values[i] = new(interface{})
+			}
+		}
+	}
+
+	if err := r.rows.Scan(r.values...); err != nil {
 		return err
 	}
 
-	if len(dest) < len(values) {
-		fmt.Printf("%d: %#v\n", len(dest), dest)
-		fmt.Printf("%d: %#v\n", len(values), values)
+	if len(dest) < len(r.values) {
 		return errors.New("expected more values than were received")
 	}
 
-	for i, v := range values {
-		dest[i] = driver.Value(v)
+	for i, v := range r.values {
+		switch v := v.(type) {
+		case *[]byte:
+			if *v == nil {
+				dest[i] = nil
+			} else {
+				dest[i] = string(*v)
+			}
+		case *interface{}:
+			dest[i] = driver.Value(*v)
+		}
 	}
 
 	return nil
@@ -163,6 +163,36 @@ func TestOpenFromConnPool(t *testing.T) {
 	}
 }
 
+func TestOpenFromConnConfig(t *testing.T) {
+	var notices []*pgx.Notice
+	connConfig := pgx.ConnConfig{
+		Host:     "127.0.0.1",
+		User:     "pgx_md5",
+		Password: "secret",
+		Database: "pgx_test",
+		OnNotice: func(c *pgx.Conn, notice *pgx.Notice) {
+			notices = append(notices, notice)
+		},
+	}
+
+	db, err := stdlib.OpenFromConnConfig(connConfig)
+	if err != nil {
+		t.Fatalf("Unable to open database: %v", err)
+	}
+	defer closeDB(t, db)
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec("do $$ begin raise notice 'hello from stdlib'; end $$"); err != nil {
+		t.Fatalf("db.Exec unexpectedly failed: %v", err)
+	}
+
+	if len(notices) != 1 || notices[0].Message != "hello from stdlib" {
+		t.Fatalf("Unexpected notices: %v", notices)
+	}
+
+	ensureConnValid(t, db)
+}
+
 func TestStmtExec(t *testing.T) {
 	db := openDB(t)
 	defer closeDB(t, db)
@@ -0,0 +1,115 @@
+package pgx
+
+import (
+	"container/list"
+	"strconv"
+	"strings"
+)
+
+// stmtCache is an LRU cache of automatically prepared statements keyed by SQL
+// text. It is used by Query, QueryRow and Exec when
+// ConnConfig.PreparedStatementCacheSize is greater than 0.
+type stmtCache struct {
+	conn *Conn
+	size int
+	l    *list.List
+	m    map[string]*list.Element
+	seq  int
+}
+
+func newStmtCache(conn *Conn, size int) *stmtCache {
+	return &stmtCache{
+		conn: conn,
+		size: size,
+		l:    list.New(),
+		m:    make(map[string]*list.Element, size),
+	}
+}
+
+// get returns the prepared statement for sql, preparing and caching it if it
+// is not already cached. If the cache is full the least recently used
+// statement is deallocated.
+func (sc *stmtCache) get(sql string) (*PreparedStatement, error) {
+	if el, ok := sc.m[sql]; ok {
+		sc.l.MoveToFront(el)
+		return el.Value.(*PreparedStatement), nil
+	}
+
+	if sc.l.Len() >= sc.size {
+		if err := sc.evict(sc.l.Back()); err != nil {
+			return nil, err
+		}
+	}
+
+	sc.seq++
+	ps, err := sc.conn.Prepare("pgx_stmtcache_"+strconv.Itoa(sc.seq), sql)
+	if err != nil {
+		return nil, err
+	}
+
+	sc.m[sql] = sc.l.PushFront(ps)
+	return ps, nil
+}
+
+// lookup returns the cached prepared statement for sql without preparing it.
+// It is safe to call on a nil *stmtCache.
+func (sc *stmtCache) lookup(sql string) (*PreparedStatement, bool) {
+	if sc == nil {
+		return nil, false
+	}
+	el, ok := sc.m[sql]
+	if !ok {
+		return nil, false
+	}
+	sc.l.MoveToFront(el)
+	return el.Value.(*PreparedStatement), true
+}
+
+// remove deallocates and removes the cached prepared statement for sql, if
+// any.
+func (sc *stmtCache) remove(sql string) error {
+	if el, ok := sc.m[sql]; ok {
+		return sc.evict(el)
+	}
+	return nil
+}
+
+func (sc *stmtCache) evict(el *list.Element) error {
+	ps := sc.l.Remove(el).(*PreparedStatement)
+	delete(sc.m, ps.SQL)
+	return sc.conn.Deallocate(ps.Name)
+}
+
+// len returns the number of cached prepared statements.
+func (sc *stmtCache) len() int {
+	return sc.l.Len()
+}
+
+// prepareForQuery returns the prepared statement to use to execute sql. sql
+// may be the name of a prepared statement. Otherwise it is prepared as the
+// unnamed statement, or through the statement cache if it is enabled.
+func (c *Conn) prepareForQuery(sql string) (*PreparedStatement, error) {
+	if ps, ok := c.preparedStatements[sql]; ok {
+		return ps, nil
+	}
+
+	if c.stmtCache != nil {
+		return c.stmtCache.get(sql)
+	}
+
+	return c.Prepare("", sql)
+}
+
+// invalidateCachedStatement removes the cached prepared statement for sql when
+// err shows the server can no longer execute it because the result type of
+// the query has changed (e.g. a column was added to a table used by a select
+// *). The next execution of sql will prepare it again.
+func (c *Conn) invalidateCachedStatement(sql string, err error) {
+	if c.stmtCache == nil || err == nil || !c.IsAlive() {
+		return
+	}
+
+	if pgErr, ok := err.(PgError); ok && pgErr.Code == "0A000" && strings.Contains(pgErr.Message, "cached plan must not change result type") {
+		c.stmtCache.remove(sql)
+	}
+}
@@ -0,0 +1,173 @@
+package pgx
+
+import (
+	"fmt"
+	"time"
+)
+
+// TimeOfDay represents a PostgreSQL time or timetz. It does not support a null
+// value (use NullTimeOfDay for this). TimeOfDay implements the Scanner and
+// Encoder interfaces so it may be used both as an argument to Query[Row] and a
+// destination for Scan.
+//
+// Offset is the time zone offset in seconds east of UTC. It is only used by
+// timetz and is ignored for time.
+type TimeOfDay struct {
+	Microseconds int64 // Microseconds since midnight
+	Offset       int32
+}
+
+// NewTimeOfDay returns the TimeOfDay for the time of day of t. Offset is set
+// to the offset of t's location at t.
+func NewTimeOfDay(t time.Time) TimeOfDay {
+	_, offset := t.Zone()
+	return TimeOfDay{
+		Microseconds: int64(t.Hour())*microsecondsPerHour +
+			int64(t.Minute())*microsecondsPerMinute +
+			int64(t.Second())*microsecondsPerSecond +
+			int64(t.Nanosecond())/1000,
+		Offset: int32(offset),
+	}
+}
+
+// Duration returns the time since midnight.
+func (t TimeOfDay) Duration() time.Duration {
+	return time.Duration(t.Microseconds) * time.Microsecond
+}
+
+// String returns t in the PostgreSQL format for time (e.g. "04:05:06.789").
+// Use StringTz for the timetz format which includes the offset.
+func (t TimeOfDay) String() string {
+	return formatMicroseconds(t.Microseconds)
+}
+
+// StringTz returns t in the PostgreSQL format for timetz (e.g.
+// "04:05:06.789-07").
+func (t TimeOfDay) StringTz() string {
+	offset := t.Offset
+	sign := '+'
+	if offset < 0 {
+		sign = '-'
+		offset = -offset
+	}
+
+	s := fmt.Sprintf("%s%c%02d", t.String(), sign, offset/3600)
+	if offset%3600 != 0 {
+		s += fmt.Sprintf(":%02d", offset%3600/60)
+	}
+	if offset%60 != 0 {
+		s += fmt.Sprintf(":%02d", offset%60)
+	}
+	return s
+}
+
+func (t *TimeOfDay) Scan(vr *ValueReader) error {
+	oid := vr.Type().DataType
+	if oid != TimeOid && oid != TimetzOid {
+		return SerializationError(fmt.Sprintf("TimeOfDay.Scan cannot decode OID %d", oid))
+	}
+
+	if vr.Len() == -1 {
+		return SerializationError("Cannot decode null into TimeOfDay")
+	}
+
+	*t = decodeTimeOfDay(vr)
+	return vr.Err()
+}
+
+func (t TimeOfDay) FormatCode() int16 { return BinaryFormatCode }
+
+func (t TimeOfDay) Encode(w *WriteBuf, oid Oid) error {
+	return encodeTimeOfDay(w, oid, t)
+}
+
+// NullTimeOfDay represents a TimeOfDay that may be null. NullTimeOfDay
+// implements the Scanner and Encoder interfaces so it may be used both as an
+// argument to Query[Row] and a destination for Scan. It corresponds with the
+// PostgreSQL types time and timetz.
+//
+// If Valid is false then the value is NULL.
+type NullTimeOfDay struct {
+	TimeOfDay TimeOfDay
+	Valid     bool // Valid is true if TimeOfDay is not NULL
+}
+
+func (n *NullTimeOfDay) Scan(vr *ValueReader) error {
+	oid := vr.Type().DataType
+	if oid != TimeOid && oid != TimetzOid {
+		return SerializationError(fmt.Sprintf("NullTimeOfDay.Scan cannot decode OID %d", oid))
+	}
+
+	if vr.Len() == -1 {
+		n.TimeOfDay, n.Valid = TimeOfDay{}, false
+		return nil
+	}
+
+	n.Valid = true
+	n.TimeOfDay = decodeTimeOfDay(vr)
+	return vr.Err()
+}
+
+func (n NullTimeOfDay) FormatCode() int16 { return BinaryFormatCode }
+
+func (n NullTimeOfDay) Encode(w *WriteBuf, oid Oid) error {
+	if oid != TimeOid && oid != TimetzOid {
+		return SerializationError(fmt.Sprintf("NullTimeOfDay.Encode cannot encode into OID %d", oid))
+	}
+
+	if !n.Valid {
+		w.WriteInt32(-1)
+		return nil
+	}
+
+	return encodeTimeOfDay(w, oid, n.TimeOfDay)
+}
+
+func decodeTimeOfDay(vr *ValueReader) TimeOfDay {
+	if vr.Len() == -1 {
+		vr.Fatal(ProtocolError("Cannot decode null into TimeOfDay"))
+		return TimeOfDay{}
+	}
+
+	if vr.Type().FormatCode != BinaryFormatCode {
+		vr.Fatal(ProtocolError(fmt.Sprintf("Unknown field description format code: %v", vr.Type().FormatCode)))
+		return TimeOfDay{}
+	}
+
+	switch vr.Type().DataType {
+	case TimeOid:
+		if vr.Len() != 8 {
+			vr.Fatal(ProtocolError(fmt.Sprintf("Received an invalid size for a time: %d", vr.Len())))
+			return TimeOfDay{}
+		}
+		return TimeOfDay{Microseconds: vr.ReadInt64()}
+	case TimetzOid:
+		if vr.Len() != 12 {
+			vr.Fatal(ProtocolError(fmt.Sprintf("Received an invalid size for a timetz: %d", vr.Len())))
+			return TimeOfDay{}
+		}
+		t := TimeOfDay{Microseconds: vr.ReadInt64()}
+		// PostgreSQL sends the offset in seconds west of UTC
+		t.Offset = -vr.ReadInt32()
+		return t
+	default:
+		vr.Fatal(ProtocolError(fmt.Sprintf("Cannot decode oid %v into TimeOfDay", vr.Type().DataType)))
+		return TimeOfDay{}
+	}
+}
+
+func encodeTimeOfDay(w *WriteBuf, oid Oid, value TimeOfDay) error {
+	switch oid {
+	case TimeOid:
+		w.WriteInt32(8)
+		w.WriteInt64(value.Microseconds)
+	case TimetzOid:
+		w.WriteInt32(12)
+		w.WriteInt64(value.Microseconds)
+		w.WriteInt32(-value.Offset)
+	default:
+		return fmt.Errorf("cannot encode %s into oid %v", "TimeOfDay", oid)
+	}
+
+	return nil
+}
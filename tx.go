@@ -1,6 +1,7 @@
 package pgx
 
 import (
+	"context"
 	"errors"
 	"fmt"
 )
@@ -46,6 +47,21 @@ func (c *Conn) BeginIso(isoLevel string) (*Tx, error) {
 	return c.begin(isoLevel)
 }
 
+// BeginContext starts a transaction with the default isolation level for the
+// current connection. ctx only applies to starting the transaction. If ctx is
+// done before the transaction has begun the connection is closed and
+// ctx.Err() is returned.
+func (c *Conn) BeginContext(ctx context.Context) (*Tx, error) {
+	if err := c.startContext(ctx); err != nil {
+		return nil, err
+	}
+	tx, err := c.begin("")
+	if err = c.termContext(err); err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
 func (c *Conn) begin(isoLevel string) (*Tx, error) {
 	var beginSQL string
 	if isoLevel == "" {
@@ -127,6 +143,15 @@ func (tx *Tx) Exec(sql string, arguments ...interface{}) (commandTag CommandTag,
 	return tx.conn.Exec(sql, arguments...)
 }
 
+// ExecContext delegates to the underlying *Conn
+func (tx *Tx) ExecContext(ctx context.Context, sql string, arguments ...interface{}) (commandTag CommandTag, err error) {
+	if tx.status != TxStatusInProgress {
+		return CommandTag(""), ErrTxClosed
+	}
+
+	return tx.conn.ExecContext(ctx, sql, arguments...)
+}
+
 // Prepare delegates to the underlying *Conn
 func (tx *Tx) Prepare(name, sql string) (*PreparedStatement, error) {
 	return tx.PrepareEx(name, sql, nil)
@@ -152,12 +177,34 @@ func (tx *Tx) Query(sql string, args ...interface{}) (*Rows, error) {
 	return tx.conn.Query(sql, args...)
 }
 
+// Select delegates to the underlying *Conn
+func (tx *Tx) Select(dst interface{}, sql string, args ...interface{}) error {
+	return selectStructs(dst, func() (*Rows, error) { return tx.Query(sql, args...) })
+}
+
 // QueryRow delegates to the underlying *Conn
 func (tx *Tx) QueryRow(sql string, args ...interface{}) *Row {
 	rows, _ := tx.Query(sql, args...)
 	return (*Row)(rows)
 }
 
+// QueryContext delegates to the underlying *Conn
+func (tx *Tx) QueryContext(ctx context.Context, sql string, args ...interface{}) (*Rows, error) {
+	if tx.status != TxStatusInProgress {
+		// Because checking for errors can be deferred to the *Rows, build one with the error
+		err := ErrTxClosed
+		return &Rows{closed: true, err: err}, err
+	}
+
+	return tx.conn.QueryContext(ctx, sql, args...)
+}
+
+// QueryRowContext delegates to the underlying *Conn
+func (tx *Tx) QueryRowContext(ctx context.Context, sql string, args ...interface{}) *Row {
+	rows, _ := tx.QueryContext(ctx, sql, args...)
+	return (*Row)(rows)
+}
+
 // CopyTo delegates to the underlying *Conn
 func (tx *Tx) CopyTo(tableName string, columnNames []string, rowSrc CopyToSource) (int, error) {
 	if tx.status != TxStatusInProgress {
@@ -167,6 +214,33 @@ func (tx *Tx) CopyTo(tableName string, columnNames []string, rowSrc CopyToSource
 	return tx.conn.CopyTo(tableName, columnNames, rowSrc)
 }
 
+// CopyToEx delegates to the underlying *Conn
+func (tx *Tx) CopyToEx(tableName string, columnNames []string, rowSrc CopyToSource, opts *CopyToOptions) (CopyToResult, error) {
+	if tx.status != TxStatusInProgress {
+		return CopyToResult{}, ErrTxClosed
+	}
+
+	return tx.conn.CopyToEx(tableName, columnNames, rowSrc, opts)
+}
+
+// CopyFromQuery delegates to the underlying *Conn
+func (tx *Tx) CopyFromQuery(sql string, args []interface{}, sink CopyFromSink) (int, error) {
+	if tx.status != TxStatusInProgress {
+		return 0, ErrTxClosed
+	}
+
+	return tx.conn.CopyFromQuery(sql, args, sink)
+}
+
+// SendBatch delegates to the underlying *Conn
+func (tx *Tx) SendBatch(b *Batch) *BatchResults {
+	if tx.status != TxStatusInProgress {
+		return &BatchResults{err: ErrTxClosed, done: true}
+	}
+
+	return tx.conn.SendBatch(b)
+}
+
 // Conn returns the *Conn this transaction is using.
 func (tx *Tx) Conn() *Conn {
 	return tx.conn
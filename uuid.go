@@ -0,0 +1,212 @@
+package pgx
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// UUID represents a PostgreSQL uuid. It does not support a null value (use
+// NullUUID for this). UUID implements the Scanner and Encoder interfaces so it
+// may be used both as an argument to Query[Row] and a destination for Scan.
+//
+// A [16]byte can also be used as an argument or a Scan destination for uuid.
+type UUID [16]byte
+
+// ParseUUID parses a UUID in the standard
+// xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx form. The hyphens are optional and
+// the hex digits are case insensitive. Enclosing braces are also accepted as
+// PostgreSQL does.
+func ParseUUID(s string) (UUID, error) {
+	var u UUID
+
+	src := s
+	if len(src) >= 2 && src[0] == '{' && src[len(src)-1] == '}' {
+		src = src[1 : len(src)-1]
+	}
+	src = strings.Replace(src, "-", "", -1)
+
+	if len(src) != 32 {
+		return u, SerializationError(fmt.Sprintf("Cannot parse %q as uuid", s))
+	}
+
+	if _, err := hex.Decode(u[:], []byte(src)); err != nil {
+		return u, SerializationError(fmt.Sprintf("Cannot parse %q as uuid", s))
+	}
+
+	return u, nil
+}
+
+// String returns u in the standard xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx form.
+func (u UUID) String() string {
+	return fmt.Sprintf("%x-%x-%x-%x-%x", u[0:4], u[4:6], u[6:8], u[8:10], u[10:16])
+}
+
+func (u *UUID) Scan(vr *ValueReader) error {
+	if vr.Type().DataType != UuidOid {
+		return SerializationError(fmt.Sprintf("UUID.Scan cannot decode OID %d", vr.Type().DataType))
+	}
+
+	if vr.Len() == -1 {
+		return SerializationError("Cannot decode null into UUID")
+	}
+
+	*u = decodeUUID(vr)
+	return vr.Err()
+}
+
+func (u UUID) FormatCode() int16 { return BinaryFormatCode }
+
+func (u UUID) Encode(w *WriteBuf, oid Oid) error {
+	return encodeUUID(w, oid, u)
+}
+
+// NullUUID represents a UUID that may be null. NullUUID implements the Scanner
+// and Encoder interfaces so it may be used both as an argument to Query[Row]
+// and a destination for Scan.
+//
+// If Valid is false then the value is NULL.
+type NullUUID struct {
+	UUID  UUID
+	Valid bool // Valid is true if UUID is not NULL
+}
+
+func (n *NullUUID) Scan(vr *ValueReader) error {
+	if vr.Type().DataType != UuidOid {
+		return SerializationError(fmt.Sprintf("NullUUID.Scan cannot decode OID %d", vr.Type().DataType))
+	}
+
+	if vr.Len() == -1 {
+		n.UUID, n.Valid = UUID{}, false
+		return nil
+	}
+
+	n.Valid = true
+	n.UUID = decodeUUID(vr)
+	return vr.Err()
+}
+
+func (n NullUUID) FormatCode() int16 { return BinaryFormatCode }
+
+func (n NullUUID) Encode(w *WriteBuf, oid Oid) error {
+	if oid != UuidOid {
+		return SerializationError(fmt.Sprintf("NullUUID.Encode cannot encode into OID %d", oid))
+	}
+
+	if !n.Valid {
+		w.WriteInt32(-1)
+		return nil
+	}
+
+	return encodeUUID(w, oid, n.UUID)
+}
+
+func decodeUUID(vr *ValueReader) UUID {
+	var u UUID
+
+	if vr.Len() == -1 {
+		vr.Fatal(ProtocolError("Cannot decode null into UUID"))
+		return u
+	}
+
+	if vr.Type().DataType != UuidOid {
+		vr.Fatal(ProtocolError(fmt.Sprintf("Cannot decode oid %v into UUID", vr.Type().DataType)))
+		return u
+	}
+
+	switch vr.Type().FormatCode {
+	case TextFormatCode:
+		var err error
+		u, err = ParseUUID(vr.ReadString(vr.Len()))
+		if err != nil {
+			vr.Fatal(err)
+		}
+	case BinaryFormatCode:
+		if vr.Len() != 16 {
+			vr.Fatal(ProtocolError(fmt.Sprintf("Received an invalid size for an uuid: %d", vr.Len())))
+			return u
+		}
+		copy(u[:], vr.ReadBytes(16))
+	default:
+		vr.Fatal(ProtocolError(fmt.Sprintf("Unknown field description format code: %v", vr.Type().FormatCode)))
+	}
+
+	return u
+}
+
+func encodeUUID(w *WriteBuf, oid Oid, value UUID) error {
+	if oid != UuidOid {
+		return fmt.Errorf("cannot encode %s into oid %v", "UUID", oid)
+	}
+
+	w.WriteInt32(16)
+	w.WriteBytes(value[:])
+	return nil
+}
+
+func decodeUUIDArray(vr *ValueReader) []UUID {
+	if vr.Len() == -1 {
+		return nil
+	}
+
+	if vr.Type().DataType != UuidArrayOid {
+		vr.Fatal(ProtocolError(fmt.Sprintf("Cannot decode oid %v into []UUID", vr.Type().DataType)))
+		return nil
+	}
+
+	if vr.Type().FormatCode != BinaryFormatCode {
+		vr.Fatal(ProtocolError(fmt.Sprintf("Unknown field description format code: %v", vr.Type().FormatCode)))
+		return nil
+	}
+
+	numElems, err := decode1dArrayHeader(vr)
+	if err != nil {
+		vr.Fatal(err)
+		return nil
+	}
+
+	a := make([]UUID, int(numElems))
+	for i := 0; i < len(a); i++ {
+		elSize := vr.ReadInt32()
+		switch elSize {
+		case 16:
+			copy(a[i][:], vr.ReadBytes(16))
+		case -1:
+			vr.Fatal(ProtocolError("Cannot decode null element"))
+			return nil
+		default:
+			vr.Fatal(ProtocolError(fmt.Sprintf("Received an invalid size for an uuid element: %d", elSize)))
+			return nil
+		}
+	}
+
+	return a
+}
+
+func encodeUUIDSlice(w *WriteBuf, oid Oid, slice []UUID) error {
+	if oid != UuidArrayOid {
+		return fmt.Errorf("cannot encode Go %s into oid %d", "[]UUID", oid)
+	}
+
+	encodeArrayHeader(w, UuidOid, len(slice), 20)
+	for _, u := range slice {
+		w.WriteInt32(16)
+		w.WriteBytes(u[:])
+	}
+
+	return nil
+}
+
+// uuidArgFormatCode returns the format code to use to send arg as a uuid or
+// uuid[] parameter. Strings, including those returned by the driver.Valuer
+// implementations of many UUID packages, are sent as text.
+func uuidArgFormatCode(arg interface{}) int16 {
+	switch arg.(type) {
+	case [16]byte, *[16]byte, []UUID, [][16]byte:
+		return BinaryFormatCode
+	}
+	if binaryArrayArg(arg) {
+		return BinaryFormatCode
+	}
+	return TextFormatCode
+}
@@ -0,0 +1,37 @@
+package pgx
+
+import (
+	"testing"
+)
+
+func TestParseUUID(t *testing.T) {
+	expected := UUID{0x01, 0x08, 0x6e, 0xe0, 0x49, 0x63, 0x4e, 0x35, 0x91, 0x16, 0x30, 0xc1, 0x73, 0xa8, 0xd0, 0xbd}
+
+	tests := []string{
+		"01086ee0-4963-4e35-9116-30c173a8d0bd",
+		"01086EE0-4963-4E35-9116-30C173A8D0BD",
+		"01086ee049634e35911630c173a8d0bd",
+		"{01086ee0-4963-4e35-9116-30c173a8d0bd}",
+	}
+
+	for i, tt := range tests {
+		u, err := ParseUUID(tt)
+		if err != nil {
+			t.Errorf("%d. Unexpected error: %v", i, err)
+			continue
+		}
+		if u != expected {
+			t.Errorf("%d. ParseUUID(%q) => %v, expected %v", i, tt, u, expected)
+		}
+	}
+
+	if s := expected.String(); s != tests[0] {
+		t.Errorf("String() => %v, expected %v", s, tests[0])
+	}
+
+	for _, s := range []string{"", "01086ee0-4963-4e35-9116-30c173a8d0b", "01086ee0-4963-4e35-9116-30c173a8d0bz"} {
+		if _, err := ParseUUID(s); err == nil {
+			t.Errorf("Expected error parsing %q", s)
+		}
+	}
+}
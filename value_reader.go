@@ -8,6 +8,7 @@ import (
 type ValueReader struct {
 	mr                  *msgReader
 	fd                  *FieldDescription
+	conn                *Conn // used to look up registered types; may be nil
 	valueBytesRemaining int32
 	err                 error
 }
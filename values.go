@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io"
 	"math"
+	"math/big"
 	"net"
 	"reflect"
 	"regexp"
@@ -50,13 +51,26 @@ const (
 	InetArrayOid        = 1041
 	VarcharOid          = 1043
 	DateOid             = 1082
+	TimeOid             = 1083
 	TimestampOid        = 1114
 	TimestampArrayOid   = 1115
+	DateArrayOid        = 1182
 	TimestampTzOid      = 1184
 	TimestampTzArrayOid = 1185
+	IntervalOid         = 1186
+	NumericArrayOid     = 1231
+	NumericOid          = 1700
+	TimetzOid           = 1266
 	RecordOid           = 2249
 	UuidOid             = 2950
+	UuidArrayOid        = 2951
 	JsonbOid            = 3802
+	Int4RangeOid        = 3904
+	NumRangeOid         = 3906
+	TsRangeOid          = 3908
+	TstzRangeOid        = 3910
+	DateRangeOid        = 3912
+	Int8RangeOid        = 3926
 )
 
 // PostgreSQL format codes
@@ -83,15 +97,18 @@ func init() {
 		"_bool":        BinaryFormatCode,
 		"_bytea":       BinaryFormatCode,
 		"_cidr":        BinaryFormatCode,
+		"_date":        BinaryFormatCode,
 		"_float4":      BinaryFormatCode,
 		"_float8":      BinaryFormatCode,
 		"_inet":        BinaryFormatCode,
 		"_int2":        BinaryFormatCode,
 		"_int4":        BinaryFormatCode,
 		"_int8":        BinaryFormatCode,
+		"_numeric":     BinaryFormatCode,
 		"_text":        BinaryFormatCode,
 		"_timestamp":   BinaryFormatCode,
 		"_timestamptz": BinaryFormatCode,
+		"_uuid":        BinaryFormatCode,
 		"_varchar":     BinaryFormatCode,
 		"aclitem":      TextFormatCode, // Pg's src/backend/utils/adt/acl.c has only in/out (text) not send/recv (bin)
 		"bool":         BinaryFormatCode,
@@ -100,6 +117,7 @@ func init() {
 		"cid":          BinaryFormatCode,
 		"cidr":         BinaryFormatCode,
 		"date":         BinaryFormatCode,
+		"daterange":    BinaryFormatCode,
 		"float4":       BinaryFormatCode,
 		"float8":       BinaryFormatCode,
 		"json":         BinaryFormatCode,
@@ -107,17 +125,29 @@ func init() {
 		"inet":         BinaryFormatCode,
 		"int2":         BinaryFormatCode,
 		"int4":         BinaryFormatCode,
+		"int4range":    BinaryFormatCode,
 		"int8":         BinaryFormatCode,
+		"int8range":    BinaryFormatCode,
+		"interval":     BinaryFormatCode,
 		"name":         BinaryFormatCode,
+		"numeric":      BinaryFormatCode,
+		"numrange":     BinaryFormatCode,
 		"oid":          BinaryFormatCode,
 		"record":       BinaryFormatCode,
 		"text":         BinaryFormatCode,
 		"tid":          BinaryFormatCode,
+		"time":         BinaryFormatCode,
 		"timestamp":    BinaryFormatCode,
 		"timestamptz":  BinaryFormatCode,
+		"tsrange":      BinaryFormatCode,
+		"tstzrange":    BinaryFormatCode,
+		"timetz":       BinaryFormatCode,
+		"uuid":         BinaryFormatCode,
 		"varchar":      BinaryFormatCode,
 		"xid":          BinaryFormatCode,
 	}
+
+	defaultConnInfo = newDefaultConnInfo()
 }
 
 // SerializationError occurs on failure to encode or decode a value
@@ -1004,6 +1034,12 @@ func Encode(wbuf *WriteBuf, oid Oid, arg interface{}) error {
 		return encodeByteSlice(wbuf, oid, arg)
 	case [][]byte:
 		return encodeByteSliceSlice(wbuf, oid, arg)
+	case *big.Rat:
+		return encodeBigRat(wbuf, oid, arg)
+	case *big.Int:
+		return encodeBigInt(wbuf, oid, arg)
+	case []*big.Rat:
+		return encodeBigRatSlice(wbuf, oid, arg)
 	}
 
 	refVal := reflect.ValueOf(arg)
@@ -1017,6 +1053,10 @@ func Encode(wbuf *WriteBuf, oid Oid, arg interface{}) error {
 		return Encode(wbuf, oid, arg)
 	}
 
+	if codec := connInfoOf(wbuf.conn).codec(oid); codec != nil {
+		return codec.Encode(wbuf, oid, arg)
+	}
+
 	if oid == JsonOid {
 		return encodeJSON(wbuf, oid, arg)
 	}
@@ -1083,6 +1123,8 @@ func Encode(wbuf *WriteBuf, oid Oid, arg interface{}) error {
 		return encodeFloat64Slice(wbuf, oid, arg)
 	case time.Time:
 		return encodeTime(wbuf, oid, arg)
+	case time.Duration:
+		return encodeDuration(wbuf, oid, arg)
 	case []time.Time:
 		return encodeTimeSlice(wbuf, oid, arg)
 	case net.IP:
@@ -1093,6 +1135,18 @@ func Encode(wbuf *WriteBuf, oid Oid, arg interface{}) error {
 		return encodeIPNet(wbuf, oid, arg)
 	case []net.IPNet:
 		return encodeIPNetSlice(wbuf, oid, arg)
+	case []Numeric:
+		return encodeNumericSlice(wbuf, oid, arg)
+	case [16]byte:
+		return encodeUUID(wbuf, oid, UUID(arg))
+	case []UUID:
+		return encodeUUIDSlice(wbuf, oid, arg)
+	case [][16]byte:
+		uuids := make([]UUID, len(arg))
+		for i := range arg {
+			uuids[i] = UUID(arg[i])
+		}
+		return encodeUUIDSlice(wbuf, oid, uuids)
 	case Oid:
 		return encodeOid(wbuf, oid, arg)
 	case Xid:
@@ -1103,6 +1157,9 @@ func Encode(wbuf *WriteBuf, oid Oid, arg interface{}) error {
 		if strippedArg, ok := stripNamedType(&refVal); ok {
 			return Encode(wbuf, oid, strippedArg)
 		}
+		if elOid, ok := connInfoOf(wbuf.conn).arrayElementOid(oid); ok && isArraySliceType(refVal.Type()) {
+			return encodeArray(wbuf, elOid, refVal)
+		}
 		return SerializationError(fmt.Sprintf("Cannot encode %T into oid %v - %T must implement Encoder or be converted to a string", arg, oid, arg))
 	}
 }
@@ -1140,6 +1197,10 @@ func stripNamedType(val *reflect.Value) (interface{}, bool) {
 // implementations of the Decoder interface to delegate the actual work of
 // decoding to the built-in functionality.
 func Decode(vr *ValueReader, d interface{}) error {
+	if codec := connInfoOf(vr.conn).codec(vr.Type().DataType); codec != nil {
+		return decodeWithCodec(vr, codec, d)
+	}
+
 	switch v := d.(type) {
 	case *bool:
 		*v = decodeBool(vr)
@@ -1238,11 +1299,60 @@ func Decode(vr *ValueReader, d interface{}) error {
 	case *Cid:
 		*v = decodeCid(vr)
 	case *string:
-		*v = decodeText(vr)
+		switch vr.Type().DataType {
+		case NumericOid:
+			*v = decodeNumericText(vr)
+		case IntervalOid:
+			*v = decodeInterval(vr).String()
+		case TimeOid:
+			*v = decodeTimeOfDay(vr).String()
+		case TimetzOid:
+			*v = decodeTimeOfDay(vr).StringTz()
+		case UuidOid:
+			*v = decodeUUID(vr).String()
+		case Int4RangeOid, Int8RangeOid, NumRangeOid, TsRangeOid, TstzRangeOid, DateRangeOid:
+			*v = decodeRangeText(vr)
+		case NumericArrayOid, DateArrayOid, UuidArrayOid:
+			*v = decodeArrayText(vr)
+		default:
+			if isBinaryEnumArray(vr) {
+				*v = decodeArrayText(vr)
+			} else {
+				*v = decodeText(vr)
+			}
+		}
 	case *float32:
 		*v = decodeFloat4(vr)
 	case *float64:
-		*v = decodeFloat8(vr)
+		if vr.Type().DataType == NumericOid {
+			*v = decodeNumericFloat64(vr)
+		} else {
+			*v = decodeFloat8(vr)
+		}
+	case *big.Rat:
+		if r := decodeBigRat(vr); r != nil {
+			v.Set(r)
+		}
+	case *big.Int:
+		if n := decodeBigInt(vr); n != nil {
+			v.Set(n)
+		}
+	case **big.Rat:
+		if vr.Len() == -1 {
+			*v = nil
+		} else {
+			*v = decodeBigRat(vr)
+		}
+	case **big.Int:
+		if vr.Len() == -1 {
+			*v = nil
+		} else {
+			*v = decodeBigInt(vr)
+		}
+	case *[]*big.Rat:
+		*v = decodeBigRatArray(vr)
+	case *[]Numeric:
+		*v = decodeNumericArray(vr)
 	case *[]AclItem:
 		*v = decodeAclItemArray(vr)
 	case *[]bool:
@@ -1264,13 +1374,55 @@ func Decode(vr *ValueReader, d interface{}) error {
 	case *[]float64:
 		*v = decodeFloat8Array(vr)
 	case *[]string:
-		*v = decodeTextArray(vr)
+		if vr.Type().DataType == UuidArrayOid {
+			uuids := decodeUUIDArray(vr)
+			if uuids == nil {
+				*v = nil
+				break
+			}
+			a := make([]string, len(uuids))
+			for i, u := range uuids {
+				a[i] = u.String()
+			}
+			*v = a
+		} else if _, ok := enumArrayElementOid(vr.conn, vr.Type().DataType); ok {
+			return decodeArray(vr, reflect.ValueOf(v).Elem())
+		} else {
+			*v = decodeTextArray(vr)
+		}
+	case *[16]byte:
+		*v = decodeUUID(vr)
+	case *[]UUID:
+		*v = decodeUUIDArray(vr)
+	case *[][16]byte:
+		uuids := decodeUUIDArray(vr)
+		if uuids == nil {
+			*v = nil
+			break
+		}
+		a := make([][16]byte, len(uuids))
+		for i, u := range uuids {
+			a[i] = u
+		}
+		*v = a
 	case *[]time.Time:
-		*v = decodeTimestampArray(vr)
+		if vr.Type().DataType == DateArrayOid {
+			*v = decodeDateArray(vr)
+		} else {
+			*v = decodeTimestampArray(vr)
+		}
+	case *time.Duration:
+		*v = decodeDuration(vr)
 	case *[][]byte:
 		*v = decodeByteaArray(vr)
 	case *[]interface{}:
 		*v = decodeRecord(vr)
+	case *interface{}:
+		if vr.Len() == -1 {
+			*v = nil
+		} else {
+			*v = decodeValue(vr)
+		}
 	case *time.Time:
 		switch vr.Type().DataType {
 		case DateOid:
@@ -1342,6 +1494,12 @@ func Decode(vr *ValueReader, d interface{}) error {
 			case reflect.String:
 				el.SetString(decodeText(vr))
 				return nil
+			case reflect.Slice:
+				// arrays of more than one dimension, with null elements, or of
+				// registered types
+				if _, ok := connInfoOf(vr.conn).arrayElementOid(vr.Type().DataType); ok && isArraySliceType(el.Type()) {
+					return decodeArray(vr, el)
+				}
 			}
 		}
 		return fmt.Errorf("Scan cannot decode into %T", d)
@@ -2022,10 +2180,18 @@ func decodeText(vr *ValueReader) string {
 		return ""
 	}
 
-	return vr.ReadString(vr.Len())
+	s := vr.ReadString(vr.Len())
+	if err := validateEnumLabel(vr.conn, vr.Type().DataType, s); err != nil {
+		vr.Fatal(err)
+	}
+	return s
 }
 
 func encodeString(w *WriteBuf, oid Oid, value string) error {
+	if err := validateEnumLabel(w.conn, oid, value); err != nil {
+		return err
+	}
+
 	w.WriteInt32(int32(len(value)))
 	w.WriteBytes([]byte(value))
 	return nil
@@ -2180,6 +2346,8 @@ func encodeTime(w *WriteBuf, oid Oid, value time.Time) error {
 		w.WriteInt64(microsecSinceY2K)
 
 		return nil
+	case TimeOid, TimetzOid:
+		return encodeTimeOfDay(w, oid, NewTimeOfDay(value))
 	default:
 		return fmt.Errorf("cannot encode %s into oid %v", "time.Time", oid)
 	}
@@ -2282,6 +2450,9 @@ func encodeIPNet(w *WriteBuf, oid Oid, value net.IPNet) error {
 	if oid != InetOid && oid != CidrOid {
 		return fmt.Errorf("cannot encode %s into oid %v", "net.IPNet", oid)
 	}
+	if w.conn == nil || w.conn.pgsqlAfInet == nil || w.conn.pgsqlAfInet6 == nil {
+		return fmt.Errorf("cannot encode %s without a connection", "net.IPNet")
+	}
 
 	var size int32
 	var family byte
@@ -2329,7 +2500,7 @@ func decodeRecord(vr *ValueReader) []interface{} {
 		return nil
 	}
 
-	if vr.Type().DataType != RecordOid {
+	if _, ok := registeredCompositeType(vr.conn, vr.Type().DataType); !ok && vr.Type().DataType != RecordOid {
 		vr.Fatal(ProtocolError(fmt.Sprintf("Cannot decode oid %v into []interface{}", vr.Type().DataType)))
 		return nil
 	}
@@ -2339,43 +2510,20 @@ func decodeRecord(vr *ValueReader) []interface{} {
 
 	for i := int32(0); i < valueCount; i++ {
 		fd := FieldDescription{FormatCode: BinaryFormatCode}
-		fieldVR := ValueReader{mr: vr.mr, fd: &fd}
+		fieldVR := ValueReader{mr: vr.mr, fd: &fd, conn: vr.conn}
 		fd.DataType = vr.ReadOid()
 		fieldVR.valueBytesRemaining = vr.ReadInt32()
-		vr.valueBytesRemaining -= fieldVR.valueBytesRemaining
-
-		switch fd.DataType {
-		case BoolOid:
-			record = append(record, decodeBool(&fieldVR))
-		case ByteaOid:
-			record = append(record, decodeBytea(&fieldVR))
-		case Int8Oid:
-			record = append(record, decodeInt8(&fieldVR))
-		case Int2Oid:
-			record = append(record, decodeInt2(&fieldVR))
-		case Int4Oid:
-			record = append(record, decodeInt4(&fieldVR))
-		case OidOid:
-			record = append(record, decodeOid(&fieldVR))
-		case Float4Oid:
-			record = append(record, decodeFloat4(&fieldVR))
-		case Float8Oid:
-			record = append(record, decodeFloat8(&fieldVR))
-		case DateOid:
-			record = append(record, decodeDate(&fieldVR))
-		case TimestampTzOid:
-			record = append(record, decodeTimestampTz(&fieldVR))
-		case TimestampOid:
-			record = append(record, decodeTimestamp(&fieldVR))
-		case InetOid, CidrOid:
-			record = append(record, decodeInet(&fieldVR))
-		case TextOid, VarcharOid, UnknownOid:
-			record = append(record, decodeText(&fieldVR))
-		default:
-			vr.Fatal(fmt.Errorf("decodeRecord cannot decode oid %d", fd.DataType))
-			return nil
+		if fieldVR.valueBytesRemaining > 0 {
+			vr.valueBytesRemaining -= fieldVR.valueBytesRemaining
 		}
 
+		if fieldVR.Len() == -1 {
+			record = append(record, nil)
+			continue
+		}
+
+		record = append(record, decodeValue(&fieldVR))
+
 		// Consume any remaining data
 		if fieldVR.Len() > 0 {
 			fieldVR.ReadBytes(fieldVR.Len())
@@ -2390,6 +2538,115 @@ func decodeRecord(vr *ValueReader) []interface{} {
 	return record
 }
 
+// decodeWithCodec decodes vr into d with codec. *interface{} receives the
+// value Rows.Values would return and pointers to pointers are handled as by
+// Decode.
+func decodeWithCodec(vr *ValueReader, codec Codec, d interface{}) error {
+	if v, ok := d.(*interface{}); ok {
+		if vr.Len() == -1 {
+			*v = nil
+		} else {
+			*v = decodeValue(vr)
+		}
+		return vr.Err()
+	}
+
+	if v := reflect.ValueOf(d); v.Kind() == reflect.Ptr && v.Elem().Kind() == reflect.Ptr {
+		el := v.Elem()
+		if vr.Len() == -1 {
+			el.Set(reflect.Zero(el.Type()))
+			return nil
+		}
+		if el.IsNil() {
+			el.Set(reflect.New(el.Type().Elem()))
+		}
+		return decodeWithCodec(vr, codec, el.Interface())
+	}
+
+	return codec.Decode(vr, d)
+}
+
+// decodeCodecArray decodes an array of a type with a codec into a
+// []interface{} with the value DecodeValue returns or nil for each element.
+func decodeCodecArray(vr *ValueReader) []interface{} {
+	var a []interface{}
+	if err := decodeArray(vr, reflect.ValueOf(&a).Elem()); err != nil {
+		vr.Fatal(err)
+		return nil
+	}
+	return a
+}
+
+// binaryTextTypes are the types read in text format whose binary format is
+// the same as their text format.
+var binaryTextTypes = map[string]struct{}{
+	"bpchar":  {},
+	"citext":  {},
+	"varchar": {},
+	"xml":     {},
+}
+
+// decodeValue decodes vr, a non-null value, into the natural Go type for its
+// data type. Values of data types pgx does not know are returned as a string
+// if they are in text format and as raw []byte if they are in binary format,
+// except for enums and types in binaryTextTypes which are returned as a
+// string in either format.
+func decodeValue(vr *ValueReader) interface{} {
+	ci := connInfoOf(vr.conn)
+
+	dt, ok := ci.DataTypeForOid(vr.Type().DataType)
+	if !ok {
+		// e.g. pseudo-types such as unknown that are not loaded from pg_type
+		dt, ok = builtinDataType(vr.Type().DataType, vr.Type().DataTypeName)
+	}
+
+	if ok && dt.Codec != nil && dt.Codec.FormatCode() == vr.Type().FormatCode {
+		v, err := dt.Codec.DecodeValue(vr)
+		if err != nil {
+			vr.Fatal(err)
+		}
+		return v
+	}
+
+	switch vr.Type().FormatCode {
+	case TextFormatCode:
+		if ok && dt.decodeTextValue != nil {
+			return dt.decodeTextValue(vr)
+		}
+		return vr.ReadString(vr.Len())
+	case BinaryFormatCode:
+		if ok && dt.decodeValue != nil {
+			return dt.decodeValue(vr)
+		}
+		// Binary copy data is in binary format even for types pgx reads as
+		// text. The binary format of these is the same as their text format.
+		if _, isEnum := ci.enumOids[vr.Type().DataType]; isEnum {
+			return vr.ReadString(vr.Len())
+		}
+		if ok {
+			if _, isText := binaryTextTypes[dt.Name]; isText {
+				return vr.ReadString(vr.Len())
+			}
+		}
+		if elOid, ok := ci.arrayElementOid(vr.Type().DataType); ok {
+			if ci.codec(elOid) != nil {
+				return decodeCodecArray(vr)
+			}
+			if _, ok := ci.enumOids[elOid]; ok {
+				var a []string
+				if err := decodeArray(vr, reflect.ValueOf(&a).Elem()); err != nil {
+					vr.Fatal(err)
+				}
+				return a
+			}
+		}
+		return vr.ReadBytes(vr.Len())
+	default:
+		vr.Fatal(ProtocolError(fmt.Sprintf("Unknown field description format code: %v", vr.Type().FormatCode)))
+		return nil
+	}
+}
+
 func decode1dArrayHeader(vr *ValueReader) (length int32, err error) {
 	numDims := vr.ReadInt32()
 	if numDims > 1 {
@@ -2404,11 +2661,7 @@ func decode1dArrayHeader(vr *ValueReader) (length int32, err error) {
 	}
 
 	length = vr.ReadInt32()
-
-	idxFirstElem := vr.ReadInt32()
-	if idxFirstElem != 1 {
-		return 0, ProtocolError(fmt.Sprintf("Expected array's first element to start a index 1, but it is %d", idxFirstElem))
-	}
+	vr.ReadInt32() // index of first element -- Go slices always start at 0
 
 	return length, nil
 }
@@ -3233,7 +3486,15 @@ func encodeStringSlice(w *WriteBuf, oid Oid, slice []string) error {
 	case TextArrayOid:
 		elOid = TextOid
 	default:
-		return fmt.Errorf("cannot encode Go %s into oid %d", "[]string", oid)
+		var ok bool
+		if elOid, ok = enumArrayElementOid(w.conn, oid); !ok {
+			return fmt.Errorf("cannot encode Go %s into oid %d", "[]string", oid)
+		}
+		for _, v := range slice {
+			if err := validateEnumLabel(w.conn, elOid, v); err != nil {
+				return err
+			}
+		}
 	}
 
 	var totalStringSize int
@@ -3299,23 +3560,65 @@ func decodeTimestampArray(vr *ValueReader) []time.Time {
 	return a
 }
 
+func decodeDateArray(vr *ValueReader) []time.Time {
+	if vr.Len() == -1 {
+		return nil
+	}
+
+	if vr.Type().DataType != DateArrayOid {
+		vr.Fatal(ProtocolError(fmt.Sprintf("Cannot decode oid %v into []time.Time", vr.Type().DataType)))
+		return nil
+	}
+
+	if vr.Type().FormatCode != BinaryFormatCode {
+		vr.Fatal(ProtocolError(fmt.Sprintf("Unknown field description format code: %v", vr.Type().FormatCode)))
+		return nil
+	}
+
+	numElems, err := decode1dArrayHeader(vr)
+	if err != nil {
+		vr.Fatal(err)
+		return nil
+	}
+
+	a := make([]time.Time, int(numElems))
+	for i := 0; i < len(a); i++ {
+		elSize := vr.ReadInt32()
+		switch elSize {
+		case 4:
+			dayOffset := vr.ReadInt32()
+			a[i] = time.Date(2000, 1, int(1+dayOffset), 0, 0, 0, 0, time.Local)
+		case -1:
+			vr.Fatal(ProtocolError("Cannot decode null element"))
+			return nil
+		default:
+			vr.Fatal(ProtocolError(fmt.Sprintf("Received an invalid size for an time.Time element: %d", elSize)))
+			return nil
+		}
+	}
+
+	return a
+}
+
 func encodeTimeSlice(w *WriteBuf, oid Oid, slice []time.Time) error {
 	var elOid Oid
+	var sizePerItem int
 	switch oid {
 	case TimestampArrayOid:
-		elOid = TimestampOid
+		elOid, sizePerItem = TimestampOid, 12
 	case TimestampTzArrayOid:
-		elOid = TimestampTzOid
+		elOid, sizePerItem = TimestampTzOid, 12
+	case DateArrayOid:
+		elOid, sizePerItem = DateOid, 8
 	default:
 		return fmt.Errorf("cannot encode Go %s into oid %d", "[]time.Time", oid)
 	}
 
-	encodeArrayHeader(w, int(elOid), len(slice), 12)
+	encodeArrayHeader(w, int(elOid), len(slice), sizePerItem)
 	for _, t := range slice {
-		w.WriteInt32(8)
-		microsecSinceUnixEpoch := t.Unix()*1000000 + int64(t.Nanosecond())/1000
-		microsecSinceY2K := microsecSinceUnixEpoch - microsecFromUnixEpochToY2K
-		w.WriteInt64(microsecSinceY2K)
+		if err := encodeTime(w, elOid, t); err != nil {
+			return err
+		}
 	}
 
 	return nil
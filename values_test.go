@@ -2,6 +2,10 @@ package pgx_test
 
 import (
 	"bytes"
+	"database/sql"
+	"fmt"
+	"math"
+	"math/big"
 	"net"
 	"reflect"
 	"strings"
@@ -51,6 +55,174 @@ func TestDateTranscode(t *testing.T) {
 	}
 }
 
+func TestIntervalTranscode(t *testing.T) {
+	t.Parallel()
+
+	conn := mustConnect(t, *defaultConnConfig)
+	defer closeConn(t, conn)
+
+	tests := []struct {
+		value    pgx.Interval
+		expected string
+	}{
+		{pgx.Interval{}, "00:00:00"},
+		{pgx.Interval{Microseconds: 1}, "00:00:00.000001"},
+		{pgx.Interval{Microseconds: -3723000000}, "-01:02:03"},
+		{pgx.Interval{Days: 1}, "1 day"},
+		{pgx.Interval{Days: -3, Microseconds: 500000}, "-3 days +00:00:00.5"},
+		{pgx.Interval{Months: -1, Days: 1}, "-1 mons +1 day"},
+		{pgx.Interval{Months: 14, Days: 2, Microseconds: 14706789000}, "1 year 2 mons 2 days 04:05:06.789"},
+	}
+
+	for i, tt := range tests {
+		var actual pgx.Interval
+		err := conn.QueryRow("select $1::interval", tt.value).Scan(&actual)
+		if err != nil {
+			t.Errorf("%d. Unexpected failure: %v (value -> %v)", i, err, tt.value)
+			continue
+		}
+		if actual != tt.value {
+			t.Errorf("%d. Expected %v, got %v", i, tt.value, actual)
+		}
+
+		var s string
+		err = conn.QueryRow("select $1::interval", tt.value).Scan(&s)
+		if err != nil {
+			t.Errorf("%d. Unexpected failure: %v (value -> %v)", i, err, tt.value)
+			continue
+		}
+		if s != tt.expected {
+			t.Errorf("%d. Expected %v, got %v", i, tt.expected, s)
+		}
+
+		var text string
+		err = conn.QueryRow("select ($1::interval)::text", tt.value).Scan(&text)
+		if err != nil {
+			t.Errorf("%d. Unexpected failure: %v (value -> %v)", i, err, tt.value)
+			continue
+		}
+		if text != tt.expected {
+			t.Errorf("%d. PostgreSQL formatted %v as %v, expected %v", i, tt.value, text, tt.expected)
+		}
+
+		ensureConnValid(t, conn)
+	}
+
+	var d time.Duration
+	err := conn.QueryRow("select $1::interval", 90*time.Minute).Scan(&d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d != 90*time.Minute {
+		t.Errorf("Expected %v, got %v", 90*time.Minute, d)
+	}
+
+	err = conn.QueryRow("select '1 month'::interval").Scan(&d)
+	if err == nil {
+		t.Error("Expected error scanning interval with months into time.Duration")
+	}
+
+	var n pgx.NullInterval
+	err = conn.QueryRow("select null::interval").Scan(&n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n.Valid {
+		t.Errorf("Expected null, got %v", n)
+	}
+
+	ensureConnValid(t, conn)
+}
+
+func TestTimeOfDayTranscode(t *testing.T) {
+	t.Parallel()
+
+	conn := mustConnect(t, *defaultConnConfig)
+	defer closeConn(t, conn)
+
+	tests := []struct {
+		sql      string
+		value    pgx.TimeOfDay
+		expected string
+	}{
+		{"select $1::time", pgx.TimeOfDay{}, "00:00:00"},
+		{"select $1::time", pgx.TimeOfDay{Microseconds: 86399999999}, "23:59:59.999999"},
+		{"select $1::timetz", pgx.TimeOfDay{Microseconds: 45296000000, Offset: 0}, "12:34:56+00"},
+		{"select $1::timetz", pgx.TimeOfDay{Microseconds: 45296000000, Offset: -7 * 3600}, "12:34:56-07"},
+		{"select $1::timetz", pgx.TimeOfDay{Microseconds: 45296000000, Offset: 5*3600 + 30*60}, "12:34:56+05:30"},
+	}
+
+	for i, tt := range tests {
+		var actual pgx.TimeOfDay
+		err := conn.QueryRow(tt.sql, tt.value).Scan(&actual)
+		if err != nil {
+			t.Errorf("%d. Unexpected failure: %v (sql -> %v, value -> %v)", i, err, tt.sql, tt.value)
+			continue
+		}
+		if actual != tt.value {
+			t.Errorf("%d. Expected %v, got %v (sql -> %v)", i, tt.value, actual, tt.sql)
+		}
+
+		var s string
+		err = conn.QueryRow(tt.sql+"::text", tt.value).Scan(&s)
+		if err != nil {
+			t.Errorf("%d. Unexpected failure: %v (sql -> %v, value -> %v)", i, err, tt.sql, tt.value)
+			continue
+		}
+		if s != tt.expected {
+			t.Errorf("%d. Expected %v, got %v (sql -> %v)", i, tt.expected, s, tt.sql)
+		}
+
+		ensureConnValid(t, conn)
+	}
+
+	var actual pgx.TimeOfDay
+	input := time.Date(2016, 3, 4, 5, 6, 7, 8000, time.UTC)
+	err := conn.QueryRow("select $1::time", input).Scan(&actual)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual.Duration() != 5*time.Hour+6*time.Minute+7*time.Second+8*time.Microsecond {
+		t.Errorf("Expected %v, got %v", input, actual)
+	}
+
+	var n pgx.NullTimeOfDay
+	err = conn.QueryRow("select null::timetz").Scan(&n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n.Valid {
+		t.Errorf("Expected null, got %v", n)
+	}
+
+	ensureConnValid(t, conn)
+}
+
+func TestDateArrayTranscode(t *testing.T) {
+	t.Parallel()
+
+	conn := mustConnect(t, *defaultConnConfig)
+	defer closeConn(t, conn)
+
+	input := []time.Time{
+		time.Date(1990, 1, 1, 0, 0, 0, 0, time.Local),
+		time.Date(2000, 1, 1, 0, 0, 0, 0, time.Local),
+		time.Date(2016, 2, 29, 0, 0, 0, 0, time.Local),
+	}
+
+	var output []time.Time
+	err := conn.QueryRow("select $1::date[]", input).Scan(&output)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(input, output) {
+		t.Errorf("Expected %v, got %v", input, output)
+	}
+
+	ensureConnValid(t, conn)
+}
+
 func TestTimestampTzTranscode(t *testing.T) {
 	t.Parallel()
 
@@ -267,6 +439,253 @@ func TestStringToNotTextTypeTranscode(t *testing.T) {
 	}
 }
 
+func TestUUIDTranscode(t *testing.T) {
+	t.Parallel()
+
+	conn := mustConnect(t, *defaultConnConfig)
+	defer closeConn(t, conn)
+
+	input := pgx.UUID{0x01, 0x08, 0x6e, 0xe0, 0x49, 0x63, 0x4e, 0x35, 0x91, 0x16, 0x30, 0xc1, 0x73, 0xa8, 0xd0, 0xbd}
+	inputString := "01086ee0-4963-4e35-9116-30c173a8d0bd"
+
+	var output pgx.UUID
+	err := conn.QueryRow("select $1::uuid", input).Scan(&output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if input != output {
+		t.Errorf("Expected %v, got %v", input, output)
+	}
+
+	var outputBytes [16]byte
+	err = conn.QueryRow("select $1::uuid", [16]byte(input)).Scan(&outputBytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if [16]byte(input) != outputBytes {
+		t.Errorf("Expected %v, got %v", input, outputBytes)
+	}
+
+	var outputString string
+	err = conn.QueryRow("select $1::uuid", input).Scan(&outputString)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if outputString != inputString {
+		t.Errorf("Expected %v, got %v", inputString, outputString)
+	}
+
+	var n pgx.NullUUID
+	err = conn.QueryRow("select $1::uuid", pgx.NullUUID{}).Scan(&n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n.Valid {
+		t.Errorf("Expected null, got %v", n)
+	}
+
+	ensureConnValid(t, conn)
+}
+
+func TestUUIDArrayTranscode(t *testing.T) {
+	t.Parallel()
+
+	conn := mustConnect(t, *defaultConnConfig)
+	defer closeConn(t, conn)
+
+	input := []pgx.UUID{
+		{0x01, 0x08, 0x6e, 0xe0, 0x49, 0x63, 0x4e, 0x35, 0x91, 0x16, 0x30, 0xc1, 0x73, 0xa8, 0xd0, 0xbd},
+		{},
+		{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff},
+	}
+
+	var output []pgx.UUID
+	err := conn.QueryRow("select $1::uuid[]", input).Scan(&output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(input, output) {
+		t.Errorf("Expected %v, got %v", input, output)
+	}
+
+	var outputStrings []string
+	err = conn.QueryRow("select $1::uuid[]", input).Scan(&outputStrings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedStrings := []string{
+		"01086ee0-4963-4e35-9116-30c173a8d0bd",
+		"00000000-0000-0000-0000-000000000000",
+		"ffffffff-ffff-ffff-ffff-ffffffffffff",
+	}
+	if !reflect.DeepEqual(expectedStrings, outputStrings) {
+		t.Errorf("Expected %v, got %v", expectedStrings, outputStrings)
+	}
+
+	ensureConnValid(t, conn)
+}
+
+func TestRangeTranscode(t *testing.T) {
+	t.Parallel()
+
+	conn := mustConnect(t, *defaultConnConfig)
+	defer closeConn(t, conn)
+
+	tests := []struct {
+		sql      string
+		value    pgx.Range
+		expected pgx.Range
+	}{
+		{
+			"select $1::int4range",
+			pgx.Range{Lower: int32(1), Upper: int32(10), LowerInclusive: true, UpperInclusive: true},
+			pgx.Range{Lower: int32(1), Upper: int32(11), LowerInclusive: true},
+		},
+		{
+			"select $1::int8range",
+			pgx.Range{Lower: 1, UpperUnbounded: true},
+			pgx.Range{Lower: int64(2), LowerInclusive: true, UpperUnbounded: true},
+		},
+		{
+			"select $1::int4range",
+			pgx.Range{Lower: 5, Upper: 5, LowerInclusive: true},
+			pgx.Range{Empty: true},
+		},
+		{
+			"select $1::numrange",
+			pgx.Range{Lower: big.NewRat(1, 2), Upper: big.NewRat(5, 4), UpperInclusive: true},
+			pgx.Range{Lower: pgx.Numeric{Int: big.NewInt(5), Exp: -1, Valid: true}, Upper: pgx.Numeric{Int: big.NewInt(125), Exp: -2, Valid: true}, UpperInclusive: true},
+		},
+		{
+			"select $1::tstzrange",
+			pgx.Range{Lower: time.Date(2016, 1, 1, 9, 0, 0, 0, time.Local), Upper: time.Date(2016, 1, 1, 17, 0, 0, 0, time.Local), LowerInclusive: true},
+			pgx.Range{Lower: time.Date(2016, 1, 1, 9, 0, 0, 0, time.Local), Upper: time.Date(2016, 1, 1, 17, 0, 0, 0, time.Local), LowerInclusive: true},
+		},
+		{
+			"select $1::tsrange",
+			pgx.Range{LowerUnbounded: true, Upper: time.Date(2016, 1, 1, 17, 0, 0, 0, time.Local)},
+			pgx.Range{LowerUnbounded: true, Upper: time.Date(2016, 1, 1, 17, 0, 0, 0, time.Local)},
+		},
+		{
+			"select $1::daterange",
+			pgx.Range{Lower: time.Date(2016, 1, 1, 0, 0, 0, 0, time.Local), Upper: time.Date(2016, 1, 31, 0, 0, 0, 0, time.Local), LowerInclusive: true, UpperInclusive: true},
+			pgx.Range{Lower: time.Date(2016, 1, 1, 0, 0, 0, 0, time.Local), Upper: time.Date(2016, 2, 1, 0, 0, 0, 0, time.Local), LowerInclusive: true},
+		},
+	}
+
+	for i, tt := range tests {
+		var actual pgx.Range
+		err := conn.QueryRow(tt.sql, tt.value).Scan(&actual)
+		if err != nil {
+			t.Errorf("%d. Unexpected failure: %v (sql -> %v, value -> %v)", i, err, tt.sql, tt.value)
+			continue
+		}
+
+		if actual.Empty != tt.expected.Empty ||
+			actual.LowerInclusive != tt.expected.LowerInclusive ||
+			actual.UpperInclusive != tt.expected.UpperInclusive ||
+			actual.LowerUnbounded != tt.expected.LowerUnbounded ||
+			actual.UpperUnbounded != tt.expected.UpperUnbounded ||
+			fmt.Sprint(actual.Lower) != fmt.Sprint(tt.expected.Lower) ||
+			fmt.Sprint(actual.Upper) != fmt.Sprint(tt.expected.Upper) {
+			t.Errorf("%d. Expected %v, got %v (sql -> %v)", i, tt.expected, actual, tt.sql)
+		}
+
+		ensureConnValid(t, conn)
+	}
+
+	var n pgx.NullRange
+	err := conn.QueryRow("select null::tstzrange").Scan(&n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n.Valid {
+		t.Errorf("Expected null, got %v", n)
+	}
+
+	rows, err := conn.Query("select int4range(1, 3)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for rows.Next() {
+		values, err := rows.Values()
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := pgx.Range{Lower: int32(1), Upper: int32(3), LowerInclusive: true}
+		if !reflect.DeepEqual(values[0], expected) {
+			t.Errorf("Expected values[0] to be %v, but it was %v", expected, values[0])
+		}
+	}
+	if rows.Err() != nil {
+		t.Fatal(rows.Err())
+	}
+
+	ensureConnValid(t, conn)
+}
+
+func TestBinaryFormatTypesScanIntoString(t *testing.T) {
+	t.Parallel()
+
+	conn := mustConnect(t, *defaultConnConfig)
+	defer closeConn(t, conn)
+
+	// These types are read in binary format but scan into a string in the
+	// PostgreSQL text format
+	tests := []struct {
+		sql      string
+		expected string
+	}{
+		{"select '[1,5)'::int4range", "[1,5)"},
+		{"select '(,5]'::int8range", "(,6)"},
+		{"select 'empty'::int4range", "empty"},
+		{"select '[1.5,2.25]'::numrange", "[1.5,2.25]"},
+		{"select '[2016-01-01 09:00:00,2016-01-01 17:30:00.5)'::tsrange", `["2016-01-01 09:00:00","2016-01-01 17:30:00.5")`},
+		{"select '[2016-01-01,2016-01-31]'::daterange", "[2016-01-01,2016-02-01)"},
+		{"select '{1.5,NULL,-2}'::numeric[]", "{1.5,NULL,-2}"},
+		{"select '{{1,2},{3,4}}'::numeric[]", "{{1,2},{3,4}}"},
+		{"select '{2016-01-01,2016-02-29}'::date[]", "{2016-01-01,2016-02-29}"},
+		{"select '{}'::date[]", "{}"},
+		{"select '{a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11}'::uuid[]", "{a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11}"},
+	}
+
+	for i, tt := range tests {
+		var s string
+		if err := conn.QueryRow(tt.sql).Scan(&s); err != nil {
+			t.Errorf("%d. Unexpected failure: %v (sql -> %v)", i, err, tt.sql)
+			continue
+		}
+		if s != tt.expected {
+			t.Errorf("%d. Expected %v, got %v (sql -> %v)", i, tt.expected, s, tt.sql)
+		}
+
+		var ns sql.NullString
+		if err := conn.QueryRow(tt.sql).Scan(&ns); err != nil {
+			t.Errorf("%d. Unexpected failure: %v (sql -> %v)", i, err, tt.sql)
+			continue
+		}
+		if !ns.Valid || ns.String != tt.expected {
+			t.Errorf("%d. Expected %v, got %v (sql -> %v)", i, tt.expected, ns, tt.sql)
+		}
+
+		ensureConnValid(t, conn)
+	}
+
+	// The time zone of a tstzrange is the session time zone in PostgreSQL's
+	// text format and the local time zone here
+	var s string
+	lower := time.Date(2016, 1, 1, 9, 0, 0, 0, time.Local)
+	if err := conn.QueryRow("select tstzrange($1, null)", lower).Scan(&s); err != nil {
+		t.Fatalf("Unexpected failure: %v", err)
+	}
+	layout := "2006-01-02 15:04:05-07"
+	if _, offset := lower.Zone(); offset%3600 != 0 {
+		layout += ":00"
+	}
+	if expected := `["` + lower.Format(layout) + `",)`; s != expected {
+		t.Errorf("Expected %v, got %v", expected, s)
+	}
+}
 func TestInetCidrTranscodeIPNet(t *testing.T) {
 	t.Parallel()
 
@@ -558,6 +977,203 @@ func TestInetCidrTranscodeWithJustIP(t *testing.T) {
 	}
 }
 
+func TestNumericTranscode(t *testing.T) {
+	t.Parallel()
+
+	conn := mustConnect(t, *defaultConnConfig)
+	defer closeConn(t, conn)
+
+	tests := []string{
+		"0",
+		"1",
+		"-1",
+		"0.00001",
+		"123456789.123456789",
+		"-98765432109876543210.0123456789",
+		"100000000000000000000000000000000000000",
+		"3.14159265358979323846264338327950288419716939937510",
+	}
+
+	for i, tt := range tests {
+		expected, ok := new(big.Rat).SetString(tt)
+		if !ok {
+			t.Fatalf("%d. Invalid test value: %s", i, tt)
+		}
+
+		var actual big.Rat
+		err := conn.QueryRow("select $1::numeric", expected).Scan(&actual)
+		if err != nil {
+			t.Errorf("%d. Unexpected failure: %v (value -> %v)", i, err, tt)
+			continue
+		}
+		if actual.Cmp(expected) != 0 {
+			t.Errorf("%d. Expected %v, got %v", i, expected.RatString(), actual.RatString())
+		}
+
+		var s string
+		err = conn.QueryRow("select $1::numeric", expected).Scan(&s)
+		if err != nil {
+			t.Errorf("%d. Unexpected failure: %v (value -> %v)", i, err, tt)
+			continue
+		}
+		if s != tt {
+			t.Errorf("%d. Expected %v, got %v", i, tt, s)
+		}
+
+		ensureConnValid(t, conn)
+	}
+
+	var n big.Int
+	err := conn.QueryRow("select $1::numeric", big.NewInt(-42)).Scan(&n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n.Int64() != -42 {
+		t.Errorf("Expected %v, got %v", -42, n.String())
+	}
+
+	err = conn.QueryRow("select 1.5::numeric").Scan(&n)
+	if err == nil || !strings.Contains(err.Error(), "losing precision") {
+		t.Errorf("Expected precision error scanning 1.5 into *big.Int, got %v", err)
+	}
+
+	var f float64
+	err = conn.QueryRow("select 1.25::numeric").Scan(&f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f != 1.25 {
+		t.Errorf("Expected %v, got %v", 1.25, f)
+	}
+
+	ensureConnValid(t, conn)
+}
+
+func TestNumericSpecialValues(t *testing.T) {
+	t.Parallel()
+
+	conn := mustConnect(t, *defaultConnConfig)
+	defer closeConn(t, conn)
+
+	tests := []struct {
+		sql      string
+		expected pgx.Numeric
+	}{
+		{"select 'NaN'::numeric", pgx.Numeric{NaN: true, Valid: true}},
+		{"select null::numeric", pgx.Numeric{}},
+		{"select 12.50::numeric", pgx.Numeric{Int: big.NewInt(1250), Exp: -2, Valid: true}},
+	}
+
+	for i, tt := range tests {
+		var actual pgx.Numeric
+		err := conn.QueryRow(tt.sql).Scan(&actual)
+		if err != nil {
+			t.Errorf("%d. Unexpected failure: %v (sql -> %v)", i, err, tt.sql)
+			continue
+		}
+		if actual.String() != tt.expected.String() {
+			t.Errorf("%d. Expected %v, got %v (sql -> %v)", i, tt.expected, actual, tt.sql)
+		}
+
+		var roundTrip pgx.Numeric
+		err = conn.QueryRow("select $1::numeric", tt.expected).Scan(&roundTrip)
+		if err != nil {
+			t.Errorf("%d. Unexpected failure: %v (value -> %v)", i, err, tt.expected)
+			continue
+		}
+		if roundTrip.String() != tt.expected.String() {
+			t.Errorf("%d. Expected %v, got %v", i, tt.expected, roundTrip)
+		}
+
+		ensureConnValid(t, conn)
+	}
+
+	var f float64
+	err := conn.QueryRow("select 'NaN'::numeric").Scan(&f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !math.IsNaN(f) {
+		t.Errorf("Expected NaN, got %v", f)
+	}
+
+	// Infinity is only supported by PostgreSQL 14 and later
+	var serverVersionNum int32
+	err = conn.QueryRow("select current_setting('server_version_num')::int4").Scan(&serverVersionNum)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if serverVersionNum < 140000 {
+		return
+	}
+
+	var inf pgx.Numeric
+	err = conn.QueryRow("select $1::numeric", pgx.Numeric{Infinity: -1, Valid: true}).Scan(&inf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if inf.Infinity != -1 {
+		t.Errorf("Expected -Infinity, got %v", inf)
+	}
+
+	ensureConnValid(t, conn)
+}
+
+func TestNumericArrayTranscode(t *testing.T) {
+	t.Parallel()
+
+	conn := mustConnect(t, *defaultConnConfig)
+	defer closeConn(t, conn)
+
+	input := []*big.Rat{big.NewRat(1, 2), nil, big.NewRat(-7, 1)}
+
+	var output []*big.Rat
+	err := conn.QueryRow("select $1::numeric[]", input).Scan(&output)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(output) != len(input) {
+		t.Fatalf("Expected %d elements, got %d", len(input), len(output))
+	}
+	for i := range input {
+		if (input[i] == nil) != (output[i] == nil) || (input[i] != nil && input[i].Cmp(output[i]) != 0) {
+			t.Errorf("%d. Expected %v, got %v", i, input[i], output[i])
+		}
+	}
+
+	var numerics []pgx.Numeric
+	err = conn.QueryRow("select array[1.5, null, 'NaN']::numeric[]").Scan(&numerics)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(numerics) != 3 || numerics[0].String() != "1.5" || numerics[1].Valid || !numerics[2].NaN {
+		t.Errorf("Unexpected numerics: %v", numerics)
+	}
+
+	rows, err := conn.Query("select 1.5::numeric, array[2.5]::numeric[]")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for rows.Next() {
+		values, err := rows.Values()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n, ok := values[0].(pgx.Numeric); !ok || n.String() != "1.5" {
+			t.Errorf("Expected values[0] to be pgx.Numeric 1.5, but it was %#v", values[0])
+		}
+		if a, ok := values[1].([]pgx.Numeric); !ok || len(a) != 1 || a[0].String() != "2.5" {
+			t.Errorf("Expected values[1] to be []pgx.Numeric{2.5}, but it was %#v", values[1])
+		}
+	}
+	if rows.Err() != nil {
+		t.Fatal(rows.Err())
+	}
+
+	ensureConnValid(t, conn)
+}
+
 func TestNullX(t *testing.T) {
 	t.Parallel()
 
@@ -825,6 +1441,130 @@ func (*shortScanner) Scan(r *pgx.ValueReader) error {
 	return nil
 }
 
+func TestMultiDimensionalArrayTranscode(t *testing.T) {
+	t.Parallel()
+
+	conn := mustConnect(t, *defaultConnConfig)
+	defer closeConn(t, conn)
+
+	tests := []struct {
+		sql      string
+		query    interface{}
+		scan     interface{}
+		expected interface{}
+	}{
+		{
+			"select $1::int[]",
+			[][]int32{{1, 2, 3}, {4, 5, 6}},
+			&[][]int32{},
+			[][]int32{{1, 2, 3}, {4, 5, 6}},
+		},
+		{
+			"select $1::bigint[]",
+			[][][]int64{{{1, 2}, {3, 4}}, {{5, 6}, {7, 8}}},
+			&[][][]int64{},
+			[][][]int64{{{1, 2}, {3, 4}}, {{5, 6}, {7, 8}}},
+		},
+		{
+			"select $1::text[]",
+			[][]string{{"foo", "bar"}, {"baz", "quz"}},
+			&[][]string{},
+			[][]string{{"foo", "bar"}, {"baz", "quz"}},
+		},
+		{
+			"select $1::float8[]",
+			[][]float64{{1.5}, {2.5}},
+			&[][]float64{},
+			[][]float64{{1.5}, {2.5}},
+		},
+	}
+
+	for i, tt := range tests {
+		err := conn.QueryRow(tt.sql, tt.query).Scan(tt.scan)
+		if err != nil {
+			t.Errorf("%d. Unexpected failure: %v (sql -> %v, query -> %v)", i, err, tt.sql, tt.query)
+			continue
+		}
+
+		actual := reflect.ValueOf(tt.scan).Elem().Interface()
+		if !reflect.DeepEqual(actual, tt.expected) {
+			t.Errorf("%d. Expected %v, got %v (sql -> %v)", i, tt.expected, actual, tt.sql)
+		}
+
+		ensureConnValid(t, conn)
+	}
+
+	var actual [][]int32
+	err := conn.QueryRow("select '[0:1][-1:0]={{1,2},{3,4}}'::int[]").Scan(&actual)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := [][]int32{{1, 2}, {3, 4}}; !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected %v, got %v", expected, actual)
+	}
+
+	err = conn.QueryRow("select $1::int[]", [][]int32{{1, 2}, {3}}).Scan(&actual)
+	if err == nil {
+		t.Error("Expected error encoding non-rectangular array")
+	}
+
+	ensureConnValid(t, conn)
+}
+
+func TestArrayWithNullsTranscode(t *testing.T) {
+	t.Parallel()
+
+	conn := mustConnect(t, *defaultConnConfig)
+	defer closeConn(t, conn)
+
+	var int32s []pgx.NullInt32
+	err := conn.QueryRow("select array[1, null, 3]::int[]").Scan(&int32s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedInt32s := []pgx.NullInt32{{Int32: 1, Valid: true}, {}, {Int32: 3, Valid: true}}
+	if !reflect.DeepEqual(int32s, expectedInt32s) {
+		t.Errorf("Expected %v, got %v", expectedInt32s, int32s)
+	}
+
+	var nullStrings [][]pgx.NullString
+	err = conn.QueryRow("select $1::text[]", [][]pgx.NullString{{{String: "foo", Valid: true}, {}}, {{}, {String: "bar", Valid: true}}}).Scan(&nullStrings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedStrings := [][]pgx.NullString{{{String: "foo", Valid: true}, {}}, {{}, {String: "bar", Valid: true}}}
+	if !reflect.DeepEqual(nullStrings, expectedStrings) {
+		t.Errorf("Expected %v, got %v", expectedStrings, nullStrings)
+	}
+
+	one := int64(1)
+	var int64Ptrs []*int64
+	err = conn.QueryRow("select $1::bigint[]", []*int64{&one, nil}).Scan(&int64Ptrs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(int64Ptrs) != 2 || int64Ptrs[0] == nil || *int64Ptrs[0] != 1 || int64Ptrs[1] != nil {
+		t.Errorf("Expected [1 <nil>], got %v", int64Ptrs)
+	}
+
+	var hasNull bool
+	err = conn.QueryRow("select array_position($1::int[], null) is not null", []pgx.NullInt32{{Int32: 1, Valid: true}, {}}).Scan(&hasNull)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !hasNull {
+		t.Error("Expected encoded array to contain a null")
+	}
+
+	var int32sNotNullable []int32
+	err = conn.QueryRow("select array[1, null]::int[]").Scan(&int32sNotNullable)
+	if err == nil {
+		t.Error("Expected error scanning array with null into []int32")
+	}
+
+	ensureConnValid(t, conn)
+}
+
 func TestShortScanner(t *testing.T) {
 	t.Parallel()
 